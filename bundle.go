@@ -0,0 +1,258 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A bundle is a gzipped tarball with the spec files in specs/, the
+// documentation sets in docs/ and a MANIFEST listing the SHA-256 of every
+// other file in the format of sha256sum. It pins exactly the registry and
+// docs a project is generated from and can be mirrored to machines
+// without internet access.
+const (
+	bundleManifestFile = "MANIFEST"
+	bundleSpecDir      = "specs"
+	bundleDocDir       = "docs"
+)
+
+func isTarball(file string) bool {
+	return strings.HasSuffix(file, ".tar.gz") || strings.HasSuffix(file, ".tgz") || strings.HasSuffix(file, ".tar")
+}
+
+// readTarball returns the regular files of a tarball by slash separated
+// path. Tarballs ending in .gz or .tgz are decompressed.
+func readTarball(file string) (map[string][]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if !strings.HasSuffix(file, ".tar") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	files := make(map[string][]byte)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file, err)
+		}
+		if !hdr.FileInfo().Mode().IsRegular() {
+			continue
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file, err)
+		}
+		files[strings.TrimPrefix(path.Clean(hdr.Name), "./")] = data
+	}
+	return files, nil
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// bundleFiles returns the files to bundle by their path in the bundle.
+// All spec files found in specDir and the DocBook files of every
+// documentation set in docDir are included.
+func bundleFiles(specDir, docDir string) (map[string]string, error) {
+	files := make(map[string]string)
+	for _, sf := range allSpecFiles {
+		p := filepath.Join(specDir, sf)
+		if _, err := os.Stat(p); err == nil {
+			files[bundleSpecDir+"/"+sf] = p
+		}
+	}
+	if _, ok := files[bundleSpecDir+"/"+openGLSpecFile]; !ok {
+		return nil, fmt.Errorf("%s not found in %s", openGLSpecFile, specDir)
+	}
+	sets, err := ioutil.ReadDir(docDir)
+	if os.IsNotExist(err) {
+		fmt.Printf("No documentation found in %s\n", docDir)
+		return files, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, set := range sets {
+		if !set.IsDir() || skipScanDir(set.Name()) {
+			continue
+		}
+		fis, err := ioutil.ReadDir(filepath.Join(docDir, set.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, fi := range fis {
+			if !fi.IsDir() && strings.HasSuffix(fi.Name(), ".xml") {
+				files[bundleDocDir+"/"+set.Name()+"/"+fi.Name()] = filepath.Join(docDir, set.Name(), fi.Name())
+			}
+		}
+	}
+	return files, nil
+}
+
+// WriteBundle writes a bundle of the specs and docs. The output only
+// depends on the content of the files, so equal inputs give equal bundles.
+func WriteBundle(w io.Writer, specDir, docDir string) error {
+	files, err := bundleFiles(specDir, docDir)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(files))
+	for n := range files {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	writeEntry := func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: time.Unix(0, 0), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	var manifest bytes.Buffer
+	fmt.Fprintf(&manifest, "# gogl2 %s bundle, verify with: sha256sum -c %s\n", generatorVersion, bundleManifestFile)
+	contents := make([][]byte, len(names))
+	for i, n := range names {
+		data, err := ioutil.ReadFile(files[n])
+		if err != nil {
+			return err
+		}
+		contents[i] = data
+		fmt.Fprintf(&manifest, "%s  %s\n", sha256Hex(data), n)
+	}
+	if err := writeEntry(bundleManifestFile, manifest.Bytes()); err != nil {
+		return err
+	}
+	for i, n := range names {
+		if err := writeEntry(n, contents[i]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// CreateBundle writes a bundle of the specs and docs to file.
+func CreateBundle(file, specDir, docDir string) error {
+	w, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	err = WriteBundle(w, specDir, docDir)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(file)
+	}
+	return err
+}
+
+// parseManifest returns the checksums of a manifest by file name.
+func parseManifest(data []byte) (map[string]string, error) {
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 || len(fields[0]) != sha256.Size*2 {
+			return nil, fmt.Errorf("%s:%d: malformed line: %s", bundleManifestFile, lineNo, line)
+		}
+		sums[fields[1]] = fields[0]
+	}
+	return sums, scanner.Err()
+}
+
+// ReadBundle reads a bundle and checks every file against the manifest.
+func ReadBundle(file string) (map[string][]byte, error) {
+	files, err := readTarball(file)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := files[bundleManifestFile]
+	if !ok {
+		return nil, fmt.Errorf("%s: no %s, not a gogl2 bundle", file, bundleManifestFile)
+	}
+	sums, err := parseManifest(m)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+	delete(files, bundleManifestFile)
+	for name, data := range files {
+		sum, ok := sums[name]
+		if !ok {
+			return nil, fmt.Errorf("%s: %s is not listed in the manifest", file, name)
+		}
+		if sha256Hex(data) != sum {
+			return nil, fmt.Errorf("%s: checksum mismatch for %s", file, name)
+		}
+	}
+	for name := range sums {
+		if _, ok := files[name]; !ok {
+			return nil, fmt.Errorf("%s: %s is missing", file, name)
+		}
+	}
+	return files, nil
+}
+
+// ExtractBundle verifies a bundle and extracts it into dir. It returns
+// the spec and doc directories.
+func ExtractBundle(file, dir string) (string, string, error) {
+	files, err := ReadBundle(file)
+	if err != nil {
+		return "", "", err
+	}
+	for name, data := range files {
+		if path.IsAbs(name) || strings.HasPrefix(name, "../") {
+			return "", "", fmt.Errorf("%s: invalid file name %s", file, name)
+		}
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return "", "", err
+		}
+		if err := ioutil.WriteFile(p, data, 0644); err != nil {
+			return "", "", err
+		}
+	}
+	return filepath.Join(dir, bundleSpecDir), filepath.Join(dir, bundleDocDir), nil
+}
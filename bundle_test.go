@@ -0,0 +1,164 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestTarball(t *testing.T, file string, files map[string]string) {
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	if err := ioutil.WriteFile(file, b.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogl2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "bundle.tar.gz")
+	if err := CreateBundle(file, "testdata", "testdata/docs"); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := ioutil.ReadFile(file)
+	if err := CreateBundle(file, "testdata", "testdata/docs"); err != nil {
+		t.Fatal(err)
+	}
+	if second, _ := ioutil.ReadFile(file); !bytes.Equal(first, second) {
+		t.Errorf("CreateBundle() is not reproducible")
+	}
+	specDir, docDir, err := ExtractBundle(file, filepath.Join(dir, "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{"gl.xml"} {
+		compareFiles(t, filepath.Join("testdata", f), filepath.Join(specDir, f))
+	}
+	for _, f := range []string{"gl4/glShaderSource.xml", "es2.0/glDeleteShader.xml", "man2/index.xml"} {
+		compareFiles(t, filepath.Join("testdata/docs", f), filepath.Join(docDir, f))
+	}
+	d, err := ParseAllDocs(docDir)
+	if err != nil || len(d.CommandDocs) != 3 {
+		t.Errorf("ParseAllDocs() of bundle = %v, %v", d, err)
+	}
+}
+
+func compareFiles(t *testing.T, expected, actual string) {
+	e, err := ioutil.ReadFile(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := ioutil.ReadFile(actual)
+	if err != nil {
+		t.Errorf("%s: %s", actual, err)
+		return
+	}
+	if !bytes.Equal(e, a) {
+		t.Errorf("%s differs from %s", actual, expected)
+	}
+}
+
+const testSum = "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae" // sha256 of "foo"
+
+type testBundle struct {
+	Files map[string]string
+	Err   string // expected error substring, empty if valid
+}
+
+var allTestsBundle = []testBundle{
+	{map[string]string{"MANIFEST": testSum + "  specs/gl.xml\n", "specs/gl.xml": "foo"}, ""},
+	{map[string]string{"specs/gl.xml": "foo"}, "not a gogl2 bundle"},
+	{map[string]string{"MANIFEST": testSum + "  specs/gl.xml\n", "specs/gl.xml": "bar"}, "checksum mismatch for specs/gl.xml"},
+	{map[string]string{"MANIFEST": testSum + "  specs/gl.xml\n", "specs/gl.xml": "foo", "specs/egl.xml": "foo"}, "specs/egl.xml is not listed"},
+	{map[string]string{"MANIFEST": testSum + "  specs/gl.xml\n" + testSum + "  docs/gl4/glA.xml\n", "specs/gl.xml": "foo"}, "docs/gl4/glA.xml is missing"},
+	{map[string]string{"MANIFEST": "1234  specs/gl.xml\n", "specs/gl.xml": "foo"}, "MANIFEST:1: malformed line"},
+}
+
+func TestReadBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogl2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "bundle.tgz")
+	for i := range allTestsBundle {
+		te := &allTestsBundle[i]
+		writeTestTarball(t, file, te.Files)
+		_, err := ReadBundle(file)
+		if te.Err == "" && err != nil {
+			t.Errorf("ReadBundle() failed: %v: %v", te.Files, err)
+		}
+		if te.Err != "" && (err == nil || !strings.Contains(err.Error(), te.Err)) {
+			t.Errorf("ReadBundle() failed: %v: expected error '%s', got %v", te.Files, te.Err, err)
+		}
+	}
+}
+
+func TestSpecSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogl2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tarball := filepath.Join(dir, "registry.tar.gz")
+	writeTestTarball(t, tarball, map[string]string{
+		"OpenGL-Registry-main/xml/gl.xml":  "gl",
+		"OpenGL-Registry-main/xml/glx.xml": "glx",
+		"a/wgl.xml":                        "wgl1",
+		"b/wgl.xml":                        "wgl2",
+	})
+	for _, src := range []string{"file://" + tarball, tarball, "file://" + dir, "testdata"} {
+		s, err := newSpecSource(src)
+		if err != nil {
+			t.Errorf("newSpecSource(%s) failed: %s", src, err)
+			continue
+		}
+		out := filepath.Join(dir, "out")
+		os.RemoveAll(out)
+		err = downloadAllSpecs(s, out)
+		if src == "file://"+dir {
+			if err == nil {
+				t.Errorf("downloadAllSpecs(%s) without gl.xml succeeded", src)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("downloadAllSpecs(%s) failed: %s", src, err)
+		}
+		if _, err := os.Stat(filepath.Join(out, "gl.xml")); err != nil {
+			t.Errorf("downloadAllSpecs(%s): %s", src, err)
+		}
+	}
+	s, _ := newSpecSource(tarball)
+	if _, err := s.ReadFile("wgl.xml"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("ReadFile(wgl.xml) = %v, expected ambiguous", err)
+	}
+	if data, err := s.ReadFile("xml/glx.xml"); err != nil || string(data) != "glx" {
+		t.Errorf("ReadFile(xml/glx.xml) = %q, %v", data, err)
+	}
+	if _, err := newSpecSource(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("newSpecSource() of a missing directory succeeded")
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	backendFuncPtr = "funcptr" // load commands with glt.GetProcAddress (default)
+	backendStatic  = "static"  // link commands directly
+)
+
+// PackageSpec describes one generated package.
+type PackageSpec struct {
+	Name       string // package name, overrides the -pkg template
+	Api        string
+	Version    Version
+	Select     VersionSelector // "latest" or a range of versions, resolved against the registry
+	Profile    string
+	Extensions []string
+	Include    []string // only generate these commands and enums
+	Exclude    []string // never generate these commands
+	Scan       string   // only generate commands and enums used by Go code below this directory
+	Import     string   // import path of the package in the scanned code
+	Path       string   // package directory, overrides the -pkgpath template
+	Backend    string
+}
+
+// Config is the content of a gogl2 project configuration file. Relative
+// directories and files are resolved against the directory of the
+// configuration file, e.g.:
+//
+//	sdir = "glspecs"
+//	odir = "render"
+//	glt  = "ourco.com/render/glt"
+//
+//	[[package]]
+//	name       = "gl33"
+//	api        = "gl"
+//	version    = "3.3"      # or "latest" or a range like "3.3-4.6"
+//	profile    = "core"
+//	extensions = ["GL_ARB_debug_output"]
+//	exclude    = ["glGetPointerv"]
+//	path       = "gl33"
+//	scan       = "."
+//	import     = "ourco.com/render/gl33"
+type Config struct {
+	SpecDir  string
+	DocDir   string
+	Bundle   string // spec and doc bundle, replaces SpecDir and DocDir
+	Lock     string // registry lock file
+	Options  GenOptions
+	Packages []PackageSpec
+}
+
+var validProfiles = []string{"core", "compatibility", "common", "common-lite"}
+var validBackends = []string{backendFuncPtr, backendStatic}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// configReader decodes toml values and records the first error.
+type configReader struct {
+	file string
+	err  error
+}
+
+func (cr *configReader) errorf(v *tomlValue, format string, args ...interface{}) {
+	if cr.err == nil {
+		cr.err = &tomlError{File: cr.file, Line: v.Line, Msg: fmt.Sprintf(format, args...)}
+	}
+}
+
+func (cr *configReader) checkKeys(t tomlTable, where string, keys ...string) {
+	names := make([]string, 0, len(t))
+	for k := range t {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		if !contains(keys, k) {
+			cr.errorf(t[k], "unknown key '%s' in %s (valid keys: %s)", k, where, strings.Join(keys, ", "))
+		}
+	}
+}
+
+func (cr *configReader) str(t tomlTable, key, def string) string {
+	v, ok := t[key]
+	if !ok {
+		return def
+	}
+	s, ok := v.Value.(string)
+	if !ok {
+		cr.errorf(v, "'%s' must be a string", key)
+	}
+	return s
+}
+
+func (cr *configReader) strList(t tomlTable, key string) []string {
+	v, ok := t[key]
+	if !ok {
+		return nil
+	}
+	arr, ok := v.Value.([]interface{})
+	if !ok {
+		cr.errorf(v, "'%s' must be an array of strings", key)
+		return nil
+	}
+	list := make([]string, 0, len(arr))
+	for _, a := range arr {
+		s, ok := a.(string)
+		if !ok {
+			cr.errorf(v, "'%s' must be an array of strings", key)
+			return nil
+		}
+		list = append(list, s)
+	}
+	return list
+}
+
+func (cr *configReader) packageSpec(pos *tomlValue, index int) PackageSpec {
+	t := pos.Value.(tomlTable)
+	where := fmt.Sprintf("package #%d", index+1)
+	cr.checkKeys(t, where, "name", "api", "version", "profile", "extensions", "include", "exclude", "scan", "import", "path", "backend")
+	ps := PackageSpec{
+		Name:       cr.str(t, "name", ""),
+		Api:        cr.str(t, "api", ""),
+		Profile:    cr.str(t, "profile", ""),
+		Extensions: cr.strList(t, "extensions"),
+		Include:    cr.strList(t, "include"),
+		Exclude:    cr.strList(t, "exclude"),
+		Scan:       cr.str(t, "scan", ""),
+		Import:     cr.str(t, "import", ""),
+		Path:       cr.str(t, "path", ""),
+		Backend:    cr.str(t, "backend", backendFuncPtr),
+	}
+	if ps.Api == "" {
+		cr.errorf(pos, "%s: 'api' is required", where)
+	}
+	if v, ok := t["version"]; !ok {
+		cr.errorf(pos, "%s: 'version' is required", where)
+	} else {
+		vs, err := ParseVersionSelector(cr.str(t, "version", ""))
+		if err != nil {
+			cr.errorf(v, "%s: %s", where, err)
+		}
+		if vs.Single() {
+			ps.Version = vs.Min
+		} else {
+			ps.Select = vs
+		}
+	}
+	if ps.Name != "" && !token.IsIdentifier(ps.Name) {
+		cr.errorf(t["name"], "%s: '%s' is not a valid Go package name", where, ps.Name)
+	}
+	if ps.Profile != "" && !contains(validProfiles, ps.Profile) {
+		cr.errorf(t["profile"], "%s: unknown profile '%s' (valid profiles: %s)", where, ps.Profile, strings.Join(validProfiles, ", "))
+	}
+	if !contains(validBackends, ps.Backend) {
+		cr.errorf(t["backend"], "%s: unknown backend '%s' (valid backends: %s)", where, ps.Backend, strings.Join(validBackends, ", "))
+	}
+	if filepath.IsAbs(ps.Path) || strings.HasPrefix(filepath.Clean(ps.Path), "..") {
+		cr.errorf(t["path"], "%s: path '%s' must be relative to the output directory", where, ps.Path)
+	}
+	if (ps.Scan == "") != (ps.Import == "") {
+		cr.errorf(pos, "%s: 'scan' and 'import' must be used together", where)
+	}
+	for _, in := range ps.Include {
+		if contains(ps.Exclude, in) {
+			cr.errorf(t["exclude"], "%s: command %s is both included and excluded", where, in)
+		}
+	}
+	return ps
+}
+
+// ParseConfig reads and validates a project configuration file.
+func ParseConfig(file string) (*Config, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	root, err := parseToml(file, f)
+	if err != nil {
+		return nil, err
+	}
+	cr := &configReader{file: file}
+	cr.checkKeys(root, "configuration", "sdir", "ddir", "bundle", "lock", "odir", "pkg", "pkgpath", "glt", "docset", "package")
+	c := &Config{
+		SpecDir: cr.str(root, "sdir", "glspecs"),
+		DocDir:  cr.str(root, "ddir", "gldocs"),
+		Bundle:  cr.str(root, "bundle", ""),
+		Lock:    cr.str(root, "lock", defaultLockFile),
+		Options: *DefaultGenOptions(),
+	}
+	c.Options.OutDir = cr.str(root, "odir", c.Options.OutDir)
+	c.Options.Name = cr.str(root, "pkg", c.Options.Name)
+	c.Options.Path = cr.str(root, "pkgpath", c.Options.Path)
+	c.Options.GltImport = cr.str(root, "glt", c.Options.GltImport)
+	c.Options.DocSet = cr.str(root, "docset", "")
+	pv, ok := root["package"]
+	if !ok {
+		return nil, fmt.Errorf("%s: no [[package]] defined", file)
+	}
+	tables, ok := pv.Value.([]*tomlValue)
+	if !ok {
+		cr.errorf(pv, "'package' must be an array of tables ([[package]])")
+		return nil, cr.err
+	}
+	paths := make(map[string]int)
+	for i, t := range tables {
+		ps := cr.packageSpec(t, i)
+		if ps.Path != "" {
+			clean := filepath.Clean(ps.Path)
+			if j, ok := paths[clean]; ok {
+				cr.errorf(t.Value.(tomlTable)["path"], "package #%d: path '%s' is already used by package #%d", i+1, ps.Path, j+1)
+			}
+			paths[clean] = i
+		}
+		c.Packages = append(c.Packages, ps)
+	}
+	if cr.err != nil {
+		return nil, cr.err
+	}
+	// paths are relative to the configuration file, not to the working
+	// directory, so the file gives the same result wherever it is used
+	dir := filepath.Dir(file)
+	for _, p := range []*string{&c.SpecDir, &c.DocDir, &c.Bundle, &c.Lock, &c.Options.OutDir} {
+		*p = resolvePath(dir, *p)
+	}
+	for i := range c.Packages {
+		c.Packages[i].Scan = resolvePath(dir, c.Packages[i].Scan)
+	}
+	return c, nil
+}
+
+// resolvePath returns path relative to dir, or path if it is empty or
+// absolute.
+func resolvePath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
@@ -0,0 +1,128 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testConfig struct {
+	In  string
+	Err string // expected error substring, empty if valid
+}
+
+var allTestsConfig = []testConfig{
+	{"[[package]]\napi = \"gl\"\nversion = \"3.3\"\n", ""},
+	{"odir = \"out\" # comment\n[[package]]\napi = \"gl\"\nversion = \"3.3\"\nextensions = [\n \"GL_ARB_a\", # a\n 'GL_ARB_b',\n]\n", ""},
+	{"", "no [[package]] defined"},
+	{"[[package]]\nversion = \"3.3\"\n", ":1: package #1: 'api' is required"},
+	{"[[package]]\napi = \"gl\"\n", "'version' is required"},
+	{"[[package]]\napi = \"gl\"\nversion = \"3\"\n", ":3: package #1: Invalid version string"},
+	{"[[package]]\napi = \"gl\"\nversion = \"3.3\"\nprofil = \"core\"\n", ":4: unknown key 'profil'"},
+	{"[[package]]\napi = \"gl\"\nversion = \"3.3\"\nprofile = \"cor\"\n", "unknown profile 'cor'"},
+	{"[[package]]\napi = \"gl\"\nversion = \"3.3\"\nbackend = \"dll\"\n", "unknown backend 'dll'"},
+	{"[[package]]\napi = \"gl\"\nversion = \"3.3\"\nname = \"gl-33\"\n", "not a valid Go package name"},
+	{"[[package]]\napi = \"gl\"\nversion = 3\n", "'version' must be a string"},
+	{"[[package]]\napi = \"gl\"\nversion = \"3.3-4.6\"\n[[package]]\napi = \"gles2\"\nversion = \"latest\"\n", ""},
+	{"[[package]]\napi = \"gl\"\nversion = \"4.6-3.3\"\n", ":3: package #1: Invalid version range"},
+	{"[[package]]\napi = \"gl\"\nversion = \"3.3\"\ninclude = [\"glA\"]\nexclude = [\"glA\"]\n", "both included and excluded"},
+	{"[[package]]\napi = \"gl\"\nversion = \"3.3\"\npath = \"a\"\n[[package]]\napi = \"gl\"\nversion = \"3.2\"\npath = \"a/\"\n", ":8: package #2: path 'a/' is already used by package #1"},
+	{"[[package]]\napi = \"gl\"\nversion = \"3.3\"\npath = \"../a\"\n", "must be relative"},
+	{"[[package]]\napi = \"gl\nversion = \"3.3\"\n", ":2: key 'api': unterminated string"},
+	{"[[package]]\napi = \"gl\"\nextensions = [\"a\"\n", "unterminated array"},
+}
+
+func TestParseConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogl2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "gogl2.toml")
+	for i := range allTestsConfig {
+		te := &allTestsConfig[i]
+		if err := ioutil.WriteFile(file, []byte(te.In), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := ParseConfig(file)
+		if te.Err == "" && err != nil {
+			t.Errorf("ParseConfig() failed: %q: %v", te.In, err)
+		}
+		if te.Err != "" && (err == nil || !strings.Contains(err.Error(), te.Err)) {
+			t.Errorf("ParseConfig() failed: %q: expected error '%s', got %v", te.In, te.Err, err)
+		}
+	}
+}
+
+func TestParseConfigPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogl2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "gogl2.toml")
+	in := "sdir = \"specs\"\nodir = \"/abs/out\"\nbundle = \"b.tar.gz\"\n[[package]]\napi = \"gl\"\nversion = \"3.3\"\nscan = \".\"\nimport = \"x/gl\"\npath = \"gl33\"\n"
+	if err := ioutil.WriteFile(file, []byte(in), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// the working directory of the test is not dir
+	c, err := ParseConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []struct{ got, want string }{
+		{c.SpecDir, filepath.Join(dir, "specs")},
+		{c.DocDir, filepath.Join(dir, "gldocs")},
+		{c.Bundle, filepath.Join(dir, "b.tar.gz")},
+		{c.Lock, filepath.Join(dir, defaultLockFile)},
+		{c.Options.OutDir, "/abs/out"},
+		{c.Packages[0].Scan, dir},
+		{c.Packages[0].Path, "gl33"},
+	} {
+		if p.got != p.want {
+			t.Errorf("path %s, want %s", p.got, p.want)
+		}
+	}
+}
+
+var allTestsGenerateArgs = []struct {
+	Args []string
+	Err  string // expected error substring, empty if valid
+}{
+	{[]string{"-config=CONFIG"}, ""},
+	{[]string{"-config=CONFIG", "-unlocked"}, ""},
+	{[]string{"-config=CONFIG", "-f=gl:3.3"}, "-f and -config"},
+	{[]string{"-odir=out", "-config=CONFIG", "-pkg=gl"}, "-odir, -pkg and -config"},
+	{[]string{"-config=CONFIG", "-pkgpath=gl", "-glt=x/glt", "-docset=gl4"}, "-docset, -glt, -pkgpath and -config"},
+}
+
+func TestParseGenerateArgsConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogl2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "gogl2.toml")
+	if err := ioutil.WriteFile(file, []byte("[[package]]\napi = \"gl\"\nversion = \"3.3\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, te := range allTestsGenerateArgs {
+		args := make([]string, len(te.Args))
+		for i, a := range te.Args {
+			args[i] = strings.Replace(a, "CONFIG", file, 1)
+		}
+		ga, err := parseGenerateArgs("generate", args)
+		if te.Err == "" && (err != nil || ga.unlocked != (len(args) > 1)) {
+			t.Errorf("parseGenerateArgs(%v) = %+v, %v", te.Args, ga, err)
+		}
+		if te.Err != "" && (err == nil || !strings.Contains(err.Error(), te.Err)) {
+			t.Errorf("parseGenerateArgs(%v): expected error '%s', got %v", te.Args, te.Err, err)
+		}
+	}
+}
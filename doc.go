@@ -8,18 +8,26 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
-	"strconv"
 	"strings"
+	"unicode"
 )
 
 type docRefEntry struct {
-	XMLName    xml.Name `xml:"refentry"`
-	Id         string   `xml:"id,attr"`
-	RefName    string   `xml:"refnamediv>refname"`
-	RefPurpose string   `xml:"refnamediv>refpurpose"`
+	XMLName    xml.Name     `xml:"refentry"`
+	Id         string       `xml:"id,attr"`
+	RefNames   []string     `xml:"refnamediv>refname"`
+	RefPurpose string       `xml:"refnamediv>refpurpose"`
+	Sections   []docSection `xml:"refsect1"`
+}
+
+type docSection struct {
+	Id      string   `xml:"id,attr"`
+	SeeAlso []string `xml:"para>citerefentry>refentrytitle"`
+	Inner   []byte   `xml:",innerxml"`
 }
 
 type docSvnIndex struct {
@@ -36,15 +44,45 @@ type docFile struct {
 	FileName string
 }
 
+type ParamDoc struct {
+	Names []string // parameter names as used in the generated code
+	Desc  string
+}
+
 type CommandDoc struct {
-	BaseName string
-	Purpose  string
-	// TODO: more?
+	BaseName    string   // page name without prefix, e.g. "Uniform"
+	Names       []string // documented commands without prefix, e.g. "Uniform1f"
+	Purpose     string
+	Params      []ParamDoc
+	Description []docBlock
+	Errors      []docBlock
+	SeeAlso     []string // command names without prefix
 }
 
+// CommandDocs holds the pages of one documentation set. A set is a
+// directory of DocBook files, either from the Khronos OpenGL-Refpages
+// repository (gl2.1, gl4, es1.1, es2.0, es3.0, es3.1, es3) or from the old
+// SVN layout with an index.xml (man2, man3, man4).
 type CommandDocs struct {
-	MajorVersion int
-	Commands     []*CommandDoc
+	Set      string
+	Commands []*CommandDoc
+	byName   map[string]*CommandDoc
+}
+
+// newCommandDocs sorts the pages of a set and indexes them by the names of
+// the commands they document. If several pages document the same command
+// the first one wins.
+func newCommandDocs(set string, cmds []*CommandDoc) CommandDocs {
+	cd := CommandDocs{Set: set, Commands: cmds, byName: make(map[string]*CommandDoc)}
+	sort.Sort(cd)
+	for _, c := range cd.Commands {
+		for _, n := range c.Names {
+			if _, ok := cd.byName[n]; !ok {
+				cd.byName[n] = c
+			}
+		}
+	}
+	return cd
 }
 
 type Documentation struct {
@@ -66,24 +104,73 @@ func writeSgiDocCopyright(w io.Writer) {
 	fmt.Fprintln(w, "//")
 }
 
-func makeCmdDocUrl(cmdName string, majorVersion int) string {
-	manVer := "2"
-	if majorVersion >= 3 {
-		manVer = strconv.Itoa(majorVersion)
+const khronosRefpagesURL = "https://registry.khronos.org/OpenGL-Refpages"
+
+// makeCmdDocUrl returns the online reference page for a page of a set.
+// The old SVN sets are mapped to their successors in the refpages repo.
+func makeCmdDocUrl(set, pageName string) string {
+	switch set {
+	case "man2", "gl2.1":
+		return fmt.Sprintf("%s/gl2.1/xhtml/gl%s.xml", khronosRefpagesURL, pageName)
+	case "es1.1", "es2.0":
+		return fmt.Sprintf("%s/%s/xhtml/gl%s.xml", khronosRefpagesURL, set, pageName)
+	case "man3", "man4":
+		set = "gl4"
 	}
-	return fmt.Sprintf("https://www.opengl.org/sdk/docs/man%s/xhtml/gl%s.xml", manVer, cmdName)
+	return fmt.Sprintf("%s/%s/html/gl%s.xhtml", khronosRefpagesURL, set, pageName)
 }
 
-func makeGLDocUrl(majorVersion int) string {
-	manVer := "2"
-	if majorVersion >= 3 {
-		manVer = strconv.Itoa(majorVersion)
+func makeExtenionSpecDocUrl(vendor, extension string) string {
+	return fmt.Sprintf("https://registry.khronos.org/OpenGL/extensions/%s/%s.txt", vendor, extension)
+}
+
+// docSetsFor returns the names of the documentation sets describing
+// api in the given version, best match first.
+func docSetsFor(api string, version Version) []string {
+	switch api {
+	case "gl":
+		switch version.Major {
+		case 1, 2:
+			return []string{"gl2.1", "man2", "gl4", "man4"}
+		case 3:
+			return []string{"man3", "gl4", "man4"}
+		}
+		return []string{"gl4", "man4"}
+	case "gles1":
+		return []string{"es1.1"}
+	case "gles2":
+		switch {
+		case version.Major < 3:
+			return []string{"es2.0", "es3"}
+		case version.Major == 3 && version.Minor == 0:
+			return []string{"es3.0", "es3"}
+		case version.Major == 3 && version.Minor == 1:
+			return []string{"es3.1", "es3"}
+		}
+		return []string{"es3"}
 	}
-	return fmt.Sprintf("https://www.opengl.org/sdk/docs/man%s", manVer)
+	return nil
 }
 
-func makeExtenionSpecDocUrl(vendor, extension string) string {
-	return fmt.Sprintf("https://www.opengl.org/registry/specs/%s/%s.txt", vendor, extension)
+// DocSet returns the name of the best available documentation set for
+// api in the given version or "" if there is none.
+func (d *Documentation) DocSet(api string, version Version) string {
+	for _, set := range docSetsFor(api, version) {
+		if d.hasSet(set) {
+			return set
+		}
+	}
+	return ""
+}
+
+// hasSet reports whether the documentation set is available.
+func (d *Documentation) hasSet(set string) bool {
+	for _, cd := range d.CommandDocs {
+		if cd.Set == set {
+			return true
+		}
+	}
+	return false
 }
 
 func (cd CommandDocs) Len() int {
@@ -98,39 +185,116 @@ func (cd CommandDocs) Less(i, j int) bool {
 	return cd.Commands[i].BaseName < cd.Commands[j].BaseName
 }
 
-func (d *Documentation) findCmd(majorVersion int, cmdName string) (*CommandDoc, error) {
-	if majorVersion == 1 {
-		majorVersion = 2
-	}
+func (d *Documentation) findCmd(set string, cmdName string) (*CommandDoc, error) {
 	for _, cd := range d.CommandDocs {
-		if cd.MajorVersion == majorVersion {
-			index := sort.Search(len(cd.Commands), func(i int) bool {
-				return cd.Commands[i].BaseName >= cmdName
-			})
-			if index == len(cd.Commands) {
-				return nil, fmt.Errorf("Command doc not found: %s, version %d", cmdName, majorVersion)
-			}
-			if strings.HasPrefix(cmdName, cd.Commands[index].BaseName) {
-				return cd.Commands[index], nil
-			}
-			if index == 0 {
-				return nil, fmt.Errorf("Command doc not found: %s, version %d", cmdName, majorVersion)
+		if cd.Set == set {
+			if c, ok := cd.byName[cmdName]; ok {
+				return c, nil
 			}
-			if strings.HasPrefix(cmdName, cd.Commands[index-1].BaseName) {
-				return cd.Commands[index-1], nil
+			return nil, fmt.Errorf("Command doc not found: %s, set %s", cmdName, set)
+		}
+	}
+	return nil, fmt.Errorf("Doc set not found: %s", set)
+}
+
+// undocumented returns the names of the functions without a page in set.
+func (d *Documentation) undocumented(set string, fs Functions) []string {
+	names := make([]string, 0, 16)
+	for _, f := range fs {
+		if _, err := d.findCmd(set, f.Name); err != nil {
+			names = append(names, f.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (cd *CommandDoc) paramDesc(name string) (string, bool) {
+	for _, pd := range cd.Params {
+		for _, n := range pd.Names {
+			if n == RenameIfReservedGoWord(name) {
+				return pd.Desc, true
 			}
-			return nil, fmt.Errorf("Command doc not found: %s, version %d", cmdName, majorVersion)
 		}
 	}
-	return nil, fmt.Errorf("Version not found %d", majorVersion)
+	return "", false
+}
+
+// docSentence turns the purpose of a command, an imperative phrase like
+// "delete a shader object", into the first sentence of a Go doc comment
+// that starts with name: "DeleteShader deletes a shader object.".
+func docSentence(name, purpose string) string {
+	words := strings.Fields(strings.TrimSuffix(strings.TrimSpace(purpose), "."))
+	if len(words) == 0 {
+		return name + "."
+	}
+	if len(words[0]) < 2 || !unicode.IsUpper(rune(words[0][1])) {
+		words[0] = strings.ToLower(words[0][:1]) + words[0][1:]
+	}
+	words[0] = thirdPerson(words[0])
+	// "push and pop", "enable or disable"
+	if len(words) > 2 && (words[1] == "and" || words[1] == "or") {
+		words[2] = thirdPerson(words[2])
+	}
+	return name + " " + strings.Join(words, " ") + "."
+}
+
+// thirdPerson returns the third person singular of the verb v.
+func thirdPerson(v string) string {
+	switch {
+	case !unicode.IsLower(rune(v[0])):
+		// an acronym or a name, not a verb
+	case strings.HasSuffix(v, "s") && !strings.HasSuffix(v, "ss"):
+		// already "replaces"
+	case len(v) > 1 && strings.HasSuffix(v, "y") && !strings.ContainsRune("aeiou", rune(v[len(v)-2])):
+		return v[:len(v)-1] + "ies"
+	case strings.HasSuffix(v, "s"), strings.HasSuffix(v, "x"), strings.HasSuffix(v, "z"),
+		strings.HasSuffix(v, "ch"), strings.HasSuffix(v, "sh"), strings.HasSuffix(v, "o"):
+		return v + "es"
+	default:
+		return v + "s"
+	}
+	return v
 }
 
-func (d *Documentation) WriteGoCmdDoc(w io.Writer, cmdName string, majorVersion int) error {
-	cd, err := d.findCmd(majorVersion, cmdName)
+func (d *Documentation) WriteGoCmdDoc(w io.Writer, f *Function, set string) error {
+	cd, err := d.findCmd(set, f.Name)
 	if err != nil {
 		return err
 	}
-	fmt.Fprintf(w, "// %s (%s)\n", cd.Purpose, makeCmdDocUrl(cd.BaseName, majorVersion))
+	writeDocText(w, "", docSentence(f.Name, cd.Purpose), 76)
+	params := make([]docBlock, 0, len(f.Parameters))
+	for _, p := range f.Parameters {
+		if desc, ok := cd.paramDesc(p.Name); ok {
+			params = append(params, docBlock{Kind: docBlockItem, Term: RenameIfReservedGoWord(p.Name), Text: desc})
+		}
+	}
+	if len(params) > 0 {
+		fmt.Fprintln(w, "//")
+		fmt.Fprintln(w, "// Parameters:")
+		fmt.Fprintln(w, "//")
+		writeDocBlocks(w, params)
+	}
+	if len(cd.Description) > 0 {
+		fmt.Fprintln(w, "//")
+		writeDocBlocks(w, cd.Description)
+	}
+	if len(cd.Errors) > 0 {
+		fmt.Fprintln(w, "//")
+		fmt.Fprintln(w, "// Errors:")
+		fmt.Fprintln(w, "//")
+		writeDocBlocks(w, cd.Errors)
+	}
+	if len(cd.SeeAlso) > 0 {
+		links := make([]string, len(cd.SeeAlso))
+		for i, sa := range cd.SeeAlso {
+			links[i] = "[" + sa + "]"
+		}
+		fmt.Fprintln(w, "//")
+		writeDocText(w, "", "See also "+strings.Join(links, ", ")+".", 76)
+	}
+	fmt.Fprintln(w, "//")
+	fmt.Fprintf(w, "// %s\n", makeCmdDocUrl(set, cd.BaseName))
 	return nil
 }
 
@@ -145,6 +309,19 @@ func readXmlFileNonStrict(fileName string, data interface{}) error {
 	return decoder.Decode(data)
 }
 
+// docPageFile returns the page of a DocBook file name. GLU and GLX pages
+// and files not describing a GL command are ignored.
+func docPageFile(fileName string) (docFile, bool) {
+	if strings.HasPrefix(fileName, "glu") || strings.HasPrefix(fileName, "glX") {
+		return docFile{}, false
+	}
+	if !strings.HasPrefix(fileName, "gl") || !strings.HasSuffix(fileName, ".xml") {
+		return docFile{}, false
+	}
+	baseName := strings.TrimPrefix(strings.TrimSuffix(fileName, ".xml"), "gl")
+	return docFile{BaseName: baseName, FileName: fileName}, true
+}
+
 func parseDocIndex(fileName string) ([]docFile, error) {
 	var di docSvnIndex
 	if err := readXmlFileNonStrict(fileName, &di); err != nil {
@@ -152,37 +329,89 @@ func parseDocIndex(fileName string) ([]docFile, error) {
 	}
 	files := make([]docFile, 0, 256)
 	for _, fr := range di.FileRef {
-		if strings.HasPrefix(fr.Ref, "glu") { // ignore
-			continue
+		if df, ok := docPageFile(fr.Ref); ok {
+			files = append(files, df)
 		}
-		if strings.HasPrefix(fr.Ref, "glX") { // ignore
+	}
+	return files, nil
+}
+
+// listDocFiles returns the pages of a documentation set directory. The
+// index.xml of the old SVN layout is used if present, otherwise all
+// files of the directory are considered.
+func listDocFiles(dir string) ([]docFile, error) {
+	index := filepath.Join(dir, "index.xml")
+	if _, err := os.Stat(index); err == nil {
+		return parseDocIndex(index)
+	}
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]docFile, 0, 256)
+	for _, fi := range fis {
+		if fi.IsDir() {
 			continue
 		}
-		if strings.HasPrefix(fr.Ref, "gl") {
-			fn := strings.TrimPrefix(strings.TrimSuffix(fr.Ref, ".xml"), "gl")
-			files = append(files, docFile{BaseName: fn, FileName: fr.Ref})
+		if df, ok := docPageFile(fi.Name()); ok {
+			files = append(files, df)
 		}
 	}
 	return files, nil
 }
 
-func DownloadDocs(url, docCat, outDir string) error {
-	complOutDir := filepath.Join(outDir, docCat)
-	err := downloadFile(url, docCat, complOutDir, "index.xml")
-	if err != nil {
-		return err
+// fileLister is implemented by local sources that can list directories.
+type fileLister interface {
+	listFiles(dir string) []string
+}
+
+// DownloadDocs copies the documentation set docCat from src to outDir.
+// The refpages repository is listed through the GitHub contents API,
+// mirrors of the old SVN server list the set as index.xml and local
+// sources are listed directly.
+func DownloadDocs(src specSource, docCat, outDir string) error {
+	var list func(dir string) ([]string, error)
+	switch s := src.(type) {
+	case *gitHubSource:
+		list = s.listDir
+		// the files are fetched like those of any httpSource
+		src = s.httpSource
+	case fileLister:
+		list = func(dir string) ([]string, error) { return s.listFiles(dir), nil }
 	}
-	file, err := parseDocIndex(filepath.Join(complOutDir, "index.xml"))
+	f, err := newFetcher(src, outDir)
 	if err != nil {
 		return err
 	}
-	for _, file := range file {
-		err = downloadFile(fmt.Sprintf("%s/%s", url, docCat), file.FileName, complOutDir, file.FileName)
+	var files []docFile
+	if list != nil {
+		names, err := list(docCat)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if df, ok := docPageFile(name); ok {
+				files = append(files, df)
+			}
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("documentation set %s not found", docCat)
+		}
+	} else {
+		err := f.fetchAll([]fetchJob{{Name: docCat, Out: docCat + "/index.xml"}})
+		if err != nil {
+			return err
+		}
+		files, err = parseDocIndex(filepath.Join(outDir, docCat, "index.xml"))
 		if err != nil {
 			return err
 		}
 	}
-	return nil
+	jobs := make([]fetchJob, len(files))
+	for i, file := range files {
+		jobs[i] = fetchJob{Name: docCat + "/" + file.FileName, Out: docCat + "/" + file.FileName}
+	}
+	return f.fetchAll(jobs)
 }
 
 func parseDocFile(fileName string) (*CommandDoc, error) {
@@ -190,12 +419,43 @@ func parseDocFile(fileName string) (*CommandDoc, error) {
 	if err := readXmlFileNonStrict(fileName, &d); err != nil {
 		return nil, err
 	}
-	return &CommandDoc{Purpose: d.RefPurpose}, nil
+	cd := &CommandDoc{Purpose: strings.Join(strings.Fields(d.RefPurpose), " ")}
+	for _, rn := range d.RefNames {
+		cd.Names = append(cd.Names, TrimGLCmdPrefix(strings.TrimSpace(rn)))
+	}
+	for _, s := range d.Sections {
+		blocks, err := parseDocBlocks(s.Inner)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", fileName, err)
+		}
+		switch {
+		case strings.HasPrefix(s.Id, "parameters"):
+			for _, b := range blocks {
+				if b.Kind != docBlockItem || b.Term == "" {
+					continue
+				}
+				names := strings.Split(b.Term, ",")
+				for i := range names {
+					names[i] = strings.TrimSpace(names[i])
+				}
+				cd.Params = append(cd.Params, ParamDoc{Names: names, Desc: b.Text})
+			}
+		case s.Id == "description":
+			cd.Description = blocks
+		case s.Id == "errors":
+			cd.Errors = blocks
+		case s.Id == "seealso":
+			for _, sa := range s.SeeAlso {
+				cd.SeeAlso = append(cd.SeeAlso, TrimGLCmdPrefix(strings.TrimSpace(sa)))
+			}
+		}
+	}
+	return cd, nil
 }
 
-func parseDocs(docCat, dir string) ([]*CommandDoc, error) {
-	complOutDir := filepath.Join(dir, docCat)
-	file, err := parseDocIndex(filepath.Join(complOutDir, "index.xml"))
+func parseDocs(set, dir string) ([]*CommandDoc, error) {
+	complOutDir := filepath.Join(dir, set)
+	file, err := listDocFiles(complOutDir)
 	if err != nil {
 		return nil, err
 	}
@@ -206,20 +466,39 @@ func parseDocs(docCat, dir string) ([]*CommandDoc, error) {
 			return nil, err
 		}
 		cd.BaseName = file.BaseName
+		if len(cd.Names) == 0 {
+			cd.Names = []string{file.BaseName}
+		}
 		commandDocs = append(commandDocs, cd)
 	}
 	return commandDocs, nil
 }
 
+// ParseAllDocs reads every documentation set found in a subdirectory of
+// dir, e.g. a checkout of https://github.com/KhronosGroup/OpenGL-Refpages.
+// A missing dir is not an error, the generated code is then undocumented.
 func ParseAllDocs(dir string) (*Documentation, error) {
-	cdocs := make([]CommandDocs, 0, 4)
-	for ver := 2; ver <= 4; ver++ {
-		cds, err := parseDocs(fmt.Sprintf("man%d", ver), dir)
+	fis, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		fmt.Printf("No documentation found in %s\n", dir)
+		return &Documentation{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cdocs := make([]CommandDocs, 0, len(fis))
+	for _, fi := range fis {
+		if !fi.IsDir() || skipScanDir(fi.Name()) {
+			continue
+		}
+		cds, err := parseDocs(fi.Name(), dir)
 		if err != nil {
 			return nil, err
 		}
-		cd := CommandDocs{MajorVersion: ver, Commands: cds}
-		cdocs = append(cdocs, cd)
+		if len(cds) == 0 {
+			continue
+		}
+		cdocs = append(cdocs, newCommandDocs(fi.Name(), cds))
 	}
 	return &Documentation{CommandDocs: cdocs}, nil
 }
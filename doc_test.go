@@ -0,0 +1,186 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+const shaderSourceDoc = `// ShaderSource replaces the source code in a shader object.
+//
+// Parameters:
+//
+//   - shader: Specifies the handle of the shader object whose source code is
+//     to be replaced.
+//   - count: Specifies the number of elements in the glstring and length
+//     arrays.
+//   - glstring: Specifies an array of pointers to strings containing the
+//     source code to be loaded into the shader.
+//   - length: Specifies the number of elements in the glstring and length
+//     arrays.
+//
+// glShaderSource sets the source code in shader to the source code in the
+// array of strings specified by glstring. Each element of length is one of:
+//
+//   - a negative value, the string is null terminated,
+//   - the length of the string.
+//
+//	const GLchar *src = "void main() {}";
+//	glShaderSource(shader, 1, &src, NULL);
+//
+// The source code strings are not scanned or parsed at this time; they are
+// simply copied into the specified shader object.
+//
+// Errors:
+//
+// GL_INVALID_VALUE is generated if count is less than 0.
+//
+// See also [CompileShader], [CreateShader].
+//
+// https://registry.khronos.org/OpenGL-Refpages/gl4/html/glShaderSource.xhtml
+`
+
+type docSentenceTest struct {
+	Name, Purpose, Sentence string
+}
+
+var allTestsDocSentence = []docSentenceTest{
+	{"ShaderSource", "Replaces the source code in a shader object", "ShaderSource replaces the source code in a shader object."},
+	{"DeleteShader", "delete a shader object", "DeleteShader deletes a shader object."},
+	{"ClearStencil", "specify the clear value for the stencil buffer.", "ClearStencil specifies the clear value for the stencil buffer."},
+	{"PushMatrix", "push and pop the current matrix stack", "PushMatrix pushes and pops the current matrix stack."},
+	{"Flush", "force execution of GL commands in finite time", "Flush forces execution of GL commands in finite time."},
+	{"PassThrough", "pass", "PassThrough passes."},
+	{"Get", "return the value or values of a selected parameter", "Get returns the value or values of a selected parameter."},
+	{"Enable", "enable or disable server-side GL capabilities", "Enable enables or disables server-side GL capabilities."},
+	{"Finish", "", "Finish."},
+	{"GetString", "GLSL version string", "GetString GLSL version string."},
+}
+
+func TestDocSentence(t *testing.T) {
+	for _, test := range allTestsDocSentence {
+		if s := docSentence(test.Name, test.Purpose); s != test.Sentence {
+			t.Errorf("docSentence(%q, %q) = %q, expected %q", test.Name, test.Purpose, s, test.Sentence)
+		}
+	}
+}
+
+func TestWriteGoCmdDoc(t *testing.T) {
+	cd, err := parseDocFile("testdata/docs/gl4/glShaderSource.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cd.BaseName = "ShaderSource"
+	d := &Documentation{CommandDocs: []CommandDocs{newCommandDocs("gl4", []*CommandDoc{cd})}}
+	f := &Function{Name: "ShaderSource", Parameters: []Parameter{
+		{Name: "shader"}, {Name: "count"}, {Name: "string"}, {Name: "length"},
+	}}
+	var b bytes.Buffer
+	if err := d.WriteGoCmdDoc(&b, f, "gl4"); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != shaderSourceDoc {
+		t.Errorf("WriteGoCmdDoc() failed:\n%s\nexpected:\n%s", b.String(), shaderSourceDoc)
+	}
+}
+
+func TestParseAllDocs(t *testing.T) {
+	d, err := ParseAllDocs("testdata/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sets := make(map[string][]string)
+	for _, cd := range d.CommandDocs {
+		for _, c := range cd.Commands {
+			sets[cd.Set] = append(sets[cd.Set], c.BaseName)
+		}
+	}
+	expected := map[string][]string{
+		"es2.0": {"DeleteShader"},
+		"gl4":   {"GetTexParameter", "ShaderSource"},
+		"man2":  {"DeleteShader"},
+	}
+	if !reflect.DeepEqual(sets, expected) {
+		t.Errorf("ParseAllDocs() = %v, expected %v", sets, expected)
+	}
+	if d, err := ParseAllDocs("testdata/nodocs"); err != nil || len(d.CommandDocs) != 0 {
+		t.Errorf("ParseAllDocs() of missing dir = %v, %v, expected no docs", d, err)
+	}
+}
+
+type docSetTest struct {
+	api     string
+	version Version
+	set     string
+	url     string
+}
+
+var allTestsDocSet = []docSetTest{
+	{"gl", Version{2, 1}, "man2", "https://registry.khronos.org/OpenGL-Refpages/gl2.1/xhtml/glClear.xml"},
+	{"gl", Version{3, 3}, "gl4", "https://registry.khronos.org/OpenGL-Refpages/gl4/html/glClear.xhtml"},
+	{"gl", Version{4, 6}, "gl4", "https://registry.khronos.org/OpenGL-Refpages/gl4/html/glClear.xhtml"},
+	{"gles2", Version{2, 0}, "es2.0", "https://registry.khronos.org/OpenGL-Refpages/es2.0/xhtml/glClear.xml"},
+	{"gles2", Version{3, 0}, "", ""},
+	{"gles1", Version{1, 0}, "", ""},
+}
+
+func TestDocSet(t *testing.T) {
+	d, err := ParseAllDocs("testdata/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range allTestsDocSet {
+		set := d.DocSet(test.api, test.version)
+		if set != test.set {
+			t.Errorf("DocSet(%s, %v) = %q, expected %q", test.api, test.version, set, test.set)
+			continue
+		}
+		if set != "" {
+			if url := makeCmdDocUrl(set, "Clear"); url != test.url {
+				t.Errorf("makeCmdDocUrl(%s, Clear) = %s, expected %s", set, url, test.url)
+			}
+		}
+	}
+}
+
+type findCmdTest struct {
+	name string
+	page string // "" if the command has no doc
+}
+
+var allTestsFindCmd = []findCmdTest{
+	{"ShaderSource", "ShaderSource"},
+	{"ShaderSourceARB", ""},
+	{"GetTexParameterIiv", "GetTexParameter"},
+	{"GetTexParameterfv", "GetTexParameter"},
+	{"GetTexParameterIuivEXT", ""},
+	{"GetTexImage", ""},
+	{"Shader", ""},
+}
+
+func TestFindCmd(t *testing.T) {
+	d, err := ParseAllDocs("testdata/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range allTestsFindCmd {
+		cd, err := d.findCmd("gl4", test.name)
+		switch {
+		case test.page == "" && err == nil:
+			t.Errorf("findCmd(%s) = %s, expected no doc", test.name, cd.BaseName)
+		case test.page != "" && err != nil:
+			t.Errorf("findCmd(%s) failed: %s", test.name, err)
+		case test.page != "" && cd.BaseName != test.page:
+			t.Errorf("findCmd(%s) = %s, expected %s", test.name, cd.BaseName, test.page)
+		}
+	}
+	fs := Functions{"ShaderSource": {Name: "ShaderSource"}, "GetTexImage": {Name: "GetTexImage"}, "Shader": {Name: "Shader"}}
+	missing := d.undocumented("gl4", fs)
+	if !reflect.DeepEqual(missing, []string{"GetTexImage", "Shader"}) {
+		t.Errorf("undocumented() = %v", missing)
+	}
+}
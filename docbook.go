@@ -0,0 +1,203 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type docBlockKind int
+
+const (
+	docBlockPara docBlockKind = iota
+	docBlockItem
+	docBlockCode
+)
+
+// A paragraph, list item or code listing of a DocBook section.
+type docBlock struct {
+	Kind docBlockKind
+	Term string // term of a variable list entry
+	Text string
+}
+
+// docBlockBuilder converts DocBook markup into plain text blocks.
+type docBlockBuilder struct {
+	blocks []docBlock
+	kind   docBlockKind
+	text   bytes.Buffer
+	term   bytes.Buffer
+	stack  []string
+}
+
+func (b *docBlockBuilder) in(name string) bool {
+	for _, s := range b.stack {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *docBlockBuilder) flush() {
+	text := b.text.String()
+	if b.kind != docBlockCode {
+		text = strings.Join(strings.Fields(text), " ")
+	} else {
+		text = strings.TrimLeft(strings.TrimRight(text, " \t\n"), "\n")
+	}
+	term := strings.Join(strings.Fields(b.term.String()), " ")
+	if text != "" || term != "" {
+		b.blocks = append(b.blocks, docBlock{Kind: b.kind, Term: term, Text: text})
+	}
+	b.text.Reset()
+	b.term.Reset()
+	b.kind = docBlockPara
+}
+
+func (b *docBlockBuilder) start(name string) {
+	switch name {
+	case "varlistentry", "row":
+		b.flush()
+		b.kind = docBlockItem
+	case "listitem":
+		if !b.in("varlistentry") {
+			b.flush()
+			b.kind = docBlockItem
+		}
+	case "programlisting":
+		b.flush()
+		b.kind = docBlockCode
+	case "para":
+		if b.kind == docBlockItem {
+			b.text.WriteString(" ")
+		} else {
+			b.flush()
+		}
+	case "entry":
+		b.text.WriteString(" ")
+	}
+	b.stack = append(b.stack, name)
+}
+
+func (b *docBlockBuilder) end(name string) {
+	if len(b.stack) > 0 {
+		b.stack = b.stack[:len(b.stack)-1]
+	}
+	switch name {
+	case "varlistentry", "row", "programlisting":
+		b.flush()
+	case "listitem":
+		if !b.in("varlistentry") {
+			b.flush()
+		}
+	case "para":
+		if b.kind != docBlockItem {
+			b.flush()
+		}
+	}
+}
+
+func (b *docBlockBuilder) charData(s string) {
+	switch {
+	case b.in("title"):
+		return
+	case b.in("parameter"):
+		s = RenameIfReservedGoWord(strings.TrimSpace(s))
+	}
+	if b.in("term") {
+		b.term.WriteString(s)
+	} else {
+		b.text.WriteString(s)
+	}
+}
+
+// parseDocBlocks converts the inner XML of a DocBook section into blocks.
+// Parameter names are renamed the same way as in the generated code.
+func parseDocBlocks(inner []byte) ([]docBlock, error) {
+	b := &docBlockBuilder{}
+	decoder := xml.NewDecoder(bytes.NewReader(inner))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			b.start(t.Name.Local)
+		case xml.EndElement:
+			b.end(t.Name.Local)
+		case xml.CharData:
+			b.charData(string(t))
+		}
+	}
+	b.flush()
+	return b.blocks, nil
+}
+
+// writeDocText writes text as comment lines of at most width characters.
+func writeDocText(w io.Writer, indent, text string, width int) {
+	writeDocTextPrefix(w, "//", indent, text, width)
+}
+
+// writeDocTextPrefix is like writeDocText but starts each line with prefix
+// instead of the comment marker alone.
+func writeDocTextPrefix(w io.Writer, prefix, indent, text string, width int) {
+	line := indent
+	for _, word := range strings.Fields(text) {
+		if len(line) > len(indent) && len(line)+1+len(word) > width {
+			fmt.Fprintln(w, prefix, line)
+			line = strings.Repeat(" ", len(indent))
+		}
+		if len(line) > 0 && line[len(line)-1] != ' ' {
+			line += " "
+		}
+		line += word
+	}
+	if strings.TrimSpace(line) != "" {
+		fmt.Fprintln(w, prefix, line)
+	}
+}
+
+// writeDocBlocks writes blocks as Go doc comment paragraphs, lists and code.
+func writeDocBlocks(w io.Writer, blocks []docBlock) {
+	for i, bl := range blocks {
+		switch bl.Kind {
+		case docBlockPara:
+			if i > 0 {
+				fmt.Fprintln(w, "//")
+			}
+			writeDocText(w, "", bl.Text, 76)
+		case docBlockItem:
+			if i > 0 && blocks[i-1].Kind != docBlockItem {
+				fmt.Fprintln(w, "//")
+			}
+			text := bl.Text
+			if bl.Term != "" {
+				text = bl.Term + ": " + text
+			}
+			writeDocText(w, "  - ", text, 76)
+		case docBlockCode:
+			fmt.Fprintln(w, "//")
+			for _, l := range strings.Split(bl.Text, "\n") {
+				if l = strings.TrimRight(l, " \t"); l == "" {
+					fmt.Fprintln(w, "//")
+				} else {
+					fmt.Fprintln(w, "//\t"+l)
+				}
+			}
+		}
+	}
+}
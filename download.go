@@ -5,69 +5,472 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
+// The reference pages are read from the KhronosGroup/OpenGL-Refpages
+// repository: the files from raw.githubusercontent.com, the directory
+// listings from the GitHub contents API.
 const (
-	khronosDocBaseURL = "https://cvs.khronos.org/svn/repos/ogl/trunk/ecosystem/public/sdk/docs"
-	khronosDocDir     = "man"
+	khronosDocBaseURL = "https://raw.githubusercontent.com/KhronosGroup/OpenGL-Refpages/main"
+	khronosDocListURL = "https://api.github.com/repos/KhronosGroup/OpenGL-Refpages/contents"
 )
 
 const (
-	khronosRegistryBaseURL = "https://cvs.khronos.org/svn/repos/ogl/trunk/doc/registry/public/api"
+	khronosRegistryBaseURL = "https://registry.khronos.org/OpenGL/xml"
 	openGLSpecFile         = "gl.xml"
 	eglSpecFile            = "egl.xml"
 	wglSpecFile            = "wgl.xml"
 	glxSpecFile            = "glx.xml"
 )
 
-func downloadFile(baseURL, fileName, outDir, outFile string) error {
-	fullURL := fmt.Sprintf("%s/%s", baseURL, fileName)
-	fmt.Printf("Downloading %s ...\n", fullURL)
-	r, err := http.Get(fullURL)
+var allSpecFiles = []string{openGLSpecFile, wglSpecFile, glxSpecFile, eglSpecFile}
+
+// A specSource provides the spec or doc files of a mirror by name.
+type specSource interface {
+	ReadFile(name string) ([]byte, error)
+}
+
+// httpSource reads files relative to a base URL. Requests failing with a
+// network error or a server error are retried with exponential backoff.
+type httpSource struct {
+	baseURL string
+	client  *http.Client
+	retries int
+	backoff time.Duration // delay before the first retry
+}
+
+// gitHubSource reads the files of a GitHub repository like an httpSource
+// and lists its directories through the contents API, with the same
+// retries.
+type gitHubSource struct {
+	*httpSource
+	list *httpSource // contents API of the repository
+}
+
+// dirSource reads files relative to a local directory.
+type dirSource string
+
+// tarSource holds the files of a (gzipped) tarball, e.g. a gogl2 bundle
+// or a snapshot of the Khronos registry repository.
+type tarSource map[string][]byte
+
+// newSpecSource returns the source for an http(s) URL, a file:// URL, a
+// tarball or a local directory.
+func newSpecSource(src string) (specSource, error) {
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		return newHTTPSource(src), nil
+	}
+	src = strings.TrimPrefix(src, "file://")
+	if isTarball(src) {
+		files, err := readTarball(src)
+		if err != nil {
+			return nil, err
+		}
+		return tarSource(files), nil
+	}
+	fi, err := os.Stat(src)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("%s is neither a directory nor a tarball", src)
+	}
+	return dirSource(src), nil
+}
+
+func newHTTPSource(baseURL string) *httpSource {
+	return &httpSource{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: 2 * time.Minute},
+		retries: 3,
+		backoff: time.Second,
+	}
+}
+
+// errNotModified is returned for conditional requests of unchanged files.
+var errNotModified = errors.New("not modified")
+
+type httpStatusError struct {
+	URL    string
+	Status int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("GET %s: %d %s", e.URL, e.Status, http.StatusText(e.Status))
+}
+
+// isRetryable reports whether a failed request may succeed later: network
+// errors, timeouts, truncated bodies and server errors. Other errors, e.g.
+// of writing the download, are returned immediately.
+func isRetryable(err error) bool {
+	switch e := err.(type) {
+	case *httpStatusError:
+		return e.Status >= 500 || e.Status == http.StatusTooManyRequests
+	case net.Error:
+		return true
+	}
+	return err == io.ErrUnexpectedEOF
+}
+
+// cacheEntry holds the HTTP cache validators of a downloaded file.
+type cacheEntry struct {
+	ETag         string
+	LastModified string
+}
+
+// get writes the body of a successful response to w.
+func (s *httpSource) get(url string, ce cacheEntry, w io.Writer) (cacheEntry, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return ce, err
+	}
+	if ce.ETag != "" {
+		req.Header.Set("If-None-Match", ce.ETag)
+	}
+	if ce.LastModified != "" {
+		req.Header.Set("If-Modified-Since", ce.LastModified)
+	}
+	r, err := s.client.Do(req)
+	if err != nil {
+		return ce, err
 	}
 	defer r.Body.Close()
-	data, err := ioutil.ReadAll(r.Body)
+	switch r.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return ce, errNotModified
+	default:
+		io.Copy(ioutil.Discard, r.Body)
+		return ce, &httpStatusError{URL: url, Status: r.StatusCode}
+	}
+	if _, err := io.Copy(w, r.Body); err != nil {
+		return ce, err
+	}
+	return cacheEntry{ETag: r.Header.Get("ETag"), LastModified: r.Header.Get("Last-Modified")}, nil
+}
+
+// fetch downloads name into f. f is truncated before every retry.
+func (s *httpSource) fetch(name string, ce cacheEntry, f *os.File) (cacheEntry, error) {
+	url := fmt.Sprintf("%s/%s", s.baseURL, name)
+	fmt.Printf("Downloading %s ...\n", url)
+	delay := s.backoff
+	for attempt := 0; ; attempt++ {
+		nce, err := s.get(url, ce, f)
+		if err == nil || attempt >= s.retries || !isRetryable(err) {
+			return nce, err
+		}
+		fmt.Printf("Retrying %s in %s: %s\n", url, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+		if err := f.Truncate(0); err != nil {
+			return ce, err
+		}
+		if _, err := f.Seek(0, 0); err != nil {
+			return ce, err
+		}
+	}
+}
+
+func (s *httpSource) ReadFile(name string) ([]byte, error) {
+	f, err := ioutil.TempFile("", "gogl2")
 	if err != nil {
-		return err
+		return nil, err
 	}
-	absPath, err := filepath.Abs(outDir)
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err := s.fetch(name, cacheEntry{}, f); err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(f.Name())
+}
+
+func newGitHubSource(baseURL, listURL string) *gitHubSource {
+	return &gitHubSource{httpSource: newHTTPSource(baseURL), list: newHTTPSource(listURL)}
+}
+
+// listDir returns the names of the files in dir.
+func (s *gitHubSource) listDir(dir string) ([]string, error) {
+	data, err := s.list.ReadFile(dir)
 	if err != nil {
+		return nil, err
+	}
+	var entries []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("listing of %s: %s", dir, err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.Type == "file" {
+			names = append(names, e.Name)
+		}
+	}
+	return names, nil
+}
+
+func (s dirSource) ReadFile(name string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(string(s), filepath.FromSlash(name)))
+}
+
+// ReadFile returns the file with the given path. Otherwise the only file
+// whose path ends with name is used, so archives with a top level
+// directory can be read as well.
+func (s tarSource) ReadFile(name string) ([]byte, error) {
+	if data, ok := s[name]; ok {
+		return data, nil
+	}
+	found := ""
+	for p := range s {
+		if strings.HasSuffix(p, "/"+name) {
+			if found != "" {
+				return nil, fmt.Errorf("%s is ambiguous in tarball: %s, %s", name, found, p)
+			}
+			found = p
+		}
+	}
+	if found == "" {
+		return nil, fmt.Errorf("%s not found in tarball", name)
+	}
+	return s[found], nil
+}
+
+// listFiles returns the names of all files in dir of a local source.
+func (s tarSource) listFiles(dir string) []string {
+	names := make([]string, 0, 256)
+	for p := range s {
+		if path.Base(path.Dir(p)) == dir {
+			names = append(names, path.Base(p))
+		}
+	}
+	return names
+}
+
+func (s dirSource) listFiles(dir string) []string {
+	fis, err := ioutil.ReadDir(filepath.Join(string(s), dir))
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(fis))
+	for _, fi := range fis {
+		if !fi.IsDir() {
+			names = append(names, fi.Name())
+		}
+	}
+	return names
+}
+
+const (
+	fetchManifestFile = bundleManifestFile
+	fetchCacheFile    = ".gogl2-cache"
+)
+
+// A fetcher copies files of a source into outDir, several at a time.
+// Every file is written to a temporary file first and then renamed, so
+// an interrupted run never leaves a partial file behind. The SHA-256 of
+// all files is recorded in outDir/MANIFEST, the HTTP cache validators in
+// outDir/.gogl2-cache to skip unchanged files next time.
+type fetcher struct {
+	src      specSource
+	outDir   string
+	parallel int
+	mu       sync.Mutex
+	sums     map[string]string     // by slash separated path relative to outDir
+	cache    map[string]cacheEntry // by slash separated path relative to outDir
+}
+
+type fetchJob struct {
+	Name     string // file name in the source
+	Out      string // slash separated path relative to outDir
+	Optional bool   // failure is reported but not an error
+}
+
+func newFetcher(src specSource, outDir string) (*fetcher, error) {
+	f := &fetcher{src: src, outDir: outDir, parallel: 8, sums: make(map[string]string), cache: make(map[string]cacheEntry)}
+	if data, err := ioutil.ReadFile(filepath.Join(outDir, fetchManifestFile)); err == nil {
+		sums, err := parseManifest(data)
+		if err != nil {
+			return nil, err
+		}
+		f.sums = sums
+	}
+	if data, err := ioutil.ReadFile(filepath.Join(outDir, fetchCacheFile)); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Split(line, "\t")
+			if len(fields) == 3 {
+				f.cache[fields[0]] = cacheEntry{ETag: fields[1], LastModified: fields[2]}
+			}
+		}
+	}
+	return f, nil
+}
+
+func sha256File(file string) (string, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return sha256Hex(data), nil
+}
+
+func (f *fetcher) fetch(job fetchJob) error {
+	out := filepath.Join(f.outDir, filepath.FromSlash(job.Out))
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
 		return err
 	}
-	err = os.MkdirAll(absPath, 0755)
+	tmp, err := ioutil.TempFile(filepath.Dir(out), "."+filepath.Base(out)+".")
 	if err != nil {
 		return err
 	}
-	err = ioutil.WriteFile(filepath.Join(absPath, outFile), data, 0644)
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	var ce cacheEntry
+	if hs, ok := f.src.(*httpSource); ok {
+		if _, err := os.Stat(out); err == nil {
+			f.mu.Lock()
+			ce = f.cache[job.Out]
+			f.mu.Unlock()
+		}
+		ce, err = hs.fetch(job.Name, ce, tmp)
+	} else {
+		var data []byte
+		if data, err = f.src.ReadFile(job.Name); err == nil {
+			_, err = tmp.Write(data)
+		}
+	}
+	if err == errNotModified {
+		fmt.Printf("%s is up to date\n", job.Out)
+	} else {
+		if err == nil {
+			err = tmp.Close()
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), out)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	sum, err := sha256File(out)
 	if err != nil {
 		return err
 	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sums[job.Out] = sum
+	if ce.ETag != "" || ce.LastModified != "" {
+		f.cache[job.Out] = ce
+	} else {
+		delete(f.cache, job.Out)
+	}
 	return nil
 }
 
-func downloadAllSpecs(baseURL, outDir string) error {
-	err := downloadFile(baseURL, openGLSpecFile, outDir, openGLSpecFile)
-	if err != nil {
+// fetchAll fetches all jobs and saves the manifest and cache, even if
+// some jobs failed. The first error is returned.
+func (f *fetcher) fetchAll(jobs []fetchJob) error {
+	errs := make([]error, len(jobs))
+	sem := make(chan bool, f.parallel)
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Add(1)
+		sem <- true
+		go func(i int, job fetchJob) {
+			defer wg.Done()
+			errs[i] = f.fetch(job)
+			<-sem
+		}(i, job)
+	}
+	wg.Wait()
+	var first error
+	failed := 0
+	for i, err := range errs {
+		switch {
+		case err == nil:
+		case jobs[i].Optional:
+			fmt.Printf("Skipping %s: %s\n", jobs[i].Name, err)
+		default:
+			fmt.Printf("Error while fetching %s: %s\n", jobs[i].Name, err)
+			if first == nil {
+				first = err
+			}
+			failed++
+		}
+	}
+	if err := f.save(); err != nil {
+		return err
+	}
+	if failed > 1 {
+		return fmt.Errorf("%d of %d files failed, first error: %s", failed, len(jobs), first)
+	}
+	return first
+}
+
+func (f *fetcher) save() error {
+	if err := os.MkdirAll(f.outDir, 0755); err != nil {
 		return err
 	}
-	err = downloadFile(baseURL, wglSpecFile, outDir, wglSpecFile)
+	names := make([]string, 0, len(f.sums))
+	for n := range f.sums {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var manifest, cache bytes.Buffer
+	fmt.Fprintf(&manifest, "# gogl2 %s download, verify with: sha256sum -c %s\n", generatorVersion, fetchManifestFile)
+	for _, n := range names {
+		fmt.Fprintf(&manifest, "%s  %s\n", f.sums[n], n)
+		if ce, ok := f.cache[n]; ok {
+			fmt.Fprintf(&cache, "%s\t%s\t%s\n", n, ce.ETag, ce.LastModified)
+		}
+	}
+	if err := writeFileAtomic(filepath.Join(f.outDir, fetchManifestFile), manifest.Bytes()); err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(f.outDir, fetchCacheFile), cache.Bytes())
+}
+
+func writeFileAtomic(file string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file)+".")
 	if err != nil {
 		return err
 	}
-	err = downloadFile(baseURL, glxSpecFile, outDir, glxSpecFile)
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
 	if err != nil {
 		return err
 	}
-	err = downloadFile(baseURL, eglSpecFile, outDir, eglSpecFile)
+	return os.Rename(tmp.Name(), file)
+}
+
+// downloadAllSpecs fetches all spec files. Only gl.xml is required, local
+// mirrors often lack the window system specs.
+func downloadAllSpecs(src specSource, outDir string) error {
+	f, err := newFetcher(src, outDir)
 	if err != nil {
 		return err
 	}
-	return nil
+	jobs := make([]fetchJob, len(allSpecFiles))
+	for i, file := range allSpecFiles {
+		jobs[i] = fetchJob{Name: file, Out: file, Optional: file != openGLSpecFile}
+	}
+	return f.fetchAll(jobs)
 }
@@ -0,0 +1,291 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// testServer serves files with ETags, fails the first requests of some
+// files with 503 and records the responses.
+type testServer struct {
+	mu       sync.Mutex
+	files    map[string]string
+	failures map[string]int // remaining 503 responses by path
+	statuses map[int]int    // number of responses by status
+	active   int
+	maxAct   int
+}
+
+func (ts *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ts.mu.Lock()
+	ts.active++
+	if ts.active > ts.maxAct {
+		ts.maxAct = ts.active
+	}
+	fail := ts.failures[r.URL.Path] > 0
+	if fail {
+		ts.failures[r.URL.Path]--
+	}
+	content, ok := ts.files[r.URL.Path]
+	ts.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	switch {
+	case fail:
+		http.Error(rec, "busy", http.StatusServiceUnavailable)
+	case !ok:
+		http.NotFound(rec, r)
+	default:
+		rec.Header().Set("ETag", fmt.Sprintf("\"%x\"", len(content)))
+		http.ServeContent(rec, r, r.URL.Path, time.Unix(0, 0), strings.NewReader(content))
+	}
+	ts.mu.Lock()
+	ts.active--
+	ts.statuses[rec.status]++
+	ts.mu.Unlock()
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (ts *testServer) takeStatuses() map[int]int {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	s := ts.statuses
+	ts.statuses = make(map[int]int)
+	return s
+}
+
+func newTestSource(url string) *httpSource {
+	s := newHTTPSource(url)
+	s.backoff = time.Millisecond
+	return s
+}
+
+func TestDownloadAllSpecs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogl2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ts := &testServer{
+		files:    map[string]string{"/api/gl.xml": "<registry/>", "/api/glx.xml": "<glx/>"},
+		failures: map[string]int{"/api/glx.xml": 2},
+		statuses: make(map[int]int),
+	}
+	server := httptest.NewServer(ts)
+	defer server.Close()
+	src := newTestSource(server.URL + "/api/")
+
+	if err := downloadAllSpecs(src, dir); err != nil {
+		t.Fatal(err)
+	}
+	if s := ts.takeStatuses(); s[200] != 2 || s[503] != 2 || s[404] != 2 {
+		t.Errorf("first download: responses %v, expected 2 OK, 2 retried, 2 missing", s)
+	}
+	compareContent(t, filepath.Join(dir, "gl.xml"), "<registry/>")
+	compareContent(t, filepath.Join(dir, "glx.xml"), "<glx/>")
+	for _, missing := range []string{"wgl.xml", "egl.xml"} {
+		if _, err := os.Stat(filepath.Join(dir, missing)); err == nil {
+			t.Errorf("%s of a 404 response was saved", missing)
+		}
+	}
+	manifest, _ := ioutil.ReadFile(filepath.Join(dir, fetchManifestFile))
+	sums, err := parseManifest(manifest)
+	if err != nil || len(sums) != 2 || sums["gl.xml"] != sha256Hex([]byte("<registry/>")) {
+		t.Errorf("manifest = %q, %v", manifest, err)
+	}
+
+	if err := downloadAllSpecs(src, dir); err != nil {
+		t.Fatal(err)
+	}
+	if s := ts.takeStatuses(); s[304] != 2 || s[200] != 0 {
+		t.Errorf("second download: responses %v, expected 2 not modified", s)
+	}
+	compareContent(t, filepath.Join(dir, "gl.xml"), "<registry/>")
+
+	ts.mu.Lock()
+	ts.files["/api/gl.xml"] = "<registry>changed</registry>"
+	ts.mu.Unlock()
+	if err := downloadAllSpecs(src, dir); err != nil {
+		t.Fatal(err)
+	}
+	compareContent(t, filepath.Join(dir, "gl.xml"), "<registry>changed</registry>")
+
+	fis, _ := ioutil.ReadDir(dir)
+	for _, fi := range fis {
+		if strings.HasPrefix(fi.Name(), ".gl") {
+			t.Errorf("temporary file %s left behind", fi.Name())
+		}
+	}
+}
+
+func TestDownloadErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogl2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ts := &testServer{
+		files:    map[string]string{},
+		failures: map[string]int{"/gl.xml": 10},
+		statuses: make(map[int]int),
+	}
+	server := httptest.NewServer(ts)
+	defer server.Close()
+	src := newTestSource(server.URL)
+	err = downloadAllSpecs(src, dir)
+	if err == nil || !strings.Contains(err.Error(), "503 Service Unavailable") {
+		t.Errorf("downloadAllSpecs() = %v, expected 503", err)
+	}
+	if s := ts.takeStatuses(); s[503] != src.retries+1 {
+		t.Errorf("responses %v, expected %d attempts", s, src.retries+1)
+	}
+	err = downloadAllSpecs(src, dir)
+	if s := ts.takeStatuses(); err == nil || s[503] != src.retries+1 {
+		t.Errorf("downloadAllSpecs() = %v, responses %v", err, s)
+	}
+	ts.failures["/gl.xml"] = 0
+	err = downloadAllSpecs(src, dir)
+	if err == nil || !strings.Contains(err.Error(), "404 Not Found") {
+		t.Errorf("downloadAllSpecs() = %v, expected 404", err)
+	}
+	if s := ts.takeStatuses(); s[404] != 4 {
+		t.Errorf("responses %v, 404 must not be retried", s)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "gl.xml")); err == nil {
+		t.Errorf("gl.xml of a failed download was saved")
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+var allTestsRetryable = []struct {
+	err       error
+	retryable bool
+}{
+	{&httpStatusError{Status: 503}, true},
+	{&httpStatusError{Status: 429}, true},
+	{&httpStatusError{Status: 404}, false},
+	{&url.Error{Op: "Get", URL: "http://x", Err: timeoutError{}}, true},
+	{io.ErrUnexpectedEOF, true},
+	{errNotModified, false},
+	{&os.PathError{Op: "write", Path: "gl.xml", Err: syscall.ENOSPC}, false},
+}
+
+func TestIsRetryable(t *testing.T) {
+	for _, test := range allTestsRetryable {
+		if r := isRetryable(test.err); r != test.retryable {
+			t.Errorf("isRetryable(%v) = %v, expected %v", test.err, r, test.retryable)
+		}
+	}
+}
+
+func TestDownloadDocs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogl2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ts := &testServer{
+		files:    map[string]string{},
+		failures: map[string]int{},
+		statuses: make(map[int]int),
+	}
+	index := "<svn><index>"
+	for i := 0; i < 40; i++ {
+		name := fmt.Sprintf("glCmd%d.xml", i)
+		index += fmt.Sprintf("<file href=\"%s\"/>", name)
+		ts.files["/man4/"+name] = "<refentry/>"
+	}
+	ts.files["/man4"] = index + "<file href=\"gluPerspective.xml\"/></index></svn>"
+	server := httptest.NewServer(ts)
+	defer server.Close()
+	if err := DownloadDocs(newTestSource(server.URL), "man4", dir); err != nil {
+		t.Fatal(err)
+	}
+	if s := ts.takeStatuses(); s[200] != 41 {
+		t.Errorf("responses %v, expected 41 OK", s)
+	}
+	if ts.maxAct < 2 || ts.maxAct > 8 {
+		t.Errorf("%d parallel requests, expected 2 to 8", ts.maxAct)
+	}
+	fis, _ := ioutil.ReadDir(filepath.Join(dir, "man4"))
+	if len(fis) != 41 {
+		t.Errorf("%d files downloaded, expected 41", len(fis))
+	}
+}
+
+func TestDownloadGitHubDocs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogl2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ts := &testServer{
+		files: map[string]string{
+			"/contents/gl4": `[{"name": "glClear.xml", "type": "file"}, {"name": "glFlush.xml", "type": "file"},
+				{"name": "html", "type": "dir"}, {"name": "gluPerspective.xml", "type": "file"}]`,
+			"/raw/gl4/glClear.xml": "<refentry/>",
+			"/raw/gl4/glFlush.xml": "<refentry/>",
+		},
+		failures: map[string]int{"/contents/gl4": 1, "/raw/gl4/glFlush.xml": 2},
+		statuses: make(map[int]int),
+	}
+	server := httptest.NewServer(ts)
+	defer server.Close()
+	src := &gitHubSource{httpSource: newTestSource(server.URL + "/raw"), list: newTestSource(server.URL + "/contents")}
+	if err := DownloadDocs(src, "gl4", dir); err != nil {
+		t.Fatal(err)
+	}
+	if s := ts.takeStatuses(); s[200] != 3 || s[503] != 3 {
+		t.Errorf("first download: responses %v, expected 3 OK, 3 retried", s)
+	}
+	compareContent(t, filepath.Join(dir, "gl4", "glFlush.xml"), "<refentry/>")
+	if err := DownloadDocs(src, "gl4", dir); err != nil {
+		t.Fatal(err)
+	}
+	if s := ts.takeStatuses(); s[200] != 1 || s[304] != 2 {
+		t.Errorf("second download: responses %v, expected the listing and 2 not modified", s)
+	}
+	if err := DownloadDocs(src, "es3", dir); err == nil || !strings.Contains(err.Error(), "404 Not Found") {
+		t.Errorf("DownloadDocs() of a missing set = %v, expected 404", err)
+	}
+}
+
+func compareContent(t *testing.T, file, expected string) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(data) != expected {
+		t.Errorf("%s = %q, expected %q", file, data, expected)
+	}
+}
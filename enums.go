@@ -5,15 +5,24 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"go/format"
 	"io"
+	"io/ioutil"
+	"path/filepath"
 	"sort"
+	"strings"
 )
 
 type Enum struct {
-	Name  string
-	Value string
-	Group string
+	Name       string
+	Value      string
+	Group      string
+	Groups     []string // all groups the enum belongs to
+	Feature    string   // version or extension that introduced the enum
+	Commands   []string // commands with parameters accepting one of the groups
+	Deprecated string   // deprecation notice, if the enum was removed in a later version
 }
 
 type Enums map[string]*Enum
@@ -36,6 +45,25 @@ func (e Enum) cleanName() string {
 }
 
 func (e Enum) WriteGoDefinition(w io.Writer) {
+	if e.Feature != "" {
+		writeDocTextPrefix(w, "\t//", "", fmt.Sprintf("%s is defined by %s.", e.cleanName(), e.Feature), 76)
+	}
+	if len(e.Groups) > 0 {
+		writeDocTextPrefix(w, "\t//", "", "Groups: "+strings.Join(e.Groups, ", ")+".", 76)
+	}
+	if len(e.Commands) > 0 {
+		links := make([]string, len(e.Commands))
+		for i, c := range e.Commands {
+			links[i] = "[" + c + "]"
+		}
+		writeDocTextPrefix(w, "\t//", "", "Used by "+strings.Join(links, ", ")+".", 76)
+	}
+	if e.Deprecated != "" {
+		if e.Feature != "" || len(e.Groups) > 0 {
+			fmt.Fprintln(w, "\t//")
+		}
+		fmt.Fprintf(w, "\t// %s\n", e.Deprecated)
+	}
 	fmt.Fprintf(w, "\t%s = %s\n", e.cleanName(), e.Value)
 }
 
@@ -55,3 +83,59 @@ func (se SortedEnums) WriteGoDefinitions(w io.Writer) {
 	}
 	fmt.Fprintf(w, ")\n")
 }
+
+// GenerateUsedEnums writes the registry enums that the Go package in dir
+// uses with the given prefix, e.g. glTEXTURE_2D for GL_TEXTURE_2D, to the
+// file of the package. Packages which work with any generated binding,
+// like util/gltex, declare the few enums they need this way. Enums of
+// bitmask blocks are declared as glt.Bitfield, all others as glt.Enum.
+func GenerateUsedEnums(specFile, dir, file, prefix, gltImport string) error {
+	pkg, names, err := ScanUndeclared(dir, prefix, file)
+	if err != nil {
+		return err
+	}
+	reg, err := readSpecFile(specFile)
+	if err != nil {
+		return err
+	}
+	type value struct{ value, typ string }
+	values := make(map[string]value)
+	for _, et := range reg.Enums {
+		typ := "glt.Enum"
+		if et.Type == "bitmask" {
+			typ = "glt.Bitfield"
+		}
+		for _, e := range et.Enums {
+			if _, ok := values[e.Name]; !ok {
+				values[e.Name] = value{e.Value, typ}
+			}
+		}
+	}
+	var w bytes.Buffer
+	fmt.Fprintln(&w, generatedBanner)
+	fmt.Fprintln(&w, "//")
+	fmt.Fprintf(&w, "// Generated by gogl2 %s from %s (sha256 %s).\n", generatorVersion, filepath.Base(specFile), reg.Hash)
+	fmt.Fprintf(&w, "// Regenerate it with: gogl2 enums -dir=<package directory> -prefix=%s -o=%s\n\n", prefix, file)
+	fmt.Fprintf(&w, "package %s\n\n", pkg)
+	fmt.Fprintf(&w, "import \"%s\"\n\n", gltImport)
+	fmt.Fprintln(&w, "const (")
+	var missing []string
+	for _, n := range names {
+		v, ok := values["GL_"+n[len(prefix):]]
+		if !ok {
+			missing = append(missing, n)
+			continue
+		}
+		fmt.Fprintf(&w, "\t%s %s = %s\n", n, v.typ, v.value)
+	}
+	fmt.Fprintln(&w, ")")
+	if len(missing) > 0 {
+		return fmt.Errorf("%s uses identifiers which are no enums of %s: %s", dir, filepath.Base(specFile), strings.Join(missing, ", "))
+	}
+	src, err := format.Source(w.Bytes())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Writing %d enums of %s to %s\n", len(names), pkg, filepath.Join(dir, file))
+	return ioutil.WriteFile(filepath.Join(dir, file), src, 0644)
+}
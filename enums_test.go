@@ -0,0 +1,73 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var usedEnumsTestFiles = map[string]string{
+	"tex.go": `package tex
+import "github.com/chsc/gogl2/glt"
+const glLOCAL glt.Enum = 1
+var targets = []glt.Enum{glTEXTURE_2D, glLOCAL, glt.Enum(glBLEND)}
+func glUse() {}`,
+	"tex_test.go": `package tex
+var _ = glTRUE`,
+}
+
+const usedEnums = `package tex
+
+import "github.com/chsc/gogl2/glt"
+
+const (
+	glBLEND      glt.Enum     = 0x0BE2
+	glTEXTURE_2D glt.Enum     = 0x0DE1
+	glTRUE       glt.Bitfield = 1
+)
+`
+
+func TestGenerateUsedEnums(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogl2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, src := range usedEnumsTestFiles {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var first []byte
+	// the second run must ignore the enums written by the first one
+	for i := 0; i < 2; i++ {
+		if err := GenerateUsedEnums("testdata/gl.xml", dir, "glenums.go", "gl", defaultGltImport); err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, "glenums.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(data), generatedBanner) || !strings.HasSuffix(string(data), usedEnums) {
+			t.Errorf("GenerateUsedEnums() wrote\n%s\nexpected it to end with\n%s", data, usedEnums)
+		}
+		if i == 1 && string(data) != string(first) {
+			t.Errorf("GenerateUsedEnums() is not repeatable")
+		}
+		first = data
+	}
+	src := "package tex\nvar _ = glNO_SUCH_ENUM\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "bad.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = GenerateUsedEnums("testdata/gl.xml", dir, "glenums.go", "gl", defaultGltImport)
+	if err == nil || !strings.Contains(err.Error(), "glNO_SUCH_ENUM") {
+		t.Errorf("GenerateUsedEnums() = %v, expected error for glNO_SUCH_ENUM", err)
+	}
+}
@@ -2,13 +2,11 @@ package main
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 	"github.com/chsc/gogl2/gl/2.1/gl"
-	"github.com/chsc/gogl2/glt"
 	_ "github.com/chsc/gogl2/procaddr/glx"
+	"github.com/chsc/gogl2/util/gltex"
 	glfw "github.com/go-gl/glfw3"
-	"image"
 	"image/png"
 	"io"
 	"os"
@@ -27,6 +25,7 @@ var (
 	ambient    []float32 = []float32{0.5, 0.5, 0.5, 1}
 	diffuse    []float32 = []float32{1, 1, 1, 1}
 	lightpos   []float32 = []float32{-5, 5, 10, 0}
+	upload               = &gltex.GL{TexImage2D: gl.TexImage2D, PixelStorei: gl.PixelStorei, GetIntegerv: gl.GetIntegerv}
 )
 
 func init() {
@@ -73,26 +72,14 @@ func createTexture(r io.Reader) (textureId uint32, err error) {
 		return 0, err
 	}
 
-	rgbaImg, ok := img.(*image.NRGBA)
-	if !ok {
-		return 0, errors.New("texture must be an NRGBA image")
-	}
-
 	gl.GenTextures(1, &textureId)
 	gl.BindTexture(gl.TEXTURE_2D, textureId)
 	gl.TexParameterf(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
 	gl.TexParameterf(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-
-	// flip image: first pixel is lower left corner
-	imgWidth, imgHeight := img.Bounds().Dx(), img.Bounds().Dy()
-	data := make([]byte, imgWidth*imgHeight*4)
-	lineLen := imgWidth * 4
-	dest := len(data) - lineLen
-	for src := 0; src < len(rgbaImg.Pix); src += rgbaImg.Stride {
-		copy(data[dest:dest+lineLen], rgbaImg.Pix[src:src+rgbaImg.Stride])
-		dest -= lineLen
+	if err := upload.Upload(gl.TEXTURE_2D, 0, img); err != nil {
+		gl.DeleteTextures(1, &textureId)
+		return 0, err
 	}
-	gl.TexImage2D(gl.TEXTURE_2D, 0, 4, int32(imgWidth), int32(imgHeight), 0, gl.RGBA, gl.UNSIGNED_BYTE, glt.Ptr(data))
 
 	return textureId, nil
 }
@@ -12,14 +12,61 @@ import (
 )
 
 type Parameter struct {
-	Name string
-	Type Type
+	Name  string
+	Type  Type
+	Group string
 }
 
 type Function struct {
 	Name       string
 	Parameters []Parameter
 	Return     Type
+	Deprecated string // deprecation notice, if the command was removed in a later version
+}
+
+// bufferOffsetParams names the pointer parameter of the commands which
+// read from or write to a bound buffer object at the offset given in this
+// parameter. These commands get an Offset variant taking a uintptr, so
+// that Go code never has to turn an integer into an unsafe.Pointer.
+var bufferOffsetParams = map[string]string{
+	"ColorPointer":                                "pointer",
+	"CompressedTexImage1D":                        "data",
+	"CompressedTexImage2D":                        "data",
+	"CompressedTexImage3D":                        "data",
+	"CompressedTexSubImage1D":                     "data",
+	"CompressedTexSubImage2D":                     "data",
+	"CompressedTexSubImage3D":                     "data",
+	"DrawArraysIndirect":                          "indirect",
+	"DrawElements":                                "indices",
+	"DrawElementsBaseVertex":                      "indices",
+	"DrawElementsIndirect":                        "indirect",
+	"DrawElementsInstanced":                       "indices",
+	"DrawElementsInstancedBaseInstance":           "indices",
+	"DrawElementsInstancedBaseVertex":             "indices",
+	"DrawElementsInstancedBaseVertexBaseInstance": "indices",
+	"DrawRangeElements":                           "indices",
+	"DrawRangeElementsBaseVertex":                 "indices",
+	"EdgeFlagPointer":                             "pointer",
+	"FogCoordPointer":                             "pointer",
+	"GetCompressedTexImage":                       "img",
+	"GetTexImage":                                 "pixels",
+	"IndexPointer":                                "pointer",
+	"MultiDrawArraysIndirect":                     "indirect",
+	"MultiDrawElementsIndirect":                   "indirect",
+	"NormalPointer":                               "pointer",
+	"ReadPixels":                                  "pixels",
+	"SecondaryColorPointer":                       "pointer",
+	"TexCoordPointer":                             "pointer",
+	"TexImage1D":                                  "pixels",
+	"TexImage2D":                                  "pixels",
+	"TexImage3D":                                  "pixels",
+	"TexSubImage1D":                               "pixels",
+	"TexSubImage2D":                               "pixels",
+	"TexSubImage3D":                               "pixels",
+	"VertexAttribIPointer":                        "pointer",
+	"VertexAttribLPointer":                        "pointer",
+	"VertexAttribPointer":                         "pointer",
+	"VertexPointer":                               "pointer",
 }
 
 type Functions map[string]*Function
@@ -93,6 +140,70 @@ func (f *Function) WriteCBridgeDefinition(w io.Writer) {
 	fmt.Fprintln(w, "// }")
 }
 
+// offsetParam returns the index of the parameter that may be a buffer
+// offset, -1 if the command has no Offset variant.
+func (f *Function) offsetParam() int {
+	name, ok := bufferOffsetParams[f.Name]
+	if !ok {
+		return -1
+	}
+	for i := range f.Parameters {
+		if f.Parameters[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// WriteCOffsetBridgeDefinition writes the C function of the Offset variant
+// of the command. It converts the offset to the pointer type of the
+// parameter, which Go must not do.
+func (f *Function) WriteCOffsetBridgeDefinition(w io.Writer, usePtr bool) {
+	off := f.offsetParam()
+	if off < 0 {
+		return
+	}
+	fmt.Fprintf(w, "// %s gogl%sOffset(", f.Return.CType(), f.Name)
+	if usePtr {
+		fmt.Fprintf(w, "PGL%s glfptr, ", strings.ToUpper(f.Name))
+	}
+	for i := range f.Parameters {
+		p := &f.Parameters[i]
+		if i != 0 {
+			fmt.Fprintf(w, ", ")
+		}
+		if i == off {
+			fmt.Fprintf(w, "uintptr_t offset")
+		} else {
+			fmt.Fprintf(w, "%s %s", p.Type.CType(), RenameIfReservedCWord(p.Name))
+		}
+	}
+	fmt.Fprintln(w, ") {")
+	if f.Return.IsVoid() {
+		fmt.Fprintf(w, "// 	")
+	} else {
+		fmt.Fprintf(w, "// 	return ")
+	}
+	if usePtr {
+		fmt.Fprintf(w, "(*glfptr)(")
+	} else {
+		fmt.Fprintf(w, "gl%s(", f.Name)
+	}
+	for i := range f.Parameters {
+		p := &f.Parameters[i]
+		if i != 0 {
+			fmt.Fprintf(w, ", ")
+		}
+		if i == off {
+			fmt.Fprintf(w, "(%s)offset", p.Type.CType())
+		} else {
+			fmt.Fprintf(w, "%s", RenameIfReservedCWord(p.Name))
+		}
+	}
+	fmt.Fprintln(w, ");")
+	fmt.Fprintln(w, "// }")
+}
+
 func (f *Function) WriteGoFunctionPtr(w io.Writer) {
 	fmt.Fprintf(w, "	pgl%s C.PGL%s\n", f.Name, strings.ToUpper(f.Name))
 }
@@ -101,11 +212,17 @@ func (f *Function) WriteGoGetProcAddress(w io.Writer) {
 	fmt.Fprintf(w, "	if pgl%s = (C.PGL%s)(unsafe.Pointer(glt.GetProcAddress(\"gl%s\"))); pgl%s == nil { return errors.New(\"gl%s\") }\n", f.Name, strings.ToUpper(f.Name), f.Name, f.Name, f.Name)
 }
 
-func (f *Function) WriteGoDefinition(w io.Writer, usePtr bool, d *Documentation, majorVersion int) {
-	err := d.WriteGoCmdDoc(w, f.Name, majorVersion)
+func (f *Function) WriteGoDefinition(w io.Writer, usePtr bool, d *Documentation, docSet string) {
+	err := d.WriteGoCmdDoc(w, f, docSet)
 	if err != nil {
 		//fmt.Printf("Unable to find function doc: %v\n", err)
 	}
+	if f.Deprecated != "" {
+		if err == nil {
+			fmt.Fprintln(w, "//")
+		}
+		fmt.Fprintf(w, "// %s\n", f.Deprecated)
+	}
 	fmt.Fprintf(w, "func %s(", f.Name)
 	for i, _ := range f.Parameters {
 		p := &f.Parameters[i]
@@ -153,6 +270,59 @@ func (f *Function) WriteGoDefinition(w io.Writer, usePtr bool, d *Documentation,
 	fmt.Fprintln(w, "}")
 }
 
+// WriteGoOffsetDefinition writes the Offset variant of the command, which
+// takes its buffer offset parameter as uintptr.
+func (f *Function) WriteGoOffsetDefinition(w io.Writer, usePtr bool) {
+	off := f.offsetParam()
+	if off < 0 {
+		return
+	}
+	fmt.Fprintf(w, "// %sOffset is %s with %s given as an offset into the bound buffer object.\n", f.Name, f.Name, f.Parameters[off].Name)
+	if f.Deprecated != "" {
+		fmt.Fprintln(w, "//")
+		fmt.Fprintf(w, "// %s\n", f.Deprecated)
+	}
+	fmt.Fprintf(w, "func %sOffset(", f.Name)
+	for i := range f.Parameters {
+		p := &f.Parameters[i]
+		if i != 0 {
+			fmt.Fprintf(w, ", ")
+		}
+		if i == off {
+			fmt.Fprintf(w, "offset uintptr")
+		} else {
+			fmt.Fprintf(w, "%s %s", RenameIfReservedGoWord(p.Name), p.Type.GoType())
+		}
+	}
+	if f.Return.IsVoid() {
+		fmt.Fprintln(w, ") {")
+		fmt.Fprintf(w, "	C.gogl%sOffset(", f.Name)
+	} else {
+		fmt.Fprintf(w, ") %s {\n", f.Return.GoType())
+		fmt.Fprintf(w, "\treturn %s(C.gogl%sOffset(", f.Return.GoConversion(), f.Name)
+	}
+	if usePtr {
+		fmt.Fprintf(w, "pgl%s, ", f.Name)
+	}
+	for i := range f.Parameters {
+		p := &f.Parameters[i]
+		if i != 0 {
+			fmt.Fprintf(w, ", ")
+		}
+		if i == off {
+			fmt.Fprintf(w, "(C.uintptr_t)(offset)")
+		} else {
+			fmt.Fprintf(w, "%s(%s)", p.Type.CgoConversion(), RenameIfReservedGoWord(p.Name))
+		}
+	}
+	if f.Return.IsVoid() {
+		fmt.Fprintln(w, ")")
+	} else {
+		fmt.Fprintln(w, "))")
+	}
+	fmt.Fprintln(w, "}")
+}
+
 func (fs Functions) Sort() SortedFunctions {
 	sortedFunctions := make(SortedFunctions, 0, len(fs))
 	for _, f := range fs {
@@ -180,12 +350,16 @@ func (sf SortedFunctions) WriteCDeclarations(w io.Writer) {
 	for _, f := range sf {
 		f.WriteCDeclaration(w)
 	}
+	for _, f := range sf {
+		f.WriteCOffsetBridgeDefinition(w, false)
+	}
 	fmt.Fprintln(w, "// ")
 }
 
 func (sf SortedFunctions) WriteCBridgeDefinitions(w io.Writer) {
 	for _, f := range sf {
 		f.WriteCBridgeDefinition(w)
+		f.WriteCOffsetBridgeDefinition(w, true)
 	}
 	fmt.Fprintln(w, "// ")
 }
@@ -198,8 +372,8 @@ func (sf SortedFunctions) WriteGoFunctionPtrs(w io.Writer) {
 	fmt.Fprintln(w, ")")
 }
 
-func (sf SortedFunctions) WriteGoInitPackage(w io.Writer) {
-	fmt.Fprintln(w, "func Init() error {")
+func (sf SortedFunctions) WriteGoInitFeature(w io.Writer, name string) {
+	fmt.Fprintf(w, "func %s() error {\n", name)
 	for _, f := range sf {
 		f.WriteGoGetProcAddress(w)
 	}
@@ -207,9 +381,10 @@ func (sf SortedFunctions) WriteGoInitPackage(w io.Writer) {
 	fmt.Fprintln(w, "}")
 }
 
-func (sf SortedFunctions) WriteGoDefinitions(w io.Writer, usePtr bool, d *Documentation, majorVersion int) {
+func (sf SortedFunctions) WriteGoDefinitions(w io.Writer, usePtr bool, d *Documentation, docSet string) {
 	for _, f := range sf {
-		f.WriteGoDefinition(w, usePtr, d, majorVersion)
+		f.WriteGoDefinition(w, usePtr, d, docSet)
+		f.WriteGoOffsetDefinition(w, usePtr)
 	}
 	fmt.Fprintln(w, "")
 }
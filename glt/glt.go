@@ -4,47 +4,75 @@
 package glt
 
 import (
-	"reflect"
 	"fmt"
+	"reflect"
+	"unsafe"
 )
 
 type Enum uint32
 type Bitfield uint32
-type Pointer uintptr
+
+// Pointer is the type of the pointer parameters and results of the
+// generated commands. It is an unsafe.Pointer, so the Go memory it
+// points to is kept alive and in place until the command returns, as the
+// cgo pointer passing rules require. Offsets into buffer objects, as
+// passed to VertexAttribPointer or DrawElements while a buffer is bound,
+// are not Go pointers and are passed as uintptr to the Offset variants
+// of these commands, e.g. VertexAttribPointerOffset.
+type Pointer = unsafe.Pointer
 
 type GetProcAddressFunc func(name string) Pointer
 
-var GetProcAddress GetProcAddressFunc 
+var GetProcAddress GetProcAddressFunc
 
+// Ptr returns the address of the data held by data for the pointer
+// parameters of GL functions. data may be nil, a pointer to any type,
+// including structs and arrays, a slice of any element type or an
+// unsafe.Pointer. Nil pointers and empty slices give nil. Ptr panics for
+// other types, including buffer offsets; arrays must be passed by
+// pointer, since an array stored in an interface is a copy.
+//
+// SlicePtr and PtrTo do the same without reflection.
 func Ptr(data interface{}) Pointer {
 	if data == nil {
-		return Pointer(0)
+		return nil
 	}
 	v := reflect.ValueOf(data)
-	switch v.Type().Kind() {
-	case reflect.Ptr: // for pointers: *byte, *int, ...
-		e := v.Elem()
-		switch e.Kind() {
-		case
-			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
-			reflect.Float32, reflect.Float64:
-			return Pointer(e.UnsafeAddr())
+	switch v.Kind() {
+	case reflect.Ptr, reflect.UnsafePointer: // *byte, *Vertex, *[4]float32, ...
+		return v.UnsafePointer()
+	case reflect.Slice: // []int, []float32, []Vertex, ...
+		if v.Len() == 0 {
+			return nil
 		}
-	case reflect.Uintptr:
-		return Pointer(v.Pointer())
-	case reflect.Slice: // for slices and arrays: []int, []float32, ...
-		return Pointer(v.Index(0).UnsafeAddr())
+		return v.UnsafePointer()
 	case reflect.Array:
-		e := v.Index(0)
-		fmt.Println(e, e.Kind())	
-		return Pointer(v.UnsafeAddr())
+		panic(fmt.Sprintf("glt: Ptr of array %s: pass a pointer to the array or a slice", v.Type()))
+	}
+	panic(fmt.Sprintf("glt: Ptr of %s: must be a pointer or a slice", v.Type()))
+}
+
+// SlicePtr returns the address of the first element of s, nil if s is
+// empty:
+//
+//	gl.BufferData(gl.ARRAY_BUFFER, glt.SliceSize(vertices), glt.SlicePtr(vertices), gl.STATIC_DRAW)
+func SlicePtr[E any](s []E) Pointer {
+	if len(s) == 0 {
+		return nil
 	}
-	panic(fmt.Sprintf("unknown type: %s: must be a pointer or a slice.", v.Type()))
+	return unsafe.Pointer(&s[0])
+}
+
+// SliceSize returns the size of the elements of s in bytes, as expected
+// by the size parameters of BufferData and BufferSubData.
+func SliceSize[E any](s []E) int {
+	var e E
+	return len(s) * int(unsafe.Sizeof(e))
 }
 
-func (p Pointer) Offset(o uintptr) Pointer {
-	return Pointer(uintptr(p) + uintptr(o))
+// PtrTo returns the address of the value p points to, nil if p is nil.
+func PtrTo[T any](p *T) Pointer {
+	return unsafe.Pointer(p)
 }
 
 func CopyString(dest []byte, str string) {
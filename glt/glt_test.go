@@ -0,0 +1,108 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package glt
+
+import (
+	"testing"
+	"unsafe"
+)
+
+type vertex struct {
+	Pos    [3]float32
+	Normal [3]float32
+	UV     [2]float32
+}
+
+var (
+	bytes    = []byte{1, 2, 3, 4}
+	floats   = []float32{1, 2, 3, 4}
+	vertices = make([]vertex, 3)
+	i32      int32
+	flag     bool
+	v        vertex
+	array    [4]float32
+	empty    = make([]float32, 0, 4)
+)
+
+type ptrTest struct {
+	Data interface{}
+	Ptr  Pointer
+}
+
+var allTestsPtr = []ptrTest{
+	{nil, nil},
+	{bytes, unsafe.Pointer(&bytes[0])},
+	{floats[1:], unsafe.Pointer(&floats[1])},
+	{vertices, unsafe.Pointer(&vertices[0])},
+	{empty, nil},
+	{[]int(nil), nil},
+	{&i32, unsafe.Pointer(&i32)},
+	{&flag, unsafe.Pointer(&flag)},
+	{&v, unsafe.Pointer(&v)},
+	{&v.Normal, unsafe.Pointer(&v.Normal)},
+	{&array, unsafe.Pointer(&array)},
+	{(*int)(nil), nil},
+	{unsafe.Pointer(&i32), unsafe.Pointer(&i32)},
+}
+
+func TestPtr(t *testing.T) {
+	for i, test := range allTestsPtr {
+		if p := Ptr(test.Data); p != test.Ptr {
+			t.Errorf("test %d: Ptr(%T) = %p, want %p", i, test.Data, p, test.Ptr)
+		}
+	}
+}
+
+func TestPtrPanics(t *testing.T) {
+	for i, data := range []interface{}{array, 1, uintptr(24), "string", map[int]int{}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("test %d: Ptr(%T) did not panic", i, data)
+				}
+			}()
+			Ptr(data)
+		}()
+	}
+}
+
+func TestSlicePtr(t *testing.T) {
+	if p := SlicePtr(vertices[1:]); p != unsafe.Pointer(&vertices[1]) {
+		t.Errorf("SlicePtr([]vertex) = %p", p)
+	}
+	if p := SlicePtr(empty); p != nil {
+		t.Errorf("SlicePtr(empty) = %p", p)
+	}
+	if p := SlicePtr([]byte(nil)); p != nil {
+		t.Errorf("SlicePtr(nil) = %p", p)
+	}
+	if p := PtrTo(&v); p != unsafe.Pointer(&v) {
+		t.Errorf("PtrTo(&v) = %p", p)
+	}
+	if p := PtrTo[vertex](nil); p != nil {
+		t.Errorf("PtrTo(nil) = %p", p)
+	}
+}
+
+type sliceSizeTest struct {
+	Size, Want int
+}
+
+var allTestsSliceSize = []sliceSizeTest{
+	{SliceSize(bytes), 4},
+	{SliceSize(floats), 16},
+	{SliceSize(vertices), 96},
+	{SliceSize(empty), 0},
+	{SliceSize([]uint16{1, 2, 3}), 6},
+	{SliceSize([]float64{1}), 8},
+}
+
+func TestSliceSize(t *testing.T) {
+	for i, test := range allTestsSliceSize {
+		if test.Size != test.Want {
+			t.Errorf("test %d: SliceSize() = %d, want %d", i, test.Size, test.Want)
+		}
+	}
+}
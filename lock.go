@@ -0,0 +1,230 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RegistryLock pins the spec files a project is generated from. It is
+// written by pullspec, e.g.:
+//
+//	source = "https://registry.khronos.org/OpenGL/xml"
+//
+//	[[spec]]
+//	file     = "gl.xml"
+//	revision = "31811"
+//	sha256   = "9e0b8a..."
+//
+// generate refuses spec files that differ from the lock.
+type RegistryLock struct {
+	Source string
+	Specs  []LockedSpec
+}
+
+type LockedSpec struct {
+	File     string
+	Revision string // revision from the registry comment, may be empty
+	Sha256   string
+}
+
+const defaultLockFile = "gogl2.lock"
+
+var (
+	svnRevisionRegexp = regexp.MustCompile(`\$Revision: *([^$ ]+) *\$`)
+	lastUpdatedRegexp = regexp.MustCompile(`(?i)last (?:updated|modified):? *([0-9][0-9-/]+)`)
+)
+
+// registryRevision extracts the revision of the registry from its
+// comment: an SVN revision keyword or the date of the last update.
+func registryRevision(comment string) string {
+	if m := svnRevisionRegexp.FindStringSubmatch(comment); m != nil {
+		return m[1]
+	}
+	if m := lastUpdatedRegexp.FindStringSubmatch(comment); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// Revision returns the revision recorded in the registry comment.
+func (reg *SpecRegistry) Revision() string {
+	return registryRevision(reg.Comment)
+}
+
+// lockSpec returns the lock entry of spec file data.
+func lockSpec(file string, data []byte) LockedSpec {
+	var reg struct {
+		Comment string `xml:"comment"`
+	}
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	if err := d.Decode(&reg); err != nil {
+		reg.Comment = ""
+	}
+	return LockedSpec{File: file, Revision: registryRevision(reg.Comment), Sha256: sha256Hex(data)}
+}
+
+// LockSpecDir returns a lock of all spec files in specDir.
+func LockSpecDir(specDir, source string) (*RegistryLock, error) {
+	l := &RegistryLock{Source: source}
+	for _, sf := range allSpecFiles {
+		data, err := ioutil.ReadFile(filepath.Join(specDir, sf))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		l.Specs = append(l.Specs, lockSpec(sf, data))
+	}
+	if len(l.Specs) == 0 {
+		return nil, fmt.Errorf("no spec files found in %s", specDir)
+	}
+	return l, nil
+}
+
+// ReadLock reads a lock file.
+func ReadLock(file string) (*RegistryLock, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	root, err := parseToml(file, f)
+	if err != nil {
+		return nil, err
+	}
+	cr := &configReader{file: file}
+	cr.checkKeys(root, "lock", "source", "spec")
+	l := &RegistryLock{Source: cr.str(root, "source", "")}
+	if sv, ok := root["spec"]; ok {
+		tables, ok := sv.Value.([]*tomlValue)
+		if !ok {
+			cr.errorf(sv, "'spec' must be an array of tables ([[spec]])")
+			return nil, cr.err
+		}
+		for i, tv := range tables {
+			t := tv.Value.(tomlTable)
+			cr.checkKeys(t, fmt.Sprintf("spec #%d", i+1), "file", "revision", "sha256")
+			ls := LockedSpec{File: cr.str(t, "file", ""), Revision: cr.str(t, "revision", ""), Sha256: cr.str(t, "sha256", "")}
+			if ls.File == "" || ls.Sha256 == "" {
+				cr.errorf(tv, "spec #%d: 'file' and 'sha256' are required", i+1)
+			}
+			l.Specs = append(l.Specs, ls)
+		}
+	}
+	if cr.err != nil {
+		return nil, cr.err
+	}
+	return l, nil
+}
+
+// Write writes the lock to file.
+func (l *RegistryLock) Write(file string) error {
+	var b bytes.Buffer
+	fmt.Fprintln(&b, "# Registry lock written by gogl2 pullspec. Commit this file, generate")
+	fmt.Fprintln(&b, "# refuses spec files that differ from it.")
+	fmt.Fprintf(&b, "source = %s\n", strconv.Quote(l.Source))
+	for _, s := range l.Specs {
+		fmt.Fprintln(&b, "")
+		fmt.Fprintln(&b, "[[spec]]")
+		fmt.Fprintf(&b, "file     = %s\n", strconv.Quote(s.File))
+		fmt.Fprintf(&b, "revision = %s\n", strconv.Quote(s.Revision))
+		fmt.Fprintf(&b, "sha256   = %s\n", strconv.Quote(s.Sha256))
+	}
+	return writeFileAtomic(file, b.Bytes())
+}
+
+func (s LockedSpec) String() string {
+	if s.Revision != "" {
+		return fmt.Sprintf("revision %s (sha256 %.12s)", s.Revision, s.Sha256)
+	}
+	return fmt.Sprintf("sha256 %.12s", s.Sha256)
+}
+
+// Check compares the spec files in specDir with the lock and returns a
+// description of every difference.
+func (l *RegistryLock) Check(specDir string) ([]string, error) {
+	cur, err := LockSpecDir(specDir, l.Source)
+	if err != nil {
+		return nil, err
+	}
+	return l.diff(cur), nil
+}
+
+func (l *RegistryLock) diff(cur *RegistryLock) []string {
+	diffs := make([]string, 0, 4)
+	for _, s := range l.Specs {
+		found := false
+		for _, c := range cur.Specs {
+			if c.File != s.File {
+				continue
+			}
+			found = true
+			if c.Sha256 != s.Sha256 {
+				diffs = append(diffs, fmt.Sprintf("%s: locked %s, found %s", s.File, s, c))
+			}
+		}
+		if !found {
+			diffs = append(diffs, fmt.Sprintf("%s: locked %s, not found", s.File, s))
+		}
+	}
+	for _, c := range cur.Specs {
+		found := false
+		for _, s := range l.Specs {
+			found = found || s.File == c.File
+		}
+		if !found {
+			diffs = append(diffs, fmt.Sprintf("%s: not locked, found %s", c.File, c))
+		}
+	}
+	return diffs
+}
+
+// checkSpecLock fails if a lock file exists and the spec files in specDir
+// differ from it.
+func checkSpecLock(lockFile, specDir string) error {
+	if lockFile == "" {
+		return nil
+	}
+	l, err := ReadLock(lockFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	diffs, err := l.Check(specDir)
+	if err != nil {
+		return err
+	}
+	if len(diffs) > 0 {
+		return fmt.Errorf("spec files in %s differ from %s:\n  %s", specDir, lockFile, strings.Join(diffs, "\n  "))
+	}
+	return nil
+}
+
+// Outdated fetches the locked spec files from src and returns a
+// description of every file that changed.
+func (l *RegistryLock) Outdated(src specSource) ([]string, error) {
+	cur := &RegistryLock{Source: l.Source}
+	for _, s := range l.Specs {
+		data, err := src.ReadFile(s.File)
+		if err != nil {
+			return nil, err
+		}
+		cur.Specs = append(cur.Specs, lockSpec(s.File, data))
+	}
+	return l.diff(cur), nil
+}
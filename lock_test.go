@@ -0,0 +1,94 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testRevision struct {
+	Comment  string
+	Revision string
+}
+
+var allTestsRevision = []testRevision{
+	{"This file, gl.xml, is the OpenGL and OpenGL API Registry.\n$Revision: 24224 $", "24224"},
+	{"$Revision:31811$ on $Date$", "31811"},
+	{"Last updated 2013-06-17", "2013-06-17"},
+	{"Registry, last modified: 2020/04/01.", "2020/04/01"},
+	{"Mini registry for the gogl2 tests.", ""},
+}
+
+func TestRegistryRevision(t *testing.T) {
+	for _, te := range allTestsRevision {
+		if rev := registryRevision(te.Comment); rev != te.Revision {
+			t.Errorf("registryRevision(%q) = %q, expected %q", te.Comment, rev, te.Revision)
+		}
+	}
+}
+
+func TestRegistryLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogl2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	specDir := filepath.Join(dir, "glspecs")
+	os.Mkdir(specDir, 0755)
+	spec := "<registry><comment>$Revision: 42 $</comment></registry>"
+	if err := ioutil.WriteFile(filepath.Join(specDir, "gl.xml"), []byte(spec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lockFile := filepath.Join(dir, "gogl2.lock")
+	if err := checkSpecLock(lockFile, specDir); err != nil {
+		t.Errorf("checkSpecLock() without lock file failed: %s", err)
+	}
+	l, err := LockSpecDir(specDir, "https://example.com/xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []LockedSpec{{File: "gl.xml", Revision: "42", Sha256: sha256Hex([]byte(spec))}}
+	if !reflect.DeepEqual(l.Specs, expected) {
+		t.Errorf("LockSpecDir() = %v, expected %v", l.Specs, expected)
+	}
+	if err := l.Write(lockFile); err != nil {
+		t.Fatal(err)
+	}
+	rl, err := ReadLock(lockFile)
+	if err != nil || !reflect.DeepEqual(rl, l) {
+		t.Errorf("ReadLock() = %v, %v, expected %v", rl, err, l)
+	}
+	if err := checkSpecLock(lockFile, specDir); err != nil {
+		t.Errorf("checkSpecLock() failed: %s", err)
+	}
+	if diffs, err := l.Outdated(dirSource(specDir)); err != nil || len(diffs) != 0 {
+		t.Errorf("Outdated() = %v, %v, expected no changes", diffs, err)
+	}
+
+	ioutil.WriteFile(filepath.Join(specDir, "gl.xml"), []byte("<registry><comment>$Revision: 43 $</comment></registry>"), 0644)
+	ioutil.WriteFile(filepath.Join(specDir, "egl.xml"), []byte("<registry/>"), 0644)
+	err = checkSpecLock(lockFile, specDir)
+	if err == nil || !strings.Contains(err.Error(), "gl.xml: locked revision 42") || !strings.Contains(err.Error(), "egl.xml: not locked") {
+		t.Errorf("checkSpecLock() of changed specs = %v", err)
+	}
+	diffs, err := l.Outdated(dirSource(specDir))
+	if err != nil || len(diffs) != 1 || !strings.Contains(diffs[0], "found revision 43") {
+		t.Errorf("Outdated() = %v, %v, expected gl.xml changed", diffs, err)
+	}
+	os.Remove(filepath.Join(specDir, "gl.xml"))
+	if _, err := l.Outdated(dirSource(specDir)); err == nil {
+		t.Errorf("Outdated() of a source without gl.xml succeeded")
+	}
+
+	ioutil.WriteFile(lockFile, []byte("[[spec]]\nfile = \"gl.xml\"\n"), 0644)
+	if _, err := ReadLock(lockFile); err == nil || !strings.Contains(err.Error(), ":1: spec #1: 'file' and 'sha256' are required") {
+		t.Errorf("ReadLock() of an invalid lock = %v", err)
+	}
+}
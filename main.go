@@ -7,18 +7,20 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
-func generateGoPackages(specsDir string, f []Feature,  d *Documentation) {
-	ps, err := ParseSpecFile(filepath.Join(specsDir, openGLSpecFile), f)
+func generateGoPackages(specsDir string, specs []PackageSpec, d *Documentation, opts *GenOptions) (Packages, error) {
+	ps, err := ParseSpecFile(filepath.Join(specsDir, openGLSpecFile), specs)
 	if err != nil {
-		fmt.Println("Error while parsing OpenGL specification:", err)
+		return nil, fmt.Errorf("Error while parsing OpenGL specification: %s", err)
 	}
-	err = ps.GeneratePackages(d)
+	err = ps.GeneratePackages(d, opts)
 	if err != nil {
-		fmt.Println("Error while generating OpenGL packages:", err)
+		return nil, fmt.Errorf("Error while generating OpenGL packages: %s", err)
 	}
 
 	/*ps, err = ParseSpecFile(filepath.Join(specsDir, wglSpecFile))
@@ -47,69 +49,290 @@ func generateGoPackages(specsDir string, f []Feature,  d *Documentation) {
 	if err != nil {
 		fmt.Println("Error while generating EGL packages:", err)
 	}*/
+	return ps, nil
 }
 
 func downloadSpec(name string, args []string) {
 	fs := flag.NewFlagSet(name, flag.ExitOnError)
-	src := fs.String("src", "khronos", "Source URL or 'khronos'.")
+	src := fs.String("src", "khronos", "Source URL, file:// URL, local directory, tarball or 'khronos'.")
 	odir := fs.String("odir", "glspecs", "Output directory for spec files.")
+	lock := fs.String("lock", defaultLockFile, "Lock file recording the fetched specs, empty to disable.")
 	fs.Parse(args)
 	fmt.Println("Downloading specs ...")
 	if *src == "khronos" {
 		*src = khronosRegistryBaseURL
 	}
-	err := downloadAllSpecs(*src, *odir)
+	s, err := newSpecSource(*src)
+	if err == nil {
+		err = downloadAllSpecs(s, *odir)
+	}
 	if err != nil {
-		fmt.Println("Error while downloading docs:", err)
+		fmt.Println("Error while downloading specs:", err)
+		return
+	}
+	if *lock == "" {
+		return
+	}
+	l, err := LockSpecDir(*odir, *src)
+	if err == nil {
+		err = l.Write(*lock)
+	}
+	if err != nil {
+		fmt.Println("Error while writing lock file:", err)
+		return
+	}
+	for _, ls := range l.Specs {
+		fmt.Printf("Locked %s at %s\n", ls.File, ls)
+	}
+}
+
+func checkOutdated(name string, args []string) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	src := fs.String("src", "", "Source URL, file:// URL, local directory or tarball. Defaults to the source of the lock.")
+	lock := fs.String("lock", defaultLockFile, "Lock file to check.")
+	fs.Parse(args)
+	l, err := ReadLock(*lock)
+	if err != nil {
+		fmt.Println("Error while reading lock file:", err)
+		os.Exit(-1)
+	}
+	if *src == "" {
+		*src = l.Source
 	}
+	if *src == "khronos" || *src == "" {
+		*src = khronosRegistryBaseURL
+	}
+	s, err := newSpecSource(*src)
+	if err != nil {
+		fmt.Println("Error while opening source:", err)
+		os.Exit(-1)
+	}
+	diffs, err := l.Outdated(s)
+	if err != nil {
+		fmt.Println("Error while checking for updates:", err)
+		os.Exit(-1)
+	}
+	if len(diffs) > 0 {
+		fmt.Println("Specs in", *src, "differ from", *lock)
+		for _, d := range diffs {
+			fmt.Println(" ", d)
+		}
+		os.Exit(1)
+	}
+	fmt.Println("Specs in", *lock, "are up to date with", *src)
 }
 
 func downloadDoc(name string, args []string) {
 	fs := flag.NewFlagSet(name, flag.ExitOnError)
-	src := fs.String("src", "khronos", "Source URL or 'khronos'.")
+	src := fs.String("src", "khronos", "Source URL, file:// URL, local directory, tarball or 'khronos'.")
 	odir := fs.String("odir", "gldocs", "Output directory for doc files.")
 	ver := fs.Int("ver", -1, "Doc version: 2, 3, 4")
+	set := fs.String("set", "", "Documentation set, e.g. -set=gl4, -set=es3 or -set=man4 of an old SVN mirror. Replaces -ver.")
 	fs.Parse(args)
-	if *ver < 2 || *ver > 4 {
-		fmt.Println("Invalid doc version:", *ver)
-		return
+	docCat := *set
+	if docCat == "" {
+		switch *ver {
+		case 2:
+			docCat = "gl2.1"
+		case 3, 4:
+			// the refpages document GL 3 in the GL 4 set
+			docCat = "gl4"
+		default:
+			fmt.Println("Invalid doc version:", *ver)
+			return
+		}
 	}
 	fmt.Println("Downloading docs ...")
+	var s specSource
+	var err error
 	if *src == "khronos" {
-		*src = khronosDocBaseURL
+		s = newGitHubSource(khronosDocBaseURL, khronosDocListURL)
+	} else {
+		s, err = newSpecSource(*src)
+	}
+	if err == nil {
+		err = DownloadDocs(s, docCat, *odir)
 	}
-	err := DownloadDocs(*src, fmt.Sprintf("man%d", *ver), *odir)
 	if err != nil {
 		fmt.Println("Error while downloading docs:", err)
 	}
 }
 
-func generatePackages(name string, args []string) {
+func createBundle(name string, args []string) {
 	fs := flag.NewFlagSet(name, flag.ExitOnError)
 	sdir := fs.String("sdir", "glspecs", "OpenGL spec directory.")
-	ddir := fs.String("ddir", "gldocs", "Documentation directory (currently not used).")
-	feat := fs.String("f", "", "Spec features and version seperated by '|'. e.g. : -f=gl:2.1|gles1:1.0")
+	ddir := fs.String("ddir", "gldocs", "Documentation directory.")
+	out := fs.String("o", "gogl2-bundle.tar.gz", "Bundle file to create.")
 	fs.Parse(args)
-	df, err := ParseAllDocs(*ddir)
-	if err != nil {
-		fmt.Println("Error while parsing docs:", err)
-		return
+	if err := CreateBundle(*out, *sdir, *ddir); err != nil {
+		fmt.Println("Error while creating bundle:", err)
+		os.Exit(-1)
+	}
+	fmt.Println("Created bundle", *out)
+}
+
+func generateEnums(name string, args []string) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	sdir := fs.String("sdir", "glspecs", "OpenGL spec directory.")
+	dir := fs.String("dir", ".", "Directory of the Go package using the enums.")
+	prefix := fs.String("prefix", "gl", "Prefix of the enums in the package, e.g. -prefix=gl for glTEXTURE_2D.")
+	out := fs.String("o", "glenums.go", "File of the package to write the enums to.")
+	glt := fs.String("glt", defaultGltImport, "Import path of the glt package.")
+	fs.Parse(args)
+	if err := GenerateUsedEnums(filepath.Join(*sdir, openGLSpecFile), *dir, *out, *prefix, *glt); err != nil {
+		fmt.Println("Error while generating enums:", err)
+		os.Exit(-1)
+	}
+}
+
+type generateArgs struct {
+	specDir  string
+	docDir   string
+	bundle   string
+	lock     string
+	unlocked bool
+	specs    []PackageSpec
+	opts     *GenOptions
+}
+
+func parseGenerateArgs(name string, args []string) (*generateArgs, error) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	sdir := fs.String("sdir", "glspecs", "OpenGL spec directory.")
+	ddir := fs.String("ddir", "gldocs", "Documentation directory, e.g. a checkout of the Khronos OpenGL-Refpages repository.")
+	bundle := fs.String("bundle", "", "Spec and doc bundle created with the bundle command. Replaces -sdir and -ddir.")
+	lock := fs.String("lock", defaultLockFile, "Lock file the specs must match, ignored if it does not exist.")
+	unlocked := fs.Bool("unlocked", false, "Generate from specs that differ from the lock file.")
+	feat := fs.String("f", "", "Spec features and version seperated by '|'. e.g. : -f=gl:2.1,3.3-4.6|gles2:latest")
+	config := fs.String("config", "", "Project configuration file, e.g. -config=gogl2.toml. Replaces all other flags but -unlocked.")
+	include := fs.String("include", "", "Comma separated list of the only commands and enums to generate.")
+	scan := fs.String("scan", "", "Only generate the commands and enums used by the Go code in this directory.")
+	imp := fs.String("import", "", "Import path of the generated package in the code scanned with -scan.")
+	opts := DefaultGenOptions()
+	fs.StringVar(&opts.OutDir, "odir", opts.OutDir, "Output root directory for generated packages.")
+	fs.StringVar(&opts.Name, "pkg", opts.Name, "Package name template, e.g. -pkg=gl{{.Version.Major}}{{.Version.Minor}}")
+	fs.StringVar(&opts.Path, "pkgpath", opts.Path, "Package directory template relative to -odir.")
+	fs.StringVar(&opts.GltImport, "glt", opts.GltImport, "Import path of the glt package used by generated code.")
+	fs.StringVar(&opts.DocSet, "docset", "", "Documentation set of the command docs, e.g. gl4. Default is the best available set.")
+	fs.Parse(args)
+	if *config != "" {
+		// the configuration replaces every flag but -unlocked
+		var conflict []string
+		fs.Visit(func(f *flag.Flag) {
+			if f.Name != "config" && f.Name != "unlocked" {
+				conflict = append(conflict, "-"+f.Name)
+			}
+		})
+		if len(conflict) > 0 {
+			return nil, fmt.Errorf("%s and -config can not be used together", strings.Join(conflict, ", "))
+		}
+		c, err := ParseConfig(*config)
+		if err != nil {
+			return nil, fmt.Errorf("Error while parsing configuration: %s", err)
+		}
+		return &generateArgs{c.SpecDir, c.DocDir, c.Bundle, c.Lock, *unlocked, c.Packages, &c.Options}, nil
 	}
 	f, err := ParseFeatureList(*feat)
 	if err != nil {
-		fmt.Println("Error while parsing feature arguments:", err)
-		return
+		return nil, fmt.Errorf("Error while parsing feature arguments: %s", err)
+	}
+	specs := f.PackageSpecs()
+	if *include != "" || *scan != "" {
+		if len(specs) != 1 || (*scan == "") != (*imp == "") {
+			return nil, fmt.Errorf("-include and -scan require a single package; -scan requires -import")
+		}
+		if *include != "" {
+			specs[0].Include = strings.Split(*include, ",")
+		}
+		specs[0].Scan, specs[0].Import = *scan, *imp
+	}
+	return &generateArgs{*sdir, *ddir, *bundle, *lock, *unlocked, specs, opts}, nil
+}
+
+func (ga *generateArgs) generate() (Packages, error) {
+	if ga.bundle != "" {
+		tmp, err := ioutil.TempDir("", "gogl2-bundle")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tmp)
+		ga.specDir, ga.docDir, err = ExtractBundle(ga.bundle, tmp)
+		if err != nil {
+			return nil, fmt.Errorf("Error while reading bundle: %s", err)
+		}
+	}
+	if err := checkSpecLock(ga.lock, ga.specDir); err != nil {
+		if !ga.unlocked {
+			return nil, fmt.Errorf("Error while checking lock file: %s\nRun pullspec to update the lock or use -unlocked.", err)
+		}
+		fmt.Println("Ignoring lock file:", err)
+	}
+	df, err := ParseAllDocs(ga.docDir)
+	if err != nil {
+		return nil, fmt.Errorf("Error while parsing docs: %s", err)
 	}
 	fmt.Println("Generate Bindings ...")
-	generateGoPackages(*sdir, f, df)
+	return generateGoPackages(ga.specDir, ga.specs, df, ga.opts)
+}
+
+func generatePackages(name string, args []string) {
+	ga, err := parseGenerateArgs(name, args)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+	if _, err := ga.generate(); err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+}
+
+// verifyPackages implements the verify command and returns the exit code: 0 if
+// the bindings are up to date, 1 if they differ and -1 on errors.
+func verifyPackages(name string, args []string) int {
+	ga, err := parseGenerateArgs(name, args)
+	if err != nil {
+		fmt.Println(err)
+		return -1
+	}
+	tmp, err := ioutil.TempDir("", "gogl2-verify")
+	if err != nil {
+		fmt.Println("Error while creating temporary directory:", err)
+		return -1
+	}
+	defer os.RemoveAll(tmp)
+	committed := ga.opts.OutDir
+	ga.opts.OutDir = tmp
+	ps, err := ga.generate()
+	if err != nil {
+		fmt.Println(err)
+		return -1
+	}
+	diffs, err := ps.Compare(tmp, committed)
+	if err != nil {
+		fmt.Println("Error while comparing packages:", err)
+		return -1
+	}
+	if len(diffs) > 0 {
+		fmt.Println("Generated bindings differ from", committed)
+		for _, d := range diffs {
+			fmt.Println(" ", d)
+		}
+		return 1
+	}
+	fmt.Println("Bindings in", committed, "are up to date.")
+	return 0
 }
 
 func printUsage(name string) {
 	fmt.Printf("Usage:     %s command [arguments]\n", name)
 	fmt.Println("Commands:")
 	fmt.Println(" pullspec  Download spec files.")
+	fmt.Println(" outdated  Check whether the locked spec files changed.")
 	fmt.Println(" pulldoc   Download documentation files.")
+	fmt.Println(" bundle    Pack spec and documentation files into one archive.")
 	fmt.Println(" generate  Generate bindings.")
+	fmt.Println(" verify    Check that generated bindings are up to date.")
+	fmt.Println(" enums     Generate the enums a Go package uses, e.g. util/gltex.")
 	fmt.Printf("Type %s <command> -help for a detailed command description.\n", name)
 }
 
@@ -130,10 +353,20 @@ func main() {
 	switch command {
 	case "pullspec":
 		downloadSpec("pullspec", args[1:])
+	case "outdated":
+		checkOutdated("outdated", args[1:])
 	case "pulldoc":
 		downloadDoc("pulldoc", args[1:])
+	case "bundle":
+		createBundle("bundle", args[1:])
 	case "generate":
 		generatePackages("generate", args[1:])
+	case "verify":
+		if code := verifyPackages("verify", args[1:]); code != 0 {
+			os.Exit(code)
+		}
+	case "enums":
+		generateEnums("enums", args[1:])
 	default:
 		fmt.Printf("Unknown command: '%s'\n", command)
 		printUsage(name)
@@ -5,26 +5,116 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"go/token"
 	"io"
+	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"text/template"
 )
 
+const (
+	defaultPackageName = "{{.Api}}"
+	defaultPackagePath = "{{.Api}}/{{.Version}}/{{.Name}}"
+	defaultGltImport   = "github.com/chsc/gogl2/glt"
+)
+
+// GenOptions controls where generated packages are written and how they
+// are named. Name and Path are text/template strings evaluated against
+// the Package; Path may refer to the already expanded .Name.
+type GenOptions struct {
+	OutDir    string
+	Name      string
+	Path      string
+	GltImport string
+	DocSet    string // documentation set, "" for the best available one
+}
+
+func DefaultGenOptions() *GenOptions {
+	return &GenOptions{OutDir: ".", Name: defaultPackageName, Path: defaultPackagePath, GltImport: defaultGltImport}
+}
+
 type Package struct {
-	Name        string
-	Api         string
-	Version     Version
-	TypeDefs    []TypeDef
-	Enums       Enums
-	Functions   Functions
+	Name       string
+	Api        string
+	Version    Version
+	Profile    string
+	Path       string
+	Backend    string
+	TypeDefs   []TypeDef
+	Enums      Enums
+	Functions  Functions
+	Features   []string          // features in the order they were added
+	CmdFeature map[string]string // command name -> feature that introduced it
+	Spec       PackageSpec       // spec the package was created from
+	SpecFile   string            // registry file name
+	SpecHash   string            // SHA-256 of the registry file
+	Dir        string            // package directory relative to the output root
+	docSet     string            // documentation set of the command docs, "" if undocumented
+	config     string            // configuration that regenerates the package
 }
 
 type Packages []*Package
 
+const (
+	generatorVersion = "0.2.0"
+	generatedBanner  = "// GoGL2 - automatically generated OpenGL binding: http://github.com/chsc/gogl2"
+	cHeaderFile      = "gogl2.h"
+)
+
+// configString returns the configuration file that makes generate -config
+// write the package as it is, one line per key. It records all options
+// which influence the generated code.
+func (p *Package) configString(opts *GenOptions) string {
+	ps := p.Spec
+	var o []string
+	str := func(key, val string) {
+		if val != "" {
+			o = append(o, fmt.Sprintf("%s = %s", key, strconv.Quote(val)))
+		}
+	}
+	list := func(key string, vals []string) {
+		if len(vals) > 0 {
+			q := make([]string, len(vals))
+			for i, v := range vals {
+				q[i] = strconv.Quote(v)
+			}
+			o = append(o, fmt.Sprintf("%s = [%s]", key, strings.Join(q, ", ")))
+		}
+	}
+	str("glt", opts.GltImport)
+	str("docset", p.docSet)
+	o = append(o, "[[package]]")
+	str("name", p.Name)
+	str("api", ps.Api)
+	str("version", ps.Version.String())
+	str("profile", ps.Profile)
+	list("extensions", ps.Extensions)
+	list("include", ps.Include)
+	list("exclude", ps.Exclude)
+	str("scan", filepath.ToSlash(ps.Scan))
+	str("import", ps.Import)
+	str("path", p.Dir)
+	str("backend", ps.Backend)
+	return strings.Join(o, "\n")
+}
+
 func (p *Package) writeHeader(w io.Writer) {
-	fmt.Fprintln(w, "// GoGL2 - automatically generated OpenGL binding: http://github.com/chsc/gogl2")
+	fmt.Fprintln(w, generatedBanner)
+	fmt.Fprintln(w, "//")
+	fmt.Fprintf(w, "// Generated by gogl2 %s from %s (sha256 %s).\n", generatorVersion, p.SpecFile, p.SpecHash)
+	fmt.Fprintln(w, "// Regenerate it with this configuration and generate -config:")
+	fmt.Fprintln(w, "//")
+	for _, l := range strings.Split(p.config, "\n") {
+		fmt.Fprintf(w, "//\t%s\n", l)
+	}
 	fmt.Fprintln(w, "//")
 	writeKhronosDocCopyright(w)
 	writeSgiDocCopyright(w)
@@ -43,12 +133,12 @@ func (p *Package) writeCTypes(w io.Writer) {
 		}
 		if t.Api == "" {
 			if len(t.Comment) > 0 {
-				fmt.Fprintln(w, "// /*", t.Comment, "*/")
+				fmt.Fprintln(w, "/*", t.Comment, "*/")
 			}
-			fmt.Fprintln(w, "// ", strings.Replace(t.CDefinition, "\n", "\n// ", -1))
+			fmt.Fprintln(w, t.CDefinition)
 		}
 	}
-	fmt.Fprintln(w, "// ")
+	fmt.Fprintln(w, "")
 }
 
 func (p *Package) writeCgoFlags(w io.Writer) {
@@ -59,25 +149,60 @@ func (p *Package) writeCgoFlags(w io.Writer) {
 }
 
 func (p *Package) writeAPIDefinitions(w io.Writer) {
-	fmt.Fprintln(w, "// #ifndef APIENTRY")
-	fmt.Fprintln(w, "// #define APIENTRY")
-	fmt.Fprintln(w, "// #endif")
-	fmt.Fprintln(w, "// #ifndef APIENTRYP")
-	fmt.Fprintln(w, "// #define APIENTRYP APIENTRY *")
-	fmt.Fprintln(w, "// #endif")
-	fmt.Fprintln(w, "// #ifndef GLAPI")
-	fmt.Fprintln(w, "// #define GLAPI extern")
-	fmt.Fprintln(w, "// #endif")
+	fmt.Fprintln(w, "#ifndef APIENTRY")
+	fmt.Fprintln(w, "#define APIENTRY")
+	fmt.Fprintln(w, "#endif")
+	fmt.Fprintln(w, "#ifndef APIENTRYP")
+	fmt.Fprintln(w, "#define APIENTRYP APIENTRY *")
+	fmt.Fprintln(w, "#endif")
+	fmt.Fprintln(w, "#ifndef GLAPI")
+	fmt.Fprintln(w, "#define GLAPI extern")
+	fmt.Fprintln(w, "#endif")
+	fmt.Fprintln(w, "")
+}
+
+func (p *Package) writeCInclude(w io.Writer) {
+	fmt.Fprintf(w, "// #include \"%s\"\n", cHeaderFile)
 	fmt.Fprintln(w, "//")
 }
 
+// writeCHeader writes the C header shared by the cgo preambles of all
+// files of the package.
+func (p *Package) writeCHeader(dir string) error {
+	w, err := os.Create(filepath.Join(dir, cHeaderFile))
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	guard := strings.ToUpper(p.Name) + "_GOGL2_H"
+	fmt.Fprintln(w, "/*", strings.TrimPrefix(generatedBanner, "// "))
+	fmt.Fprintf(w, " * Generated by gogl2 %s from %s (sha256 %s).\n", generatorVersion, p.SpecFile, p.SpecHash)
+	fmt.Fprintln(w, " * Regenerate it with this configuration and generate -config:")
+	fmt.Fprintln(w, " *")
+	for _, l := range strings.Split(p.config, "\n") {
+		fmt.Fprintf(w, " *\t%s\n", l)
+	}
+	fmt.Fprintln(w, " */")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "#ifndef", guard)
+	fmt.Fprintln(w, "#define", guard)
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "#include <stdint.h>")
+	fmt.Fprintln(w, "")
+	p.writeAPIDefinitions(w)
+	p.writeCTypes(w)
+	fmt.Fprintln(w, "#endif")
+	return nil
+}
+
 func (p *Package) writeConvFunctions(w io.Writer) {
 	fmt.Fprintln(w, "func GLBoolean(b C.GLboolean) bool {")
-	fmt.Fprintln(w, "	return b == TRUE")
+	// literals, TRUE and FALSE are missing from restricted packages
+	fmt.Fprintln(w, "	return b == 1")
 	fmt.Fprintln(w, "}")
 	fmt.Fprintln(w, "func GoBoolean(b bool) C.GLboolean {")
-	fmt.Fprintln(w, "	if b { return TRUE }")
-	fmt.Fprintln(w, "	return FALSE")
+	fmt.Fprintln(w, "	if b { return 1 }")
+	fmt.Fprintln(w, "	return 0")
 	fmt.Fprintln(w, "}")
 	fmt.Fprintln(w, "func cgoPtr1(p *glt.Pointer) *unsafe.Pointer {")
 	fmt.Fprintln(w, " return (*unsafe.Pointer)(unsafe.Pointer(p))")
@@ -100,19 +225,46 @@ func (p *Package) writeEnums(dir string) error {
 	return nil
 }
 
-func (p *Package) writeCommands(dir string, useFuncPtrs bool, d *Documentation) error {
+// writeCommands writes the package-wide part of the commands: cgo flags,
+// conversion helpers and Init, which initializes all features.
+func (p *Package) writeCommands(dir string, features []string, useFuncPtrs bool, opts *GenOptions) error {
 	w, err := os.Create(filepath.Join(dir, "commands.go"))
 	if err != nil {
 		return err
 	}
 	defer w.Close()
 
-	sf := p.Functions.Sort()
-
 	p.writeHeader(w)
 	p.writeCgoFlags(w)
-	p.writeAPIDefinitions(w)
-	p.writeCTypes(w)
+	p.writeCInclude(w)
+	fmt.Fprintln(w, "import \"C\"")
+	fmt.Fprintf(w, "import \"%s\"\n", opts.GltImport)
+	fmt.Fprintln(w, "import \"unsafe\"")
+	fmt.Fprintln(w, "")
+	p.writeConvFunctions(w)
+	fmt.Fprintln(w, "func Init() error {")
+	if useFuncPtrs {
+		for _, f := range features {
+			fmt.Fprintf(w, "	if err := %s(); err != nil { return err }\n", featureInitName(f))
+		}
+	}
+	fmt.Fprintln(w, "	return nil")
+	fmt.Fprintln(w, "}")
+	p.writeFooter(w)
+
+	return nil
+}
+
+// writeFeatureCommands writes the commands introduced by a single feature.
+func (p *Package) writeFeatureCommands(dir, feature string, sf SortedFunctions, useFuncPtrs bool, d *Documentation, opts *GenOptions) error {
+	w, err := os.Create(filepath.Join(dir, featureFileName(feature)))
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	p.writeHeader(w)
+	p.writeCInclude(w)
 	if useFuncPtrs {
 		sf.WriteCFunctionPtrTypedefs(w)
 		sf.WriteCBridgeDefinitions(w)
@@ -120,42 +272,232 @@ func (p *Package) writeCommands(dir string, useFuncPtrs bool, d *Documentation)
 		sf.WriteCDeclarations(w)
 	}
 	fmt.Fprintln(w, "import \"C\"")
-	fmt.Fprintln(w, "import \"errors\"")
-	fmt.Fprintln(w, "import \"github.com/chsc/gogl2/glt\"")
+	if useFuncPtrs {
+		fmt.Fprintln(w, "import \"errors\"")
+	}
+	fmt.Fprintf(w, "import \"%s\"\n", opts.GltImport)
 	fmt.Fprintln(w, "import \"unsafe\"")
 	fmt.Fprintln(w, "")
-	sf.WriteGoFunctionPtrs(w)
-	p.writeConvFunctions(w)
-	sf.WriteGoDefinitions(w, useFuncPtrs, d, p.Version.Major)
-	sf.WriteGoInitPackage(w)
+	fmt.Fprintln(w, "var _ glt.Enum")
+	fmt.Fprintln(w, "var _ unsafe.Pointer")
+	fmt.Fprintln(w, "")
+	if useFuncPtrs {
+		sf.WriteGoFunctionPtrs(w)
+	}
+	sf.WriteGoDefinitions(w, useFuncPtrs, d, p.docSet)
+	if useFuncPtrs {
+		sf.WriteGoInitFeature(w, featureInitName(feature))
+	}
 	p.writeFooter(w)
 
 	return nil
 }
 
-func (p *Package) GeneratePackage(d *Documentation) error {
+// commandsByFeature groups the commands of the package by the feature that
+// introduced them. Features without commands are omitted.
+func (p *Package) commandsByFeature() ([]string, map[string]SortedFunctions) {
+	byFeature := make(map[string]Functions)
+	for name, f := range p.Functions {
+		ft := p.CmdFeature[name]
+		if byFeature[ft] == nil {
+			byFeature[ft] = make(Functions)
+		}
+		byFeature[ft][name] = f
+	}
+	features := make([]string, 0, len(byFeature))
+	sorted := make(map[string]SortedFunctions, len(byFeature))
+	for _, ft := range p.Features {
+		if fs, ok := byFeature[ft]; ok {
+			if _, done := sorted[ft]; !done {
+				features = append(features, ft)
+				sorted[ft] = fs.Sort()
+			}
+		}
+	}
+	return features, sorted
+}
+
+// removeGeneratedFiles removes Go files of a previous run, so that files of
+// features which are no longer part of the package do not linger.
+func removeGeneratedFiles(dir string) error {
+	files, err := generatedFiles(dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := os.Remove(filepath.Join(dir, file)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Package) expand(name, text string) (string, error) {
+	t, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, p); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func (p *Package) GeneratePackage(d *Documentation, opts *GenOptions) error {
+	if p.Name == "" {
+		name, err := p.expand("name", opts.Name)
+		if err != nil {
+			return fmt.Errorf("invalid package name template: %s", err)
+		}
+		p.Name = name
+	}
+	if !token.IsIdentifier(p.Name) {
+		return fmt.Errorf("invalid package name: '%s'", p.Name)
+	}
+	pkgPath := p.Path
+	if pkgPath == "" {
+		var err error
+		pkgPath, err = p.expand("path", opts.Path)
+		if err != nil {
+			return fmt.Errorf("invalid package path template: %s", err)
+		}
+	}
+	p.Dir = path.Clean(filepath.ToSlash(pkgPath))
+	p.docSet = opts.DocSet
+	if p.docSet == "" {
+		p.docSet = d.DocSet(p.Api, p.Version)
+	} else if !d.hasSet(p.docSet) {
+		return fmt.Errorf("documentation set '%s' not found", p.docSet)
+	}
+	p.config = p.configString(opts)
 	fmt.Println("Generating package", p.Name, p.Version)
-	dir := ""
-	usePtr := true
-	dir = filepath.Join(p.Api, p.Version.String(), p.Name)
+	if len(d.CommandDocs) > 0 {
+		if p.docSet == "" {
+			fmt.Printf("No documentation set for %s %s, generating package %s without docs\n", p.Api, p.Version, p.Name)
+		} else if missing := d.undocumented(p.docSet, p.Functions); len(missing) > 0 {
+			fmt.Printf("%d commands of package %s have no documentation in %s: %s\n", len(missing), p.Name, p.docSet, strings.Join(missing, ", "))
+		}
+	}
+	usePtr := p.Backend != backendStatic
+	dir := filepath.Join(opts.OutDir, filepath.FromSlash(p.Dir))
 	err := os.MkdirAll(dir, 0755)
 	if err != nil {
 		return err
 	}
+	err = removeGeneratedFiles(dir)
+	if err != nil {
+		return err
+	}
 	err = p.writeEnums(dir)
 	if err != nil {
 		return err
 	}
-	err = p.writeCommands(dir, usePtr, d)
+	err = p.writeCHeader(dir)
+	if err != nil {
+		return err
+	}
+	features, byFeature := p.commandsByFeature()
+	err = p.writeCommands(dir, features, usePtr, opts)
 	if err != nil {
 		return err
 	}
+	for _, ft := range features {
+		err = p.writeFeatureCommands(dir, ft, byFeature[ft], usePtr, d, opts)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (ps Packages) GeneratePackages(df *Documentation) error {
+// generatedFiles returns the sorted names of all files in dir written by
+// the generator.
+func generatedFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(files)+1)
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		line, _ := bufio.NewReader(f).ReadString('\n')
+		f.Close()
+		if strings.TrimSpace(line) == generatedBanner {
+			names = append(names, filepath.Base(file))
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, cHeaderFile)); err == nil {
+		names = append(names, cHeaderFile)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Compare compares the generated files of all packages below dir with
+// the files below the reference directory and describes every difference.
+func (ps Packages) Compare(dir, ref string) ([]string, error) {
+	diffs := make([]string, 0)
+	for _, p := range ps {
+		pdir := filepath.Join(dir, filepath.FromSlash(p.Dir))
+		rdir := filepath.Join(ref, filepath.FromSlash(p.Dir))
+		files, err := generatedFiles(pdir)
+		if err != nil {
+			return nil, err
+		}
+		refFiles, err := generatedFiles(rdir)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range refFiles {
+			if !contains(files, f) {
+				diffs = append(diffs, fmt.Sprintf("%s: not generated anymore", path.Join(p.Dir, f)))
+			}
+		}
+		for _, f := range files {
+			if !contains(refFiles, f) {
+				diffs = append(diffs, fmt.Sprintf("%s: missing", path.Join(p.Dir, f)))
+				continue
+			}
+			a, err := ioutil.ReadFile(filepath.Join(pdir, f))
+			if err != nil {
+				return nil, err
+			}
+			b, err := ioutil.ReadFile(filepath.Join(rdir, f))
+			if err != nil {
+				return nil, err
+			}
+			if line := firstDiffLine(a, b); line > 0 {
+				diffs = append(diffs, fmt.Sprintf("%s:%d: differs", path.Join(p.Dir, f), line))
+			}
+		}
+	}
+	return diffs, nil
+}
+
+// firstDiffLine returns the first line number where a and b differ or 0.
+func firstDiffLine(a, b []byte) int {
+	if bytes.Equal(a, b) {
+		return 0
+	}
+	la, lb := bytes.Split(a, []byte("\n")), bytes.Split(b, []byte("\n"))
+	for i := 0; i < len(la) && i < len(lb); i++ {
+		if !bytes.Equal(la[i], lb[i]) {
+			return i + 1
+		}
+	}
+	if len(la) < len(lb) {
+		return len(la) + 1
+	}
+	return len(lb) + 1
+}
+
+func (ps Packages) GeneratePackages(df *Documentation, opts *GenOptions) error {
 	for _, p := range ps {
-		err := p.GeneratePackage(df)
+		err := p.GeneratePackage(df, opts)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,222 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package main
+
+import (
+	"flag"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata/golden")
+
+const goldenDir = "testdata/golden"
+
+var goldenSpecs = []PackageSpec{
+	{Api: "gl", Version: Version{2, 0}, Extensions: []string{"GL_NV_half_float"}, Backend: backendFuncPtr, Path: "gl20"},
+	{Api: "gl", Version: Version{3, 2}, Profile: "core", Extensions: []string{"GL_ARB_debug_output"}, Backend: backendFuncPtr, Path: "gl32core", Name: "gl32"},
+	{Api: "gles2", Version: Version{2, 0}, Backend: backendStatic, Path: "gles20"},
+}
+
+func copyGoldenFiles(src, dest string) error {
+	files, err := generatedFiles(src)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dest); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+	for _, f := range files {
+		data, err := ioutil.ReadFile(filepath.Join(src, f))
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dest, f), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TestGolden generates packages from the mini registry in testdata and
+// compares them with the golden files. Run 'go test -update' to accept
+// changes of the generated code.
+func TestGolden(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogl2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ps, err := ParseSpecFile("testdata/gl.xml", goldenSpecs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultGenOptions()
+	opts.OutDir = dir
+	if err := ps.GeneratePackages(&Documentation{}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		for _, p := range ps {
+			err := copyGoldenFiles(filepath.Join(dir, p.Dir), filepath.Join(goldenDir, p.Dir))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	diffs, err := ps.Compare(dir, goldenDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range diffs {
+		t.Errorf("golden file mismatch: %s", d)
+	}
+}
+
+// TestGoldenConfig regenerates every golden package from the configuration
+// recorded in its header and compares it with the golden files.
+func TestGoldenConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogl2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, spec := range goldenSpecs {
+		data, err := ioutil.ReadFile(filepath.Join(goldenDir, spec.Path, "enums.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var config []string
+		for _, l := range strings.Split(string(data), "\n") {
+			if strings.HasPrefix(l, "//\t") {
+				config = append(config, l[3:])
+			}
+		}
+		file := filepath.Join(dir, spec.Path+".toml")
+		if err := ioutil.WriteFile(file, []byte(strings.Join(config, "\n")), 0644); err != nil {
+			t.Fatal(err)
+		}
+		c, err := ParseConfig(file)
+		if err != nil {
+			t.Fatalf("configuration of %s: %v", spec.Path, err)
+		}
+		ps, err := ParseSpecFile("testdata/gl.xml", c.Packages)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Options.OutDir = filepath.Join(dir, "out")
+		if err := ps.GeneratePackages(&Documentation{}, &c.Options); err != nil {
+			t.Fatal(err)
+		}
+		diffs, err := ps.Compare(c.Options.OutDir, goldenDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range diffs {
+			t.Errorf("%s regenerated from its configuration: %s", spec.Path, d)
+		}
+	}
+}
+
+// undeclared returns the identifiers the Go files in dir use but neither
+// declare nor import, ignoring predeclared identifiers.
+func undeclared(dir string) ([]string, error) {
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, pkg := range pkgs {
+		declared := make(map[string]bool)
+		for _, f := range pkg.Files {
+			for name := range f.Scope.Objects {
+				declared[name] = true
+			}
+			for _, imp := range f.Imports {
+				name := imp.Path.Value[1 : len(imp.Path.Value)-1]
+				if imp.Name != nil {
+					name = imp.Name.Name
+				}
+				declared[filepath.Base(name)] = true
+			}
+		}
+		for _, f := range pkg.Files {
+			for _, id := range f.Unresolved {
+				if !declared[id.Name] && types.Universe.Lookup(id.Name) == nil {
+					missing = append(missing, id.Name)
+				}
+			}
+		}
+	}
+	return missing, nil
+}
+
+// TestRestrictedPackage checks that a package restricted to a single
+// command still declares every identifier its code uses.
+func TestRestrictedPackage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogl2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	specs := []PackageSpec{{Api: "gl", Version: Version{2, 0}, Include: []string{"glClear"}, Backend: backendFuncPtr, Path: "gl20"}}
+	ps, err := ParseSpecFile("testdata/gl.xml", specs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultGenOptions()
+	opts.OutDir = dir
+	if err := ps.GeneratePackages(&Documentation{}, opts); err != nil {
+		t.Fatal(err)
+	}
+	missing, err := undeclared(filepath.Join(dir, ps[0].Dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing) != 0 {
+		t.Errorf("restricted package uses undeclared identifiers %v", missing)
+	}
+}
+
+// TestDocSetOption checks that the documentation set is recorded in the
+// configuration of the generated files and that unknown sets are rejected.
+func TestDocSetOption(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogl2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	d, err := ParseAllDocs("testdata/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	specs := []PackageSpec{{Api: "gl", Version: Version{2, 0}, Include: []string{"glClear"}, Backend: backendFuncPtr, Path: "gl20"}}
+	ps, err := ParseSpecFile("testdata/gl.xml", specs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultGenOptions()
+	opts.OutDir = dir
+	opts.DocSet = "gl4"
+	if err := ps.GeneratePackages(d, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(ps[0].config, "docset = \"gl4\"") {
+		t.Errorf("configuration %q does not record the documentation set", ps[0].config)
+	}
+	opts.DocSet = "gl9"
+	if err := ps.GeneratePackages(d, opts); err == nil {
+		t.Errorf("GeneratePackages() with unknown documentation set succeeded")
+	}
+}
@@ -6,22 +6,26 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/xml"
 	"fmt"
 	"io"
-	"os"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
 	"strings"
 )
 
 type SpecRegistry struct {
-	XMLName  xml.Name        `xml:"registry"`
-	Comment  string          `xml:"comment"`
-	Types    []SpecType      `xml:"types>type"`
-	Groups   []SpecGroup     `xml:"groups>group"`
-	Enums    []SpecEnumToken `xml:"enums"`
-	Commands []SpecCommand   `xml:"commands>command"`
-	Features []SpecFeature   `xml:"feature"`
-	// TODO: extensions
+	XMLName    xml.Name        `xml:"registry"`
+	Comment    string          `xml:"comment"`
+	Types      []SpecType      `xml:"types>type"`
+	Groups     []SpecGroup     `xml:"groups>group"`
+	Enums      []SpecEnumToken `xml:"enums"`
+	Commands   []SpecCommand   `xml:"commands>command"`
+	Features   []SpecFeature   `xml:"feature"`
+	Extensions []SpecExtension `xml:"extensions>extension"`
+	Hash       string          `xml:"-"` // SHA-256 of the registry file
 }
 
 type SpecType struct {
@@ -81,13 +85,23 @@ type SpecFeature struct {
 	Removes  []SpecRemove  `xml:"remove"`
 }
 
+type SpecExtension struct {
+	Name      string        `xml:"name,attr"`
+	Supported string        `xml:"supported,attr"`
+	Requires  []SpecRequire `xml:"require"`
+}
+
 type SpecRequire struct {
+	Api      string           `xml:"api,attr"`
+	Profile  string           `xml:"profile,attr"`
 	Comment  string           `xml:"comment,attr"`
 	Enums    []SpecEnumRef    `xml:"enum"`
 	Commands []SpecCommandRef `xml:"command"`
 }
 
 type SpecRemove struct {
+	Api      string           `xml:"api,attr"`
+	Profile  string           `xml:"profile,attr"`
 	Comment  string           `xml:"comment,attr"`
 	Enums    []SpecEnumRef    `xml:"enum"`
 	Commands []SpecCommandRef `xml:"command"`
@@ -101,6 +115,15 @@ type SpecCommandRef struct {
 	Name string `xml:"name,attr"`
 }
 
+func (e SpecExtension) Supports(api string) bool {
+	for _, s := range strings.Split(e.Supported, "|") {
+		if s == api {
+			return true
+		}
+	}
+	return false
+}
+
 func (st *SpecType) Parse() (TypeDef, error) {
 	typed := TypeDef{Name: st.Name, Comment: st.Comment, Api: st.Api, CDefinition: ""}
 	readName := false
@@ -226,16 +249,16 @@ func (si SpecSignature) Parse() (string, Type, error) {
 
 func readSpecFile(file string) (*SpecRegistry, error) {
 	var reg SpecRegistry
-	f, err := os.Open(file)
+	data, err := ioutil.ReadFile(file)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-	d := xml.NewDecoder(f)
+	d := xml.NewDecoder(bytes.NewReader(data))
 	err = d.Decode(&reg)
 	if err != nil {
 		return nil, err
 	}
+	reg.Hash = fmt.Sprintf("%x", sha256.Sum256(data))
 	return &reg, nil
 }
 
@@ -252,7 +275,7 @@ func commandsToFunctions(commands []SpecCommand) Functions {
 				if err != nil {
 					fmt.Printf("Unable to parse parameter signature '%s' of function '%s': %s\n", (string)(p.Inner), cname, err)
 				} else {
-					parameters = append(parameters, Parameter{Name: pname, Type: pt})
+					parameters = append(parameters, Parameter{Name: pname, Type: pt, Group: p.Group})
 				}
 			}
 			//fmt.Println(cname)
@@ -274,96 +297,462 @@ func findEnum(enumName string, est []SpecEnumToken) (string, string) {
 	return "", ""
 }
 
-func addEnums(ps Packages, api string, ver Version, enumNames []SpecEnumRef, et []SpecEnumToken) {
-	fmt.Println("Adding enums from version", api, ver , "to")
-	for _, pc := range ps {
-		if pc.Api != api {
-			continue
+func (p *Package) hasProfile(profile string) bool {
+	return profile == "" || p.Profile == "" || profile == p.Profile
+}
+
+func (p *Package) hasApi(api string) bool {
+	return api == "" || api == p.Api
+}
+
+func (p *Package) addEnums(enumNames []SpecEnumRef, et []SpecEnumToken, feature string) {
+	for _, en := range enumNames {
+		val, grp := findEnum(en.Name, et)
+		if val == "" {
+			fmt.Println("Not found:", en.Name)
+		}
+		//fmt.Println("adding", en)
+		ft := feature
+		if e, ok := p.Enums[en.Name]; ok {
+			ft = e.Feature
 		}
-		if pc.Version.Compare(ver) < 0 {
+		p.Enums[en.Name] = &Enum{Name: TrimGLEnumPrefix(en.Name), Value: val, Group: grp, Feature: ft}
+	}
+}
+
+func apiDisplayName(api string) string {
+	switch {
+	case api == "gl":
+		return "OpenGL"
+	case strings.HasPrefix(api, "gles"):
+		return "OpenGL ES"
+	}
+	return api
+}
+
+// markDeprecated marks every command and enum of the package that a core
+// profile (or any) <remove> of the package's API deletes, including
+// removals in versions newer than the package.
+func (p *Package) markDeprecated(reg *SpecRegistry) {
+	for _, f := range reg.Features {
+		if f.Api != p.Api {
 			continue
 		}
-		fmt.Println(" package", pc.Api, pc.Version)
-		for _, en := range enumNames {
-			val, grp := findEnum(en.Name, et)
-			if val == "" {
-				fmt.Println("Not found:", en.Name)
+		for _, d := range f.Removes {
+			if !p.hasApi(d.Api) {
+				continue
+			}
+			msg := fmt.Sprintf("Deprecated: removed in %s %s.", apiDisplayName(f.Api), f.Number)
+			if d.Profile != "" {
+				msg = fmt.Sprintf("Deprecated: removed from the %s profile in %s %s.", d.Profile, apiDisplayName(f.Api), f.Number)
+			}
+			for _, c := range d.Commands {
+				if fn, ok := p.Functions[TrimGLCmdPrefix(c.Name)]; ok && fn.Deprecated == "" {
+					fn.Deprecated = msg
+				}
+			}
+			for _, en := range d.Enums {
+				if e, ok := p.Enums[en.Name]; ok && e.Deprecated == "" {
+					e.Deprecated = msg
+				}
 			}
-			//fmt.Println("adding", en)
-			pc.Enums[en.Name] = &Enum{Name: TrimGLEnumPrefix(en.Name), Value: val, Group: grp}
 		}
 	}
 }
 
-func removeEnums(ps Packages, api string, ver Version, enumNames []SpecEnumRef) {
-	fmt.Println("Removing enums from version", api, ver, "to")
-	for _, pc := range ps {
-		if pc.Api != api {
-			continue
+// annotateEnums records the groups of every enum and the commands of the
+// package which accept these groups.
+func (p *Package) annotateEnums(reg *SpecRegistry) {
+	enumGroups := make(map[string][]string)
+	for _, g := range reg.Groups {
+		for _, e := range g.Enums {
+			enumGroups[e.Name] = append(enumGroups[e.Name], g.Name)
 		}
-		if pc.Version.Compare(ver) < 0 {
-			continue
+	}
+	groupCmds := make(map[string][]string)
+	for _, f := range p.Functions.Sort() {
+		for _, pa := range f.Parameters {
+			cmds := groupCmds[pa.Group]
+			if pa.Group != "" && (len(cmds) == 0 || cmds[len(cmds)-1] != f.Name) {
+				groupCmds[pa.Group] = append(cmds, f.Name)
+			}
 		}
-		fmt.Println(" package", pc.Api, pc.Version)
-		for _, en := range enumNames {
-			if _, ok := pc.Enums[en.Name]; ok {
-				delete(pc.Enums, en.Name)
+	}
+	for key, e := range p.Enums {
+		groups := enumGroups[key]
+		if e.Group != "" && !contains(groups, e.Group) {
+			groups = append(groups, e.Group)
+		}
+		sort.Strings(groups)
+		var cmds []string
+		for _, g := range groups {
+			for _, c := range groupCmds[g] {
+				if !contains(cmds, c) {
+					cmds = append(cmds, c)
+				}
 			}
 		}
+		sort.Strings(cmds)
+		e.Groups, e.Commands = groups, cmds
 	}
 }
 
-func addCommands(ps Packages, api string, ver Version, cmdNames []SpecCommandRef, functions Functions) {
-	//fmt.Println("Adding commands from version", api, ver , "to")
-	for _, pc := range ps {
-		if pc.Api != api {
-			continue
+func (p *Package) removeEnums(enumNames []SpecEnumRef) {
+	for _, en := range enumNames {
+		if _, ok := p.Enums[en.Name]; ok {
+			delete(p.Enums, en.Name)
 		}
-		if pc.Version.Compare(ver) < 0 {
-			continue
+	}
+}
+
+func (p *Package) addCommands(cmdNames []SpecCommandRef, functions Functions, feature string) {
+	for _, cn := range cmdNames {
+		fname := TrimGLCmdPrefix(cn.Name)
+		f, ok := functions[fname]
+		if !ok {
+			fmt.Println("add cmd: Cmd not found:", fname)
+		} else {
+			//fmt.Println("adding", cn)
+			fc := *f // packages annotate their own copy
+			p.Functions[fname] = &fc
+			if _, ok := p.CmdFeature[fname]; !ok {
+				p.CmdFeature[fname] = feature
+			}
 		}
-		//fmt.Println(" package", pc.Api, pc.Version)
-		for _, cn := range cmdNames {
-			fname := TrimGLCmdPrefix(cn.Name)
-			f, ok := functions[fname]
-			if !ok {
-				fmt.Println("add cmd: Cmd not found:", fname)
-			} else {
-				//fmt.Println("adding", cn)
-				pc.Functions[fname] = f
+	}
+}
+
+func (p *Package) removeCommands(cmdNames []SpecCommandRef) {
+	for _, cn := range cmdNames {
+		fname := TrimGLCmdPrefix(cn.Name)
+		if _, ok := p.Functions[fname]; !ok {
+			fmt.Println("Remove cmd: Cmd not found", fname)
+		} else {
+			delete(p.Functions, fname)
+			delete(p.CmdFeature, fname)
+		}
+	}
+}
+
+func (p *Package) addFeature(f SpecFeature, reg *SpecRegistry, functions Functions) {
+	fmt.Println("Adding", f.Name, "to package", p.Api, p.Version, p.Profile)
+	for _, r := range f.Requires {
+		if p.hasProfile(r.Profile) && p.hasApi(r.Api) {
+			p.addEnums(r.Enums, reg.Enums, f.Name)
+		}
+	}
+	for _, d := range f.Removes {
+		if p.hasProfile(d.Profile) && p.hasApi(d.Api) {
+			p.removeEnums(d.Enums)
+		}
+	}
+	for _, r := range f.Requires {
+		if p.hasProfile(r.Profile) && p.hasApi(r.Api) {
+			p.addCommands(r.Commands, functions, f.Name)
+		}
+	}
+	p.Features = append(p.Features, f.Name)
+	for _, d := range f.Removes {
+		if p.hasProfile(d.Profile) && p.hasApi(d.Api) {
+			p.removeCommands(d.Commands)
+		}
+	}
+}
+
+func (p *Package) addExtension(e SpecExtension, reg *SpecRegistry, functions Functions) error {
+	if !e.Supports(p.Api) {
+		return fmt.Errorf("extension %s is not supported by API '%s' (supported: %s)", e.Name, p.Api, e.Supported)
+	}
+	fmt.Println("Adding", e.Name, "to package", p.Api, p.Version, p.Profile)
+	for _, r := range e.Requires {
+		if p.hasProfile(r.Profile) && p.hasApi(r.Api) {
+			p.addEnums(r.Enums, reg.Enums, e.Name)
+			p.addCommands(r.Commands, functions, e.Name)
+		}
+	}
+	p.Features = append(p.Features, e.Name)
+	return nil
+}
+
+// restrict reduces the package to the given commands and enums plus all
+// enums of the groups the parameters of these commands refer to. A command
+// of an extension keeps everything the extension requires with it, as
+// extensions are usually used as a whole. Names may be given with or
+// without prefix (glEnable, Enable, GL_BLEND, BLEND). Unknown names are an
+// error if strict is set and ignored otherwise.
+func (p *Package) restrict(names []string, strict bool, groups map[string][]string, requires map[string][]SpecRequire, keepF Functions, keepE Enums) error {
+	enumNames := make(map[string]string, len(p.Enums))
+	for key, e := range p.Enums {
+		enumNames[e.Name] = key
+		enumNames[key] = key
+	}
+	var keep func(f *Function)
+	keep = func(f *Function) {
+		if _, ok := keepF[f.Name]; ok {
+			return
+		}
+		keepF[f.Name] = f
+		for _, pa := range f.Parameters {
+			for _, en := range groups[pa.Group] {
+				if e, ok := p.Enums[en]; ok {
+					keepE[en] = e
+				}
 			}
 		}
+		for _, r := range requires[p.CmdFeature[f.Name]] {
+			for _, en := range r.Enums {
+				if e, ok := p.Enums[en.Name]; ok {
+					keepE[en.Name] = e
+				}
+			}
+			for _, c := range r.Commands {
+				if rf, ok := p.Functions[TrimGLCmdPrefix(c.Name)]; ok {
+					keep(rf)
+				}
+			}
+		}
+	}
+	for _, n := range names {
+		if f, ok := p.Functions[TrimGLCmdPrefix(n)]; ok {
+			keep(f)
+		} else if key, ok := enumNames[n]; ok {
+			keepE[key] = p.Enums[key]
+		} else if strict {
+			return fmt.Errorf("included command or enum %s is not part of package %s %s", n, p.Api, p.Version)
+		}
 	}
+	return nil
 }
 
-func removeCommands(ps Packages, api string, ver Version, cmdNames []SpecCommandRef) {
-	//fmt.Println("Removing commands from version", api, ver, "to")
-	for _, pc := range ps {
-		if pc.Api != api {
+// filter applies the include and exclude lists of a package spec. If the
+// spec has an include list or scans Go code for uses of the package, only
+// the listed or used commands and enums are kept.
+func (p *Package) filter(spec PackageSpec, reg *SpecRegistry) error {
+	if len(spec.Include) > 0 || spec.Scan != "" {
+		groups := make(map[string][]string, len(reg.Groups))
+		for _, g := range reg.Groups {
+			for _, e := range g.Enums {
+				groups[g.Name] = append(groups[g.Name], e.Name)
+			}
+		}
+		requires := make(map[string][]SpecRequire, len(spec.Extensions))
+		for _, en := range spec.Extensions {
+			e, _ := reg.findExtension(en)
+			for _, r := range e.Requires {
+				if p.hasProfile(r.Profile) && p.hasApi(r.Api) {
+					requires[e.Name] = append(requires[e.Name], r)
+				}
+			}
+		}
+		keepF, keepE := make(Functions), make(Enums)
+		if err := p.restrict(spec.Include, true, groups, requires, keepF, keepE); err != nil {
+			return err
+		}
+		if spec.Scan != "" {
+			uses, err := ScanPackageUses(spec.Scan, spec.Import)
+			if err != nil {
+				return err
+			}
+			p.restrict(uses, false, groups, requires, keepF, keepE)
+		}
+		fmt.Printf("Restricting package %s %s to %d of %d commands and %d of %d enums\n", p.Api, p.Version, len(keepF), len(p.Functions), len(keepE), len(p.Enums))
+		p.Functions, p.Enums = keepF, keepE
+	}
+	for _, n := range spec.Exclude {
+		delete(p.Functions, TrimGLCmdPrefix(n))
+	}
+	return nil
+}
+
+func (r *SpecRegistry) findExtension(name string) (SpecExtension, bool) {
+	for _, e := range r.Extensions {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return SpecExtension{}, false
+}
+
+func (r *SpecRegistry) findFeature(api string, ver Version) (SpecFeature, bool) {
+	for _, f := range r.Features {
+		if f.Api != api {
 			continue
 		}
-		if pc.Version.Compare(ver) < 0 {
+		v, err := ParseVersion(f.Number)
+		if err == nil && v.Compare(ver) == 0 {
+			return f, true
+		}
+	}
+	return SpecFeature{}, false
+}
+
+// apis returns the sorted names of all APIs with features.
+func (r *SpecRegistry) apis() []string {
+	apis := make([]string, 0, 4)
+	for _, f := range r.Features {
+		if !contains(apis, f.Api) {
+			apis = append(apis, f.Api)
+		}
+	}
+	sort.Strings(apis)
+	return apis
+}
+
+// apiVersions returns the sorted versions of all features of api.
+func (r *SpecRegistry) apiVersions(api string) []Version {
+	versions := make([]Version, 0, 16)
+	for _, f := range r.Features {
+		if v, err := ParseVersion(f.Number); err == nil && f.Api == api {
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Compare(versions[j]) < 0 })
+	return versions
+}
+
+// profileVersions returns the first version of api that uses a profile
+// for every profile. The compatibility profile is implied by the core
+// profile, it is what remains without the removals.
+func (r *SpecRegistry) profileVersions(api string) map[string]Version {
+	since := make(map[string]Version)
+	use := func(profile string, v Version) {
+		if s, ok := since[profile]; profile != "" && (!ok || v.Compare(s) < 0) {
+			since[profile] = v
+		}
+	}
+	for _, f := range r.Features {
+		v, err := ParseVersion(f.Number)
+		if err != nil || f.Api != api {
 			continue
 		}
-		//fmt.Println(" package", pc.Api, pc.Version)
-		for _, cn := range cmdNames {
-			fname := TrimGLCmdPrefix(cn.Name)
-			if _, ok := pc.Functions[fname]; !ok {
-				fmt.Println("Remove cmd: Cmd not found", fname)
-			} else {
-				delete(pc.Functions, fname)
+		for _, rq := range f.Requires {
+			use(rq.Profile, v)
+		}
+		for _, rm := range f.Removes {
+			use(rm.Profile, v)
+		}
+	}
+	if v, ok := since["core"]; ok {
+		use("compatibility", v)
+	}
+	return since
+}
+
+// nearVersions returns the versions next to v.
+func nearVersions(v Version, all []Version) []string {
+	near := make([]string, 0, 2)
+	for i, a := range all {
+		if a.Compare(v) > 0 {
+			if i > 0 {
+				near = append(near, all[i-1].String())
+			}
+			return append(near, a.String())
+		}
+	}
+	if len(all) > 0 {
+		near = append(near, all[len(all)-1].String())
+	}
+	return near
+}
+
+func versionList(versions []Version) string {
+	s := make([]string, len(versions))
+	for i, v := range versions {
+		s[i] = v.String()
+	}
+	return strings.Join(s, ", ")
+}
+
+// resolveSpecs checks the API, version, profile and extensions of every
+// spec against the registry and expands "latest" and version ranges into
+// one spec per version.
+func (r *SpecRegistry) resolveSpecs(specs []PackageSpec) ([]PackageSpec, error) {
+	resolved := make([]PackageSpec, 0, len(specs))
+	apis := r.apis()
+	for _, spec := range specs {
+		if !contains(apis, spec.Api) {
+			return nil, fmt.Errorf("unknown API '%s'%s (APIs: %s)", spec.Api, didYouMean(suggest(spec.Api, apis)), strings.Join(apis, ", "))
+		}
+		all := r.apiVersions(spec.Api)
+		sel := spec.Select
+		if sel.Single() {
+			sel = VersionSelector{Min: spec.Version, Max: spec.Version}
+		}
+		if !sel.Latest {
+			for _, bound := range []Version{sel.Min, sel.Max} {
+				if _, ok := r.findFeature(spec.Api, bound); !ok {
+					return nil, fmt.Errorf("%s %s not found%s (versions: %s)", spec.Api, bound, didYouMean(nearVersions(bound, all)), versionList(all))
+				}
+			}
+		}
+		versions := sel.Select(all)
+		if len(versions) > 1 && (spec.Name != "" || spec.Path != "") {
+			return nil, fmt.Errorf("%s %s selects %d versions, name and path can only be used for a single version", spec.Api, sel, len(versions))
+		}
+		for _, v := range versions {
+			s := spec
+			s.Version, s.Select = v, VersionSelector{}
+			if err := r.checkProfile(s); err != nil {
+				return nil, err
+			}
+			if err := r.checkExtensions(s); err != nil {
+				return nil, err
 			}
+			resolved = append(resolved, s)
 		}
 	}
+	return resolved, nil
 }
 
-func ParseSpecFile(file string, fs Features) (Packages, error) {
-	pacs := make(Packages, 0)
+func (r *SpecRegistry) checkProfile(spec PackageSpec) error {
+	if spec.Profile == "" {
+		return nil
+	}
+	since := r.profileVersions(spec.Api)
+	v, ok := since[spec.Profile]
+	if !ok {
+		profiles := make([]string, 0, len(since))
+		for p := range since {
+			profiles = append(profiles, p)
+		}
+		sort.Strings(profiles)
+		if len(profiles) == 0 {
+			return fmt.Errorf("%s has no profiles, remove profile '%s'", spec.Api, spec.Profile)
+		}
+		return fmt.Errorf("profile '%s' is not defined for %s%s (profiles: %s)", spec.Profile, spec.Api, didYouMean(suggest(spec.Profile, profiles)), strings.Join(profiles, ", "))
+	}
+	if spec.Version.Compare(v) < 0 {
+		return fmt.Errorf("profile %s of %s requires version %s or later, not %s", spec.Profile, spec.Api, v, spec.Version)
+	}
+	return nil
+}
+
+func (r *SpecRegistry) checkExtensions(spec PackageSpec) error {
+	for _, en := range spec.Extensions {
+		e, ok := r.findExtension(en)
+		if !ok {
+			names := make([]string, len(r.Extensions))
+			for i, e := range r.Extensions {
+				names[i] = e.Name
+			}
+			return fmt.Errorf("extension %s not found%s", en, didYouMean(suggest(en, names)))
+		}
+		if !e.Supports(spec.Api) {
+			return fmt.Errorf("extension %s does not support %s (supported: %s)", en, spec.Api, strings.Replace(e.Supported, "|", ", ", -1))
+		}
+	}
+	return nil
+}
+
+func ParseSpecFile(file string, specs []PackageSpec) (Packages, error) {
 
 	reg, err := readSpecFile(file)
 	if err != nil {
 		return nil, err
 	}
+	if rev := reg.Revision(); rev != "" {
+		fmt.Printf("Using %s revision %s\n", filepath.Base(file), rev)
+	}
 
 	functions := commandsToFunctions(reg.Commands)
 	tds, err := reg.ParseTypedefs()
@@ -371,48 +760,64 @@ func ParseSpecFile(file string, fs Features) (Packages, error) {
 		return nil, err
 	}
 
-	for _, ft := range reg.Features {
-		fmt.Println("Feature:", ft.Name, ft.Api, ft.Number)
-		version, err := ParseVersion(ft.Number)
-		if err != nil {
-			return nil, err
-		}
-		if fs.HasFeature(ft.Api, version) {
-			fmt.Println("Adding", ft.Name, ft.Api, ft.Number)
-			p := &Package{Api: ft.Api, Name: ft.Api, Version: version, TypeDefs: tds, Enums: make(Enums), Functions: make(Functions)}
-			pacs = append(pacs, p)
-		}
+	specs, err = reg.resolveSpecs(specs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filepath.Base(file), err)
 	}
-
-	for _, f := range reg.Features {
-		version, err := ParseVersion(f.Number)
-		if err != nil {
-			return nil, err
+	pacs := make(Packages, 0, len(specs))
+	for _, spec := range specs {
+		p := &Package{
+			Api:        spec.Api,
+			Name:       spec.Name,
+			Version:    spec.Version,
+			Profile:    spec.Profile,
+			Path:       spec.Path,
+			Backend:    spec.Backend,
+			TypeDefs:   tds,
+			Spec:       spec,
+			SpecFile:   filepath.Base(file),
+			SpecHash:   reg.Hash,
+			Enums:      make(Enums),
+			Functions:  make(Functions),
+			CmdFeature: make(map[string]string),
 		}
-		for _, r := range f.Requires {
-			addEnums(pacs, f.Api, version, r.Enums, reg.Enums)
-		}
-		for _, d := range f.Removes {
-			removeEnums(pacs, f.Api, version, d.Enums)
+		for _, f := range reg.Features {
+			version, err := ParseVersion(f.Number)
+			if err != nil {
+				return nil, err
+			}
+			if f.Api == p.Api && version.Compare(p.Version) <= 0 {
+				p.addFeature(f, reg, functions)
+			}
 		}
-		for _, r := range f.Requires {
-			addCommands(pacs, f.Api, version, r.Commands, functions)
+		for _, en := range spec.Extensions {
+			e, ok := reg.findExtension(en)
+			if !ok {
+				return nil, fmt.Errorf("extension %s not found in %s", en, file)
+			}
+			if err := p.addExtension(e, reg, functions); err != nil {
+				return nil, err
+			}
 		}
-		for _, d := range f.Removes {
-			removeCommands(pacs, f.Api, version, d.Commands)
+		if err := p.filter(spec, reg); err != nil {
+			return nil, err
 		}
+		p.markDeprecated(reg)
+		p.annotateEnums(reg)
+		pacs = append(pacs, p)
 	}
 
 	return pacs, nil
 }
 
 type Feature struct {
-	Name string
-	Versions []Version
+	Name     string
+	Versions []VersionSelector
 }
 
 type Features []Feature
 
+// ParseFeatureList parses APIs and versions, e.g. "gl:2.1,3.3-4.6|gles2:latest".
 func ParseFeatureList(featureStr string) (Features, error) {
 	if len(featureStr) == 0 {
 		return nil, fmt.Errorf("feature string is empty")
@@ -424,10 +829,10 @@ func ParseFeatureList(featureStr string) (Features, error) {
 		if len(featver) != 2 {
 			return nil, fmt.Errorf("wrong format or version needed: '%s'", featureStr)
 		}
-		versions := make([]Version, 0, 8)
+		versions := make([]VersionSelector, 0, 8)
 		versionStrs := strings.Split(featver[1], ",")
 		for _, v := range versionStrs {
-			version, err := ParseVersion(v)
+			version, err := ParseVersionSelector(v)
 			if err != nil {
 				return nil, err
 			}
@@ -438,15 +843,18 @@ func ParseFeatureList(featureStr string) (Features, error) {
 	return features, nil
 }
 
-func (fs Features) HasFeature(name string, ver Version) bool {
+func (fs Features) PackageSpecs() []PackageSpec {
+	specs := make([]PackageSpec, 0, len(fs))
 	for _, f := range fs {
-		if f.Name == name {
-			for _, v := range f.Versions {
-				if v.Compare(ver) == 0 {
-					return true
-				}
+		for _, v := range f.Versions {
+			spec := PackageSpec{Api: f.Name, Backend: backendFuncPtr}
+			if v.Single() {
+				spec.Version = v.Min
+			} else {
+				spec.Select = v
 			}
+			specs = append(specs, spec)
 		}
 	}
-	return false
+	return specs
 }
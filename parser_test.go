@@ -0,0 +1,167 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+type testSignature struct {
+	In   string
+	Name string
+	Type Type
+	Ok   bool
+}
+
+var allTestsSignature = []testSignature{
+	{"void <name>glEnd</name>", "End", Type{Name: "void"}, true},
+	{"<ptype>GLenum</ptype> <name>mode</name>", "mode", Type{Name: "GLenum"}, true},
+	{"<ptype>GLint</ptype> *<name>data</name>", "data", Type{Name: "GLint", PointerLevel: 1}, true},
+	{"const <ptype>GLint</ptype> *<name>length</name>", "length", Type{Name: "GLint", IsConst: true, PointerLevel: 1}, true},
+	{"const void *<name>data</name>", "data", Type{Name: "void", IsConst: true, PointerLevel: 1}, true},
+	{"void *<name>glMapBuffer</name>", "MapBuffer", Type{Name: "void", PointerLevel: 1}, true},
+	{"void **<name>params</name>", "params", Type{Name: "void **"}, true}, // see Type.GoType
+	{"const <ptype>GLchar</ptype> *const*<name>string</name>", "string", Type{Name: "GLchar", IsConst: true, PointerLevel: 2}, true},
+	{"<ptype>GLchar</ptype> **<name>names</name>", "names", Type{Name: "GLchar", PointerLevel: 2}, true},
+	{"const <ptype>GLubyte</ptype> *<name>glGetString</name>", "GetString", Type{Name: "GLubyte", IsConst: true, PointerLevel: 1}, true},
+	{"<ptype>GLsync</ptype> <name>sync</name>", "sync", Type{Name: "GLsync"}, true},
+	{"struct _cl_context *<name>context</name>", "context", Type{Name: "struct _cl_context *"}, true},
+	{"int <name>x</name> extra", "", Type{}, false},
+	{"<ptype>GLint</ptype> <bad>x</bad>", "", Type{}, false},
+}
+
+func TestSignatureParse(t *testing.T) {
+	for i := range allTestsSignature {
+		te := &allTestsSignature[i]
+		name, typ, err := SpecSignature(te.In).Parse()
+		if (err == nil) != te.Ok {
+			t.Errorf("SpecSignature.Parse() failed: %s: %v", te.In, err)
+			continue
+		}
+		if te.Ok && (name != te.Name || typ != te.Type) {
+			t.Errorf("SpecSignature.Parse() failed: %s -> %s %v (%s %v)", te.In, te.Name, te.Type, name, typ)
+		}
+	}
+}
+
+type testSpecType struct {
+	In   SpecType
+	Name string
+	CDef string
+	Ok   bool
+}
+
+var allTestsSpecType = []testSpecType{
+	{SpecType{Inner: []byte("typedef unsigned int <name>GLenum</name>;")}, "GLenum", "typedef unsigned int GLenum;", true},
+	{SpecType{Name: "khrplatform", Inner: []byte("#include &lt;KHR/khrplatform.h&gt;")}, "khrplatform", "#include <KHR/khrplatform.h>", true},
+	{SpecType{Inner: []byte("typedef void (<apientry/> *<name>GLDEBUGPROC</name>)(GLenum source);")}, "GLDEBUGPROC", "typedef void (APIENTRY *GLDEBUGPROC)(GLenum source);", true},
+	{SpecType{Inner: []byte("typedef <type>int</type> <name>GLint</name>;")}, "", "", false},
+}
+
+func TestSpecTypeParse(t *testing.T) {
+	for i := range allTestsSpecType {
+		te := &allTestsSpecType[i]
+		td, err := te.In.Parse()
+		if (err == nil) != te.Ok {
+			t.Errorf("SpecType.Parse() failed: %s: %v", te.In.Inner, err)
+			continue
+		}
+		if te.Ok && (td.Name != te.Name || td.CDefinition != te.CDef) {
+			t.Errorf("SpecType.Parse() failed: %s -> %s '%s' (%s '%s')", te.In.Inner, te.Name, te.CDef, td.Name, td.CDefinition)
+		}
+	}
+}
+
+func TestParseSpecFile(t *testing.T) {
+	specs := []PackageSpec{
+		{Api: "gl", Version: Version{2, 1}},
+		{Api: "gl", Version: Version{3, 2}, Profile: "core"},
+		{Api: "gl", Version: Version{3, 2}, Profile: "compatibility"},
+	}
+	_, err := ParseSpecFile("testdata/gl.xml", specs)
+	if err == nil {
+		t.Fatalf("ParseSpecFile() failed: expected error for missing version 2.1")
+	}
+	specs[0].Version = Version{2, 0}
+	ps, err := ParseSpecFile("testdata/gl.xml", specs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		Functions, Enums int
+	}{{14, 12}, {14, 13}, {16, 15}}
+	for i, p := range ps {
+		if len(p.Functions) != expected[i].Functions || len(p.Enums) != expected[i].Enums {
+			t.Errorf("ParseSpecFile() failed: %s %s %s: %d commands, %d enums (expected %d, %d)", p.Api, p.Version, p.Profile,
+				len(p.Functions), len(p.Enums), expected[i].Functions, expected[i].Enums)
+		}
+	}
+	if _, ok := ps[1].Functions["Begin"]; ok {
+		t.Errorf("ParseSpecFile() failed: Begin not removed from core profile")
+	}
+	if _, ok := ps[2].Functions["Begin"]; !ok {
+		t.Errorf("ParseSpecFile() failed: Begin removed from compatibility profile")
+	}
+}
+
+type testResolveSpecs struct {
+	Features string
+	Profile  string
+	Ext      string
+	Out      string // resolved versions or expected error substring
+}
+
+var allTestsResolveSpecs = []testResolveSpecs{
+	{"gl:2.0", "", "", "gl 2.0"},
+	{"gl:latest|gles2:latest", "", "", "gl 3.2, gles2 2.0"},
+	{"gl:1.1-2.0", "", "", "gl 1.1, gl 1.5, gl 2.0"},
+	{"gl:1.0-latest", "", "", "Invalid version string"},
+	{"gl:2.0-1.1", "", "", "Invalid version range"},
+	{"gl:3.5", "", "", "gl 3.5 not found; did you mean 3.2? (versions: 1.0, 1.1, 1.5, 2.0, 3.2)"},
+	{"gl:1.2-2.0", "", "", "gl 1.2 not found; did you mean 1.1 or 1.5?"},
+	{"gls2:2.0", "", "", "unknown API 'gls2'; did you mean gles2? (APIs: gl, gles2)"},
+	{"gl:3.2", "core", "", "gl 3.2"},
+	{"gl:3.2", "compatibility", "", "gl 3.2"},
+	{"gl:3.2", "cor", "", "profile 'cor' is not defined for gl; did you mean core? (profiles: compatibility, core)"},
+	{"gl:2.0", "core", "", "profile core of gl requires version 3.2 or later, not 2.0"},
+	{"gles2:2.0", "core", "", "gles2 has no profiles"},
+	{"gl:2.0", "", "GL_NV_half_float", "gl 2.0"},
+	{"gl:2.0", "", "GL_ARB_debug_ouput", "extension GL_ARB_debug_ouput not found; did you mean GL_ARB_debug_output?"},
+	{"gles2:2.0", "", "GL_NV_half_float", "extension GL_NV_half_float does not support gles2 (supported: gl)"},
+}
+
+func TestResolveSpecs(t *testing.T) {
+	reg, err := readSpecFile("testdata/gl.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, te := range allTestsResolveSpecs {
+		out := ""
+		f, err := ParseFeatureList(te.Features)
+		if err == nil {
+			specs := f.PackageSpecs()
+			for i := range specs {
+				specs[i].Profile = te.Profile
+				if te.Ext != "" {
+					specs[i].Extensions = []string{te.Ext}
+				}
+			}
+			specs, err = reg.resolveSpecs(specs)
+			for i, s := range specs {
+				if i > 0 {
+					out += ", "
+				}
+				out += s.Api + " " + s.Version.String()
+			}
+		}
+		if err != nil {
+			out = err.Error()
+		}
+		if !strings.Contains(out, te.Out) {
+			t.Errorf("resolveSpecs(%s, %q, %q) = %s, expected %s", te.Features, te.Profile, te.Ext, out, te.Out)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// GoGL2 - automatically generated OpenGL binding: http://github.com/chsc/gogl2
+//
+// Generated by gogl2 0.2.0 from gl.xml (sha256 0cac87b7a760e02aa19d6334da4dfe3c91e93ffa4bcd31a8556f6c084ddf83c0).
+// Regenerate it with this configuration and generate -config:
+//
+//	glt = "github.com/chsc/gogl2/glt"
+//	[[package]]
+//	name = "gl"
+//	api = "gl"
+//	version = "2.0"
+//	extensions = ["GL_NV_half_float"]
+//	path = "gl20"
+//	backend = "funcptr"
+//
+// Copyright (c) 2010 Khronos Group.
+// This material may be distributed subject to the terms and conditions
+// set forth in the Open Publication License, v 1.0, 8 June 1999.
+// http://opencontent.org/openpub/.
+// 
+// Copyright (c) 1991-2006 Silicon Graphics, Inc.
+// This document is licensed under the SGI Free Software B License.
+// For details, see http://oss.sgi.com/projects/FreeB.
+//
+package gl
+
+const (
+	// ARRAY_BUFFER is defined by GL_VERSION_1_5.
+	ARRAY_BUFFER = 0x8892
+	// BLEND is defined by GL_VERSION_1_0.
+	// Groups: EnableCap.
+	// Used by [Enable], [IsEnabled].
+	BLEND = 0x0BE2
+	// COLOR_BUFFER_BIT is defined by GL_VERSION_1_0.
+	COLOR_BUFFER_BIT = 0x00004000
+	// FALSE is defined by GL_VERSION_1_0.
+	// Groups: Boolean.
+	FALSE = 0
+	// GL_2D is defined by GL_VERSION_1_0.
+	//
+	// Deprecated: removed from the core profile in OpenGL 3.2.
+	GL_2D = 0x2A10
+	// POINTS is defined by GL_VERSION_1_0.
+	// Groups: PrimitiveType.
+	// Used by [Begin], [DrawArrays].
+	POINTS = 0x0000
+	// QUADS is defined by GL_VERSION_1_0.
+	// Groups: PrimitiveType.
+	// Used by [Begin], [DrawArrays].
+	//
+	// Deprecated: removed from the core profile in OpenGL 3.2.
+	QUADS = 0x0007
+	// STATIC_DRAW is defined by GL_VERSION_1_5.
+	STATIC_DRAW = 0x88E4
+	// TEXTURE_2D is defined by GL_VERSION_1_0.
+	// Groups: EnableCap.
+	// Used by [Enable], [IsEnabled].
+	TEXTURE_2D = 0x0DE1
+	// TRIANGLES is defined by GL_VERSION_1_0.
+	// Groups: PrimitiveType.
+	// Used by [Begin], [DrawArrays].
+	TRIANGLES = 0x0004
+	// TRUE is defined by GL_VERSION_1_0.
+	// Groups: Boolean.
+	TRUE = 1
+	// VENDOR is defined by GL_VERSION_1_0.
+	VENDOR = 0x1F00
+)
+// package gl EOF
@@ -0,0 +1,109 @@
+// GoGL2 - automatically generated OpenGL binding: http://github.com/chsc/gogl2
+//
+// Generated by gogl2 0.2.0 from gl.xml (sha256 0cac87b7a760e02aa19d6334da4dfe3c91e93ffa4bcd31a8556f6c084ddf83c0).
+// Regenerate it with this configuration and generate -config:
+//
+//	glt = "github.com/chsc/gogl2/glt"
+//	[[package]]
+//	name = "gl"
+//	api = "gl"
+//	version = "2.0"
+//	extensions = ["GL_NV_half_float"]
+//	path = "gl20"
+//	backend = "funcptr"
+//
+// Copyright (c) 2010 Khronos Group.
+// This material may be distributed subject to the terms and conditions
+// set forth in the Open Publication License, v 1.0, 8 June 1999.
+// http://opencontent.org/openpub/.
+// 
+// Copyright (c) 1991-2006 Silicon Graphics, Inc.
+// This document is licensed under the SGI Free Software B License.
+// For details, see http://oss.sgi.com/projects/FreeB.
+//
+package gl
+
+// #include "gogl2.h"
+//
+// typedef void (APIENTRYP PGLBEGIN)(GLenum mode);
+// typedef void (APIENTRYP PGLCLEAR)(GLbitfield mask);
+// typedef void (APIENTRYP PGLENABLE)(GLenum cap);
+// typedef void (APIENTRYP PGLEND)();
+// typedef void (APIENTRYP PGLGETINTEGERV)(GLenum pname, GLint* data);
+// typedef const GLubyte* (APIENTRYP PGLGETSTRING)(GLenum name);
+// typedef GLboolean (APIENTRYP PGLISENABLED)(GLenum cap);
+// 
+// void goglBegin(PGLBEGIN glfptr, GLenum mode) {
+// 	(*glfptr)(mode);
+// }
+// void goglClear(PGLCLEAR glfptr, GLbitfield mask) {
+// 	(*glfptr)(mask);
+// }
+// void goglEnable(PGLENABLE glfptr, GLenum cap) {
+// 	(*glfptr)(cap);
+// }
+// void goglEnd(PGLEND glfptr) {
+// 	(*glfptr)();
+// }
+// void goglGetIntegerv(PGLGETINTEGERV glfptr, GLenum pname, GLint* data) {
+// 	(*glfptr)(pname, data);
+// }
+// const GLubyte* goglGetString(PGLGETSTRING glfptr, GLenum name) {
+// 	return (*glfptr)(name);
+// }
+// GLboolean goglIsEnabled(PGLISENABLED glfptr, GLenum cap) {
+// 	return (*glfptr)(cap);
+// }
+// 
+import "C"
+import "errors"
+import "github.com/chsc/gogl2/glt"
+import "unsafe"
+
+var _ glt.Enum
+var _ unsafe.Pointer
+
+var (
+	pglBegin C.PGLBEGIN
+	pglClear C.PGLCLEAR
+	pglEnable C.PGLENABLE
+	pglEnd C.PGLEND
+	pglGetIntegerv C.PGLGETINTEGERV
+	pglGetString C.PGLGETSTRING
+	pglIsEnabled C.PGLISENABLED
+)
+// Deprecated: removed from the core profile in OpenGL 3.2.
+func Begin(mode glt.Enum) {
+	C.goglBegin(pglBegin, (C.GLenum)(mode))
+}
+func Clear(mask glt.Bitfield) {
+	C.goglClear(pglClear, (C.GLbitfield)(mask))
+}
+func Enable(cap glt.Enum) {
+	C.goglEnable(pglEnable, (C.GLenum)(cap))
+}
+// Deprecated: removed from the core profile in OpenGL 3.2.
+func End() {
+	C.goglEnd(pglEnd)
+}
+func GetIntegerv(pname glt.Enum, data *int32) {
+	C.goglGetIntegerv(pglGetIntegerv, (C.GLenum)(pname), (*C.GLint)(data))
+}
+func GetString(name glt.Enum) *uint8 {
+	return (*byte)(C.goglGetString(pglGetString, (C.GLenum)(name)))
+}
+func IsEnabled(cap glt.Enum) bool {
+	return GLBoolean(C.goglIsEnabled(pglIsEnabled, (C.GLenum)(cap)))
+}
+
+func initVERSION10() error {
+	if pglBegin = (C.PGLBEGIN)(unsafe.Pointer(glt.GetProcAddress("glBegin"))); pglBegin == nil { return errors.New("glBegin") }
+	if pglClear = (C.PGLCLEAR)(unsafe.Pointer(glt.GetProcAddress("glClear"))); pglClear == nil { return errors.New("glClear") }
+	if pglEnable = (C.PGLENABLE)(unsafe.Pointer(glt.GetProcAddress("glEnable"))); pglEnable == nil { return errors.New("glEnable") }
+	if pglEnd = (C.PGLEND)(unsafe.Pointer(glt.GetProcAddress("glEnd"))); pglEnd == nil { return errors.New("glEnd") }
+	if pglGetIntegerv = (C.PGLGETINTEGERV)(unsafe.Pointer(glt.GetProcAddress("glGetIntegerv"))); pglGetIntegerv == nil { return errors.New("glGetIntegerv") }
+	if pglGetString = (C.PGLGETSTRING)(unsafe.Pointer(glt.GetProcAddress("glGetString"))); pglGetString == nil { return errors.New("glGetString") }
+	if pglIsEnabled = (C.PGLISENABLED)(unsafe.Pointer(glt.GetProcAddress("glIsEnabled"))); pglIsEnabled == nil { return errors.New("glIsEnabled") }
+	return nil
+}
+// package gl EOF
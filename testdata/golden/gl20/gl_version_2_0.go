@@ -0,0 +1,69 @@
+// GoGL2 - automatically generated OpenGL binding: http://github.com/chsc/gogl2
+//
+// Generated by gogl2 0.2.0 from gl.xml (sha256 0cac87b7a760e02aa19d6334da4dfe3c91e93ffa4bcd31a8556f6c084ddf83c0).
+// Regenerate it with this configuration and generate -config:
+//
+//	glt = "github.com/chsc/gogl2/glt"
+//	[[package]]
+//	name = "gl"
+//	api = "gl"
+//	version = "2.0"
+//	extensions = ["GL_NV_half_float"]
+//	path = "gl20"
+//	backend = "funcptr"
+//
+// Copyright (c) 2010 Khronos Group.
+// This material may be distributed subject to the terms and conditions
+// set forth in the Open Publication License, v 1.0, 8 June 1999.
+// http://opencontent.org/openpub/.
+// 
+// Copyright (c) 1991-2006 Silicon Graphics, Inc.
+// This document is licensed under the SGI Free Software B License.
+// For details, see http://oss.sgi.com/projects/FreeB.
+//
+package gl
+
+// #include "gogl2.h"
+//
+// typedef void (APIENTRYP PGLSHADERSOURCE)(GLuint shader, GLsizei count, const GLchar** string, const GLint* length);
+// typedef void (APIENTRYP PGLVERTEXATTRIBPOINTER)(GLuint index, GLint size, GLenum type, GLboolean normalized, GLsizei stride, const void* pointer);
+// 
+// void goglShaderSource(PGLSHADERSOURCE glfptr, GLuint shader, GLsizei count, const GLchar** string, const GLint* length) {
+// 	(*glfptr)(shader, count, string, length);
+// }
+// void goglVertexAttribPointer(PGLVERTEXATTRIBPOINTER glfptr, GLuint index, GLint size, GLenum type, GLboolean normalized, GLsizei stride, const void* pointer) {
+// 	(*glfptr)(index, size, type, normalized, stride, pointer);
+// }
+// void goglVertexAttribPointerOffset(PGLVERTEXATTRIBPOINTER glfptr, GLuint index, GLint size, GLenum type, GLboolean normalized, GLsizei stride, uintptr_t offset) {
+// 	(*glfptr)(index, size, type, normalized, stride, (const void*)offset);
+// }
+// 
+import "C"
+import "errors"
+import "github.com/chsc/gogl2/glt"
+import "unsafe"
+
+var _ glt.Enum
+var _ unsafe.Pointer
+
+var (
+	pglShaderSource C.PGLSHADERSOURCE
+	pglVertexAttribPointer C.PGLVERTEXATTRIBPOINTER
+)
+func ShaderSource(shader uint32, count int32, glstring **int8, length *int32) {
+	C.goglShaderSource(pglShaderSource, (C.GLuint)(shader), (C.GLsizei)(count), cgoChar2(glstring), (*C.GLint)(length))
+}
+func VertexAttribPointer(index uint32, size int32, gltype glt.Enum, normalized bool, stride int32, pointer glt.Pointer) {
+	C.goglVertexAttribPointer(pglVertexAttribPointer, (C.GLuint)(index), (C.GLint)(size), (C.GLenum)(gltype), GoBoolean(normalized), (C.GLsizei)(stride), unsafe.Pointer(pointer))
+}
+// VertexAttribPointerOffset is VertexAttribPointer with pointer given as an offset into the bound buffer object.
+func VertexAttribPointerOffset(index uint32, size int32, gltype glt.Enum, normalized bool, stride int32, offset uintptr) {
+	C.goglVertexAttribPointerOffset(pglVertexAttribPointer, (C.GLuint)(index), (C.GLint)(size), (C.GLenum)(gltype), GoBoolean(normalized), (C.GLsizei)(stride), (C.uintptr_t)(offset))
+}
+
+func initVERSION20() error {
+	if pglShaderSource = (C.PGLSHADERSOURCE)(unsafe.Pointer(glt.GetProcAddress("glShaderSource"))); pglShaderSource == nil { return errors.New("glShaderSource") }
+	if pglVertexAttribPointer = (C.PGLVERTEXATTRIBPOINTER)(unsafe.Pointer(glt.GetProcAddress("glVertexAttribPointer"))); pglVertexAttribPointer == nil { return errors.New("glVertexAttribPointer") }
+	return nil
+}
+// package gl EOF
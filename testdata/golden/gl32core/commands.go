@@ -0,0 +1,59 @@
+// GoGL2 - automatically generated OpenGL binding: http://github.com/chsc/gogl2
+//
+// Generated by gogl2 0.2.0 from gl.xml (sha256 0cac87b7a760e02aa19d6334da4dfe3c91e93ffa4bcd31a8556f6c084ddf83c0).
+// Regenerate it with this configuration and generate -config:
+//
+//	glt = "github.com/chsc/gogl2/glt"
+//	[[package]]
+//	name = "gl32"
+//	api = "gl"
+//	version = "3.2"
+//	profile = "core"
+//	extensions = ["GL_ARB_debug_output"]
+//	path = "gl32core"
+//	backend = "funcptr"
+//
+// Copyright (c) 2010 Khronos Group.
+// This material may be distributed subject to the terms and conditions
+// set forth in the Open Publication License, v 1.0, 8 June 1999.
+// http://opencontent.org/openpub/.
+// 
+// Copyright (c) 1991-2006 Silicon Graphics, Inc.
+// This document is licensed under the SGI Free Software B License.
+// For details, see http://oss.sgi.com/projects/FreeB.
+//
+package gl32
+
+// #cgo darwin  LDFLAGS: -framework OpenGL
+// #cgo linux   LDFLAGS: -lGL
+// #cgo windows LDFLAGS: -lopengl32
+//
+// #include "gogl2.h"
+//
+import "C"
+import "github.com/chsc/gogl2/glt"
+import "unsafe"
+
+func GLBoolean(b C.GLboolean) bool {
+	return b == 1
+}
+func GoBoolean(b bool) C.GLboolean {
+	if b { return 1 }
+	return 0
+}
+func cgoPtr1(p *glt.Pointer) *unsafe.Pointer {
+ return (*unsafe.Pointer)(unsafe.Pointer(p))
+}
+func cgoChar2(p **int8) **C.GLchar {
+ return (**C.GLchar)(unsafe.Pointer(p))
+}
+func Init() error {
+	if err := initVERSION10(); err != nil { return err }
+	if err := initVERSION11(); err != nil { return err }
+	if err := initVERSION15(); err != nil { return err }
+	if err := initVERSION20(); err != nil { return err }
+	if err := initVERSION32(); err != nil { return err }
+	if err := initARBDebugOutput(); err != nil { return err }
+	return nil
+}
+// package gl32 EOF
@@ -0,0 +1,54 @@
+// GoGL2 - automatically generated OpenGL binding: http://github.com/chsc/gogl2
+//
+// Generated by gogl2 0.2.0 from gl.xml (sha256 0cac87b7a760e02aa19d6334da4dfe3c91e93ffa4bcd31a8556f6c084ddf83c0).
+// Regenerate it with this configuration and generate -config:
+//
+//	glt = "github.com/chsc/gogl2/glt"
+//	[[package]]
+//	name = "gl32"
+//	api = "gl"
+//	version = "3.2"
+//	profile = "core"
+//	extensions = ["GL_ARB_debug_output"]
+//	path = "gl32core"
+//	backend = "funcptr"
+//
+// Copyright (c) 2010 Khronos Group.
+// This material may be distributed subject to the terms and conditions
+// set forth in the Open Publication License, v 1.0, 8 June 1999.
+// http://opencontent.org/openpub/.
+// 
+// Copyright (c) 1991-2006 Silicon Graphics, Inc.
+// This document is licensed under the SGI Free Software B License.
+// For details, see http://oss.sgi.com/projects/FreeB.
+//
+package gl32
+
+// #include "gogl2.h"
+//
+// typedef void (APIENTRYP PGLDEBUGMESSAGEINSERTARB)(GLenum source, GLenum type, GLuint id, GLenum severity, GLsizei length, const GLchar* buf);
+// 
+// void goglDebugMessageInsertARB(PGLDEBUGMESSAGEINSERTARB glfptr, GLenum source, GLenum type, GLuint id, GLenum severity, GLsizei length, const GLchar* buf) {
+// 	(*glfptr)(source, type, id, severity, length, buf);
+// }
+// 
+import "C"
+import "errors"
+import "github.com/chsc/gogl2/glt"
+import "unsafe"
+
+var _ glt.Enum
+var _ unsafe.Pointer
+
+var (
+	pglDebugMessageInsertARB C.PGLDEBUGMESSAGEINSERTARB
+)
+func DebugMessageInsertARB(source glt.Enum, gltype glt.Enum, id uint32, severity glt.Enum, length int32, buf *int8) {
+	C.goglDebugMessageInsertARB(pglDebugMessageInsertARB, (C.GLenum)(source), (C.GLenum)(gltype), (C.GLuint)(id), (C.GLenum)(severity), (C.GLsizei)(length), (*C.GLchar)(buf))
+}
+
+func initARBDebugOutput() error {
+	if pglDebugMessageInsertARB = (C.PGLDEBUGMESSAGEINSERTARB)(unsafe.Pointer(glt.GetProcAddress("glDebugMessageInsertARB"))); pglDebugMessageInsertARB == nil { return errors.New("glDebugMessageInsertARB") }
+	return nil
+}
+// package gl32 EOF
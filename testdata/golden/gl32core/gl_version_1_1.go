@@ -0,0 +1,54 @@
+// GoGL2 - automatically generated OpenGL binding: http://github.com/chsc/gogl2
+//
+// Generated by gogl2 0.2.0 from gl.xml (sha256 0cac87b7a760e02aa19d6334da4dfe3c91e93ffa4bcd31a8556f6c084ddf83c0).
+// Regenerate it with this configuration and generate -config:
+//
+//	glt = "github.com/chsc/gogl2/glt"
+//	[[package]]
+//	name = "gl32"
+//	api = "gl"
+//	version = "3.2"
+//	profile = "core"
+//	extensions = ["GL_ARB_debug_output"]
+//	path = "gl32core"
+//	backend = "funcptr"
+//
+// Copyright (c) 2010 Khronos Group.
+// This material may be distributed subject to the terms and conditions
+// set forth in the Open Publication License, v 1.0, 8 June 1999.
+// http://opencontent.org/openpub/.
+// 
+// Copyright (c) 1991-2006 Silicon Graphics, Inc.
+// This document is licensed under the SGI Free Software B License.
+// For details, see http://oss.sgi.com/projects/FreeB.
+//
+package gl32
+
+// #include "gogl2.h"
+//
+// typedef void (APIENTRYP PGLDRAWARRAYS)(GLenum mode, GLint first, GLsizei count);
+// 
+// void goglDrawArrays(PGLDRAWARRAYS glfptr, GLenum mode, GLint first, GLsizei count) {
+// 	(*glfptr)(mode, first, count);
+// }
+// 
+import "C"
+import "errors"
+import "github.com/chsc/gogl2/glt"
+import "unsafe"
+
+var _ glt.Enum
+var _ unsafe.Pointer
+
+var (
+	pglDrawArrays C.PGLDRAWARRAYS
+)
+func DrawArrays(mode glt.Enum, first int32, count int32) {
+	C.goglDrawArrays(pglDrawArrays, (C.GLenum)(mode), (C.GLint)(first), (C.GLsizei)(count))
+}
+
+func initVERSION11() error {
+	if pglDrawArrays = (C.PGLDRAWARRAYS)(unsafe.Pointer(glt.GetProcAddress("glDrawArrays"))); pglDrawArrays == nil { return errors.New("glDrawArrays") }
+	return nil
+}
+// package gl32 EOF
@@ -0,0 +1,81 @@
+// GoGL2 - automatically generated OpenGL binding: http://github.com/chsc/gogl2
+//
+// Generated by gogl2 0.2.0 from gl.xml (sha256 0cac87b7a760e02aa19d6334da4dfe3c91e93ffa4bcd31a8556f6c084ddf83c0).
+// Regenerate it with this configuration and generate -config:
+//
+//	glt = "github.com/chsc/gogl2/glt"
+//	[[package]]
+//	name = "gl32"
+//	api = "gl"
+//	version = "3.2"
+//	profile = "core"
+//	extensions = ["GL_ARB_debug_output"]
+//	path = "gl32core"
+//	backend = "funcptr"
+//
+// Copyright (c) 2010 Khronos Group.
+// This material may be distributed subject to the terms and conditions
+// set forth in the Open Publication License, v 1.0, 8 June 1999.
+// http://opencontent.org/openpub/.
+// 
+// Copyright (c) 1991-2006 Silicon Graphics, Inc.
+// This document is licensed under the SGI Free Software B License.
+// For details, see http://oss.sgi.com/projects/FreeB.
+//
+package gl32
+
+// #include "gogl2.h"
+//
+// typedef void (APIENTRYP PGLBUFFERDATA)(GLenum target, GLsizeiptr size, const void* data, GLenum usage);
+// typedef void (APIENTRYP PGLGENBUFFERS)(GLsizei n, GLuint* buffers);
+// typedef void (APIENTRYP PGLGETBUFFERPOINTERV)(GLenum target, GLenum pname, void ** params);
+// typedef void* (APIENTRYP PGLMAPBUFFER)(GLenum target, GLenum access);
+// 
+// void goglBufferData(PGLBUFFERDATA glfptr, GLenum target, GLsizeiptr size, const void* data, GLenum usage) {
+// 	(*glfptr)(target, size, data, usage);
+// }
+// void goglGenBuffers(PGLGENBUFFERS glfptr, GLsizei n, GLuint* buffers) {
+// 	(*glfptr)(n, buffers);
+// }
+// void goglGetBufferPointerv(PGLGETBUFFERPOINTERV glfptr, GLenum target, GLenum pname, void ** params) {
+// 	(*glfptr)(target, pname, params);
+// }
+// void* goglMapBuffer(PGLMAPBUFFER glfptr, GLenum target, GLenum access) {
+// 	return (*glfptr)(target, access);
+// }
+// 
+import "C"
+import "errors"
+import "github.com/chsc/gogl2/glt"
+import "unsafe"
+
+var _ glt.Enum
+var _ unsafe.Pointer
+
+var (
+	pglBufferData C.PGLBUFFERDATA
+	pglGenBuffers C.PGLGENBUFFERS
+	pglGetBufferPointerv C.PGLGETBUFFERPOINTERV
+	pglMapBuffer C.PGLMAPBUFFER
+)
+func BufferData(target glt.Enum, size int, data glt.Pointer, usage glt.Enum) {
+	C.goglBufferData(pglBufferData, (C.GLenum)(target), (C.GLsizeiptr)(size), unsafe.Pointer(data), (C.GLenum)(usage))
+}
+func GenBuffers(n int32, buffers *uint32) {
+	C.goglGenBuffers(pglGenBuffers, (C.GLsizei)(n), (*C.GLuint)(buffers))
+}
+func GetBufferPointerv(target glt.Enum, pname glt.Enum, params *glt.Pointer) {
+	C.goglGetBufferPointerv(pglGetBufferPointerv, (C.GLenum)(target), (C.GLenum)(pname), cgoPtr1(params))
+}
+func MapBuffer(target glt.Enum, access glt.Enum) glt.Pointer {
+	return glt.Pointer(C.goglMapBuffer(pglMapBuffer, (C.GLenum)(target), (C.GLenum)(access)))
+}
+
+func initVERSION15() error {
+	if pglBufferData = (C.PGLBUFFERDATA)(unsafe.Pointer(glt.GetProcAddress("glBufferData"))); pglBufferData == nil { return errors.New("glBufferData") }
+	if pglGenBuffers = (C.PGLGENBUFFERS)(unsafe.Pointer(glt.GetProcAddress("glGenBuffers"))); pglGenBuffers == nil { return errors.New("glGenBuffers") }
+	if pglGetBufferPointerv = (C.PGLGETBUFFERPOINTERV)(unsafe.Pointer(glt.GetProcAddress("glGetBufferPointerv"))); pglGetBufferPointerv == nil { return errors.New("glGetBufferPointerv") }
+	if pglMapBuffer = (C.PGLMAPBUFFER)(unsafe.Pointer(glt.GetProcAddress("glMapBuffer"))); pglMapBuffer == nil { return errors.New("glMapBuffer") }
+	return nil
+}
+// package gl32 EOF
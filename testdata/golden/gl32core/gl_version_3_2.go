@@ -0,0 +1,63 @@
+// GoGL2 - automatically generated OpenGL binding: http://github.com/chsc/gogl2
+//
+// Generated by gogl2 0.2.0 from gl.xml (sha256 0cac87b7a760e02aa19d6334da4dfe3c91e93ffa4bcd31a8556f6c084ddf83c0).
+// Regenerate it with this configuration and generate -config:
+//
+//	glt = "github.com/chsc/gogl2/glt"
+//	[[package]]
+//	name = "gl32"
+//	api = "gl"
+//	version = "3.2"
+//	profile = "core"
+//	extensions = ["GL_ARB_debug_output"]
+//	path = "gl32core"
+//	backend = "funcptr"
+//
+// Copyright (c) 2010 Khronos Group.
+// This material may be distributed subject to the terms and conditions
+// set forth in the Open Publication License, v 1.0, 8 June 1999.
+// http://opencontent.org/openpub/.
+// 
+// Copyright (c) 1991-2006 Silicon Graphics, Inc.
+// This document is licensed under the SGI Free Software B License.
+// For details, see http://oss.sgi.com/projects/FreeB.
+//
+package gl32
+
+// #include "gogl2.h"
+//
+// typedef GLsync (APIENTRYP PGLFENCESYNC)(GLenum condition, GLbitfield flags);
+// typedef void (APIENTRYP PGLGETSYNCIV)(GLsync sync, GLenum pname, GLsizei bufSize, GLsizei* length, GLint* values);
+// 
+// GLsync goglFenceSync(PGLFENCESYNC glfptr, GLenum condition, GLbitfield flags) {
+// 	return (*glfptr)(condition, flags);
+// }
+// void goglGetSynciv(PGLGETSYNCIV glfptr, GLsync sync, GLenum pname, GLsizei bufSize, GLsizei* length, GLint* values) {
+// 	(*glfptr)(sync, pname, bufSize, length, values);
+// }
+// 
+import "C"
+import "errors"
+import "github.com/chsc/gogl2/glt"
+import "unsafe"
+
+var _ glt.Enum
+var _ unsafe.Pointer
+
+var (
+	pglFenceSync C.PGLFENCESYNC
+	pglGetSynciv C.PGLGETSYNCIV
+)
+func FenceSync(condition glt.Enum, flags glt.Bitfield) glt.Pointer {
+	return glt.Pointer(C.goglFenceSync(pglFenceSync, (C.GLenum)(condition), (C.GLbitfield)(flags)))
+}
+func GetSynciv(sync glt.Pointer, pname glt.Enum, bufSize int32, length *int32, values *int32) {
+	C.goglGetSynciv(pglGetSynciv, (C.GLsync)(sync), (C.GLenum)(pname), (C.GLsizei)(bufSize), (*C.GLsizei)(length), (*C.GLint)(values))
+}
+
+func initVERSION32() error {
+	if pglFenceSync = (C.PGLFENCESYNC)(unsafe.Pointer(glt.GetProcAddress("glFenceSync"))); pglFenceSync == nil { return errors.New("glFenceSync") }
+	if pglGetSynciv = (C.PGLGETSYNCIV)(unsafe.Pointer(glt.GetProcAddress("glGetSynciv"))); pglGetSynciv == nil { return errors.New("glGetSynciv") }
+	return nil
+}
+// package gl32 EOF
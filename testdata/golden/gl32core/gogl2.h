@@ -0,0 +1,48 @@
+/* GoGL2 - automatically generated OpenGL binding: http://github.com/chsc/gogl2
+ * Generated by gogl2 0.2.0 from gl.xml (sha256 0cac87b7a760e02aa19d6334da4dfe3c91e93ffa4bcd31a8556f6c084ddf83c0).
+ * Regenerate it with this configuration and generate -config:
+ *
+ *	glt = "github.com/chsc/gogl2/glt"
+ *	[[package]]
+ *	name = "gl32"
+ *	api = "gl"
+ *	version = "3.2"
+ *	profile = "core"
+ *	extensions = ["GL_ARB_debug_output"]
+ *	path = "gl32core"
+ *	backend = "funcptr"
+ */
+
+#ifndef GL32_GOGL2_H
+#define GL32_GOGL2_H
+
+#include <stdint.h>
+
+#ifndef APIENTRY
+#define APIENTRY
+#endif
+#ifndef APIENTRYP
+#define APIENTRYP APIENTRY *
+#endif
+#ifndef GLAPI
+#define GLAPI extern
+#endif
+
+typedef unsigned int GLenum;
+typedef unsigned char GLboolean;
+typedef unsigned int GLbitfield;
+typedef void GLvoid;
+typedef int GLint;
+typedef unsigned int GLuint;
+typedef int GLsizei;
+typedef float GLfloat;
+typedef unsigned char GLubyte;
+typedef char GLchar;
+typedef long GLsizeiptr;
+typedef long GLintptr;
+typedef unsigned short GLhalfNV;
+/* Sync object handle */
+typedef struct __GLsync *GLsync;
+typedef void (APIENTRY *GLDEBUGPROC)(GLenum source,GLenum type,GLuint id,GLenum severity,GLsizei length,const GLchar *message,const void *userParam);
+
+#endif
@@ -0,0 +1,41 @@
+// GoGL2 - automatically generated OpenGL binding: http://github.com/chsc/gogl2
+//
+// Generated by gogl2 0.2.0 from gl.xml (sha256 0cac87b7a760e02aa19d6334da4dfe3c91e93ffa4bcd31a8556f6c084ddf83c0).
+// Regenerate it with this configuration and generate -config:
+//
+//	glt = "github.com/chsc/gogl2/glt"
+//	[[package]]
+//	name = "gles2"
+//	api = "gles2"
+//	version = "2.0"
+//	path = "gles20"
+//	backend = "static"
+//
+// Copyright (c) 2010 Khronos Group.
+// This material may be distributed subject to the terms and conditions
+// set forth in the Open Publication License, v 1.0, 8 June 1999.
+// http://opencontent.org/openpub/.
+// 
+// Copyright (c) 1991-2006 Silicon Graphics, Inc.
+// This document is licensed under the SGI Free Software B License.
+// For details, see http://oss.sgi.com/projects/FreeB.
+//
+package gles2
+
+const (
+	// BLEND is defined by GL_ES_VERSION_2_0.
+	// Groups: EnableCap.
+	// Used by [Enable].
+	BLEND = 0x0BE2
+	// FALSE is defined by GL_ES_VERSION_2_0.
+	// Groups: Boolean.
+	FALSE = 0
+	// TRIANGLES is defined by GL_ES_VERSION_2_0.
+	// Groups: PrimitiveType.
+	// Used by [DrawArrays].
+	TRIANGLES = 0x0004
+	// TRUE is defined by GL_ES_VERSION_2_0.
+	// Groups: Boolean.
+	TRUE = 1
+)
+// package gles2 EOF
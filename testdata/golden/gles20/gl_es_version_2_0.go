@@ -0,0 +1,55 @@
+// GoGL2 - automatically generated OpenGL binding: http://github.com/chsc/gogl2
+//
+// Generated by gogl2 0.2.0 from gl.xml (sha256 0cac87b7a760e02aa19d6334da4dfe3c91e93ffa4bcd31a8556f6c084ddf83c0).
+// Regenerate it with this configuration and generate -config:
+//
+//	glt = "github.com/chsc/gogl2/glt"
+//	[[package]]
+//	name = "gles2"
+//	api = "gles2"
+//	version = "2.0"
+//	path = "gles20"
+//	backend = "static"
+//
+// Copyright (c) 2010 Khronos Group.
+// This material may be distributed subject to the terms and conditions
+// set forth in the Open Publication License, v 1.0, 8 June 1999.
+// http://opencontent.org/openpub/.
+// 
+// Copyright (c) 1991-2006 Silicon Graphics, Inc.
+// This document is licensed under the SGI Free Software B License.
+// For details, see http://oss.sgi.com/projects/FreeB.
+//
+package gles2
+
+// #include "gogl2.h"
+//
+// GLAPI void APIENTRY glDrawArrays(GLenum mode, GLint first, GLsizei count);
+// GLAPI void APIENTRY glEnable(GLenum cap);
+// GLAPI void APIENTRY glVertexAttribPointer(GLuint index, GLint size, GLenum type, GLboolean normalized, GLsizei stride, const void* pointer);
+// void goglVertexAttribPointerOffset(GLuint index, GLint size, GLenum type, GLboolean normalized, GLsizei stride, uintptr_t offset) {
+// 	glVertexAttribPointer(index, size, type, normalized, stride, (const void*)offset);
+// }
+// 
+import "C"
+import "github.com/chsc/gogl2/glt"
+import "unsafe"
+
+var _ glt.Enum
+var _ unsafe.Pointer
+
+func DrawArrays(mode glt.Enum, first int32, count int32) {
+	C.glDrawArrays((C.GLenum)(mode), (C.GLint)(first), (C.GLsizei)(count))
+}
+func Enable(cap glt.Enum) {
+	C.glEnable((C.GLenum)(cap))
+}
+func VertexAttribPointer(index uint32, size int32, gltype glt.Enum, normalized bool, stride int32, pointer glt.Pointer) {
+	C.glVertexAttribPointer((C.GLuint)(index), (C.GLint)(size), (C.GLenum)(gltype), GoBoolean(normalized), (C.GLsizei)(stride), unsafe.Pointer(pointer))
+}
+// VertexAttribPointerOffset is VertexAttribPointer with pointer given as an offset into the bound buffer object.
+func VertexAttribPointerOffset(index uint32, size int32, gltype glt.Enum, normalized bool, stride int32, offset uintptr) {
+	C.goglVertexAttribPointerOffset((C.GLuint)(index), (C.GLint)(size), (C.GLenum)(gltype), GoBoolean(normalized), (C.GLsizei)(stride), (C.uintptr_t)(offset))
+}
+
+// package gles2 EOF
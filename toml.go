@@ -0,0 +1,240 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A minimal reader for the subset of TOML used by gogl2 configuration
+// files: comments, key/value pairs, [tables], [[arrays of tables]] and
+// values of type string, integer, boolean and (possibly multi-line)
+// arrays of those.
+
+type tomlValue struct {
+	Line  int
+	Value interface{} // string, int64, bool, []interface{}, tomlTable or []*tomlValue of tables
+}
+
+type tomlTable map[string]*tomlValue
+
+type tomlError struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e *tomlError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+func parseToml(file string, r io.Reader) (tomlTable, error) {
+	root := make(tomlTable)
+	cur := root
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	errorf := func(line int, format string, args ...interface{}) error {
+		return &tomlError{File: file, Line: line, Msg: fmt.Sprintf(format, args...)}
+	}
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(stripTomlComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[[") {
+			if !strings.HasSuffix(line, "]]") {
+				return nil, errorf(lineNo, "malformed table header: %s", line)
+			}
+			name := strings.TrimSpace(line[2 : len(line)-2])
+			if !isTomlKey(name) {
+				return nil, errorf(lineNo, "invalid table name: '%s'", name)
+			}
+			v, ok := root[name]
+			if !ok {
+				v = &tomlValue{Line: lineNo, Value: []*tomlValue{}}
+				root[name] = v
+			}
+			tables, ok := v.Value.([]*tomlValue)
+			if !ok {
+				return nil, errorf(lineNo, "'%s' is already defined in line %d", name, v.Line)
+			}
+			cur = make(tomlTable)
+			v.Value = append(tables, &tomlValue{Line: lineNo, Value: cur})
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, errorf(lineNo, "malformed table header: %s", line)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if !isTomlKey(name) {
+				return nil, errorf(lineNo, "invalid table name: '%s'", name)
+			}
+			if v, ok := root[name]; ok {
+				return nil, errorf(lineNo, "'%s' is already defined in line %d", name, v.Line)
+			}
+			cur = make(tomlTable)
+			root[name] = &tomlValue{Line: lineNo, Value: cur}
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, errorf(lineNo, "expected 'key = value', got: %s", line)
+		}
+		key := strings.TrimSpace(kv[0])
+		if !isTomlKey(key) {
+			return nil, errorf(lineNo, "invalid key: '%s'", key)
+		}
+		if v, ok := cur[key]; ok {
+			return nil, errorf(lineNo, "'%s' is already defined in line %d", key, v.Line)
+		}
+		start := lineNo
+		raw := strings.TrimSpace(kv[1])
+		// arrays may span several lines
+		for strings.HasPrefix(raw, "[") && !tomlBalanced(raw) {
+			if !scanner.Scan() {
+				return nil, errorf(start, "unterminated array for key '%s'", key)
+			}
+			lineNo++
+			raw += " " + strings.TrimSpace(stripTomlComment(scanner.Text()))
+		}
+		val, rest, err := parseTomlValue(raw)
+		if err != nil {
+			return nil, errorf(start, "key '%s': %s", key, err)
+		}
+		if strings.TrimSpace(rest) != "" {
+			return nil, errorf(start, "key '%s': unexpected trailing characters: %s", key, rest)
+		}
+		cur[key] = &tomlValue{Line: start, Value: val}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+func isTomlKey(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// stripTomlComment removes a trailing comment that is not part of a string.
+func stripTomlComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return s[:i]
+		}
+	}
+	return s
+}
+
+func tomlBalanced(s string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		}
+	}
+	return depth == 0
+}
+
+func parseTomlValue(s string) (interface{}, string, error) {
+	s = strings.TrimLeft(s, " \t")
+	if s == "" {
+		return nil, s, fmt.Errorf("missing value")
+	}
+	switch c := s[0]; {
+	case c == '"':
+		for i := 1; i < len(s); i++ {
+			if s[i] == '\\' {
+				i++
+			} else if s[i] == '"' {
+				str, err := strconv.Unquote(s[:i+1])
+				if err != nil {
+					return nil, s, fmt.Errorf("invalid string %s", s[:i+1])
+				}
+				return str, s[i+1:], nil
+			}
+		}
+		return nil, s, fmt.Errorf("unterminated string")
+	case c == '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return nil, s, fmt.Errorf("unterminated string")
+		}
+		return s[1 : end+1], s[end+2:], nil
+	case c == '[':
+		arr := make([]interface{}, 0, 4)
+		rest := strings.TrimLeft(s[1:], " \t")
+		for {
+			if strings.HasPrefix(rest, "]") {
+				return arr, rest[1:], nil
+			}
+			v, r, err := parseTomlValue(rest)
+			if err != nil {
+				return nil, s, err
+			}
+			arr = append(arr, v)
+			rest = strings.TrimLeft(r, " \t")
+			if strings.HasPrefix(rest, ",") {
+				rest = strings.TrimLeft(rest[1:], " \t")
+			} else if !strings.HasPrefix(rest, "]") {
+				return nil, s, fmt.Errorf("expected ',' or ']' in array")
+			}
+		}
+	}
+	end := strings.IndexAny(s, " \t,]")
+	if end < 0 {
+		end = len(s)
+	}
+	word := s[:end]
+	switch word {
+	case "true":
+		return true, s[end:], nil
+	case "false":
+		return false, s[end:], nil
+	}
+	n, err := strconv.ParseInt(strings.Replace(word, "_", "", -1), 0, 64)
+	if err != nil {
+		return nil, s, fmt.Errorf("invalid value: %s", word)
+	}
+	return n, s[end:], nil
+}
@@ -109,13 +109,14 @@ func (t Type) GoType() string {
 	case "GLushort":
 		return t.ptrStr() + "uint16"
 	case "GLhandleARB":
-		return t.ptrStr() + "glt.Pointer"
+		// an unsigned int, but a pointer on darwin
+		return t.ptrStr() + "uintptr"
 	case "GLhalfNV":
 		return t.ptrStr() + "uint16"
 	case "GLeglImageOES":
 		return t.ptrStr() + "glt.Pointer"
 	case "GLvdpauSurfaceARB":
-		return t.ptrStr() + "glt.Pointer"
+		return t.ptrStr() + "int"
 	case "GLsync":
 		return t.ptrStr() + "glt.Pointer"
 	case "void **":
@@ -186,6 +187,18 @@ func (t Type) GoConversion() string {
 		if t.PointerLevel == 0 {
 			return "int"
 		}
+	case "GLsync", "GLeglImageOES":
+		if t.PointerLevel == 0 {
+			return "glt.Pointer"
+		}
+	case "GLhandleARB":
+		if t.PointerLevel == 0 {
+			return "uintptr"
+		}
+	case "GLvdpauSurfaceARB":
+		if t.PointerLevel == 0 {
+			return "int"
+		}
 	}
 	return fmt.Sprintf("<unknown type:%sC.%s>", t.ptrStr(), t.Name)
 }
@@ -0,0 +1,39 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package main
+
+import (
+	"testing"
+)
+
+type typeTest struct {
+	In                      Type
+	GoType, GoConv, CgoConv string
+}
+
+var typeTests = []typeTest{
+	{Type{false, 1, "void"}, "glt.Pointer", "glt.Pointer", "unsafe.Pointer"},
+	{Type{true, 1, "void"}, "glt.Pointer", "glt.Pointer", "unsafe.Pointer"},
+	{Type{false, 0, "GLsync"}, "glt.Pointer", "glt.Pointer", "(C.GLsync)"},
+	{Type{false, 0, "GLeglImageOES"}, "glt.Pointer", "glt.Pointer", "(C.GLeglImageOES)"},
+	{Type{false, 0, "GLhandleARB"}, "uintptr", "uintptr", "(C.GLhandleARB)"},
+	{Type{false, 0, "GLvdpauSurfaceARB"}, "int", "int", "(C.GLvdpauSurfaceARB)"},
+	{Type{false, 0, "GLsizeiptr"}, "int", "int", "(C.GLsizeiptr)"},
+}
+
+func TestTypeConversions(t *testing.T) {
+	for i := range typeTests {
+		test := &typeTests[i]
+		if s := test.In.GoType(); s != test.GoType {
+			t.Errorf("%v: GoType() = %s, want %s", test.In, s, test.GoType)
+		}
+		if s := test.In.GoConversion(); s != test.GoConv {
+			t.Errorf("%v: GoConversion() = %s, want %s", test.In, s, test.GoConv)
+		}
+		if s := test.In.CgoConversion(); s != test.CgoConv {
+			t.Errorf("%v: CgoConversion() = %s, want %s", test.In, s, test.CgoConv)
+		}
+	}
+}
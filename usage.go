@@ -0,0 +1,148 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func skipScanDir(name string) bool {
+	return name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+// scanFileUses adds all names selected from importPath in f to uses.
+// It reports whether the file imports the package at all.
+func scanFileUses(fset *token.FileSet, f *ast.File, importPath string, uses map[string]bool) (bool, error) {
+	local := ""
+	for _, is := range f.Imports {
+		p, err := strconv.Unquote(is.Path.Value)
+		if err != nil || p != importPath {
+			continue
+		}
+		local = path.Base(importPath)
+		if is.Name != nil {
+			local = is.Name.Name
+		}
+	}
+	switch local {
+	case "":
+		return false, nil
+	case "_":
+		return true, nil
+	case ".":
+		return true, fmt.Errorf("%s: dot import of %s is not supported", fset.Position(f.Pos()), importPath)
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		if se, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := se.X.(*ast.Ident); ok && id.Name == local && id.Obj == nil {
+				uses[se.Sel.Name] = true
+			}
+		}
+		return true
+	})
+	return true, nil
+}
+
+// ScanPackageUses returns the sorted names of all identifiers that the Go
+// files below dir use from the package with the given import path. The
+// package is expected to be named after the last element of its path
+// unless it is imported with an explicit name.
+func ScanPackageUses(dir, importPath string) ([]string, error) {
+	fset := token.NewFileSet()
+	uses := make(map[string]bool)
+	importers := 0
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			if p != dir && skipScanDir(fi.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(p, ".go") {
+			return nil
+		}
+		f, err := parser.ParseFile(fset, p, nil, 0)
+		if err != nil {
+			return err
+		}
+		imports, err := scanFileUses(fset, f, importPath, uses)
+		if imports {
+			importers++
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if importers == 0 {
+		return nil, fmt.Errorf("no Go file below %s imports %s", dir, importPath)
+	}
+	names := make([]string, 0, len(uses))
+	for n := range uses {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	fmt.Printf("Found %d identifiers of %s in %d files below %s\n", len(names), importPath, importers, dir)
+	return names, nil
+}
+
+// ScanUndeclared returns the name of the Go package in dir and the sorted
+// identifiers of the form prefix + upper case letter or digit, e.g.
+// glTEXTURE_2D, that its files, including tests, use but do not declare.
+// The file skip, if any, is ignored, so that a file declaring these
+// identifiers can be regenerated.
+func ScanUndeclared(dir, prefix, skip string) (string, []string, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool { return fi.Name() != skip }, 0)
+	if err != nil {
+		return "", nil, err
+	}
+	name := ""
+	declared := make(map[string]bool)
+	used := make(map[string]bool)
+	for pn, pkg := range pkgs {
+		if !strings.HasSuffix(pn, "_test") {
+			if name != "" {
+				return "", nil, fmt.Errorf("%s holds the packages %s and %s", dir, name, pn)
+			}
+			name = pn
+		}
+		for _, f := range pkg.Files {
+			for n := range f.Scope.Objects {
+				declared[n] = true
+			}
+			for _, id := range f.Unresolved {
+				if len(id.Name) > len(prefix) && strings.HasPrefix(id.Name, prefix) {
+					if c := id.Name[len(prefix)]; c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+						used[id.Name] = true
+					}
+				}
+			}
+		}
+	}
+	if name == "" {
+		return "", nil, fmt.Errorf("no Go package in %s", dir)
+	}
+	names := make([]string, 0, len(used))
+	for n := range used {
+		if !declared[n] {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return name, names, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var usageTestFiles = map[string]string{
+	"main.go": `package main
+import "ourco.com/render/gl"
+func main() {
+	gl.Init()
+	gl.Enable(gl.BLEND)
+}`,
+	"sub/draw.go": `package sub
+import ogl "ourco.com/render/gl"
+func draw(gl int) {
+	ogl.DrawArrays(ogl.TRIANGLES, 0, 3)
+	_ = gl
+}`,
+	"vendor/x/x.go": `package x
+import "ourco.com/render/gl"
+func x() { gl.Begin(gl.QUADS) }`,
+	"other/other.go": `package other
+import "other.com/gl"
+func y() { gl.Clear(0) }`,
+}
+
+func TestScanPackageUses(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogl2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, src := range usageTestFiles {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(file), 0755)
+		if err := ioutil.WriteFile(file, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	uses, err := ScanPackageUses(dir, "ourco.com/render/gl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"BLEND", "DrawArrays", "Enable", "Init", "TRIANGLES"}
+	if !reflect.DeepEqual(uses, expected) {
+		t.Errorf("ScanPackageUses() failed: %v != %v", uses, expected)
+	}
+	if _, err := ScanPackageUses(dir, "ourco.com/none"); err == nil {
+		t.Errorf("ScanPackageUses() failed: expected error for unused package")
+	}
+}
+
+// TestScanExtensionRequires checks that a command of an extension which is
+// only used by scanned code keeps the enums the extension requires.
+func TestScanExtensionRequires(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogl2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := `package main
+import "ourco.com/render/gl"
+func main() { gl.DebugMessageInsertARB(0, 0, 0, 0, 0, nil) }`
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	specs := []PackageSpec{{Api: "gl", Version: Version{3, 2}, Profile: "core", Extensions: []string{"GL_ARB_debug_output"},
+		Scan: dir, Import: "ourco.com/render/gl", Backend: backendFuncPtr, Path: "gl32core"}}
+	ps, err := ParseSpecFile("testdata/gl.xml", specs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ps[0].Functions["DebugMessageInsertARB"]; !ok || len(ps[0].Functions) != 1 {
+		t.Errorf("restricted package has commands %v, expected DebugMessageInsertARB", ps[0].Functions)
+	}
+	if _, ok := ps[0].Enums["GL_DEBUG_OUTPUT_SYNCHRONOUS_ARB"]; !ok {
+		t.Errorf("restricted package lacks GL_DEBUG_OUTPUT_SYNCHRONOUS_ARB required by GL_ARB_debug_output")
+	}
+}
@@ -6,6 +6,7 @@ package main
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"unicode"
@@ -107,3 +108,94 @@ func CamelCase(n string) string {
 		},
 		n)
 }
+
+// GOOS and GOARCH values which must not end a generated file name,
+// otherwise the go tool treats them as implicit build constraints.
+var buildSuffixes = []string{
+	"aix", "android", "darwin", "dragonfly", "freebsd", "hurd", "illumos", "ios", "js", "linux",
+	"nacl", "netbsd", "openbsd", "plan9", "solaris", "wasip1", "windows", "zos",
+	"386", "amd64", "arm", "arm64", "loong64", "mips", "mips64", "mips64le", "mipsle",
+	"ppc64", "ppc64le", "riscv64", "s390x", "sparc64", "wasm", "test",
+}
+
+// Returns the name of the file holding the commands of a feature. e.g.: GL_VERSION_1_0 -> gl_version_1_0.go
+func featureFileName(feature string) string {
+	n := strings.ToLower(feature)
+	last := n[strings.LastIndex(n, "_")+1:]
+	for _, s := range buildSuffixes {
+		if last == s {
+			n += "_cmds"
+			break
+		}
+	}
+	return n + ".go"
+}
+
+// Returns the name of the Go function initializing the commands of a feature. e.g.: GL_ARB_debug_output -> initARBDebugOutput
+func featureInitName(feature string) string {
+	return "init" + CamelCase(TrimGLEnumPrefix(feature))
+}
+
+// levenshtein returns the edit distance of a and b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// suggest returns up to three of the candidates closest to name. Case is
+// ignored.
+func suggest(name string, candidates []string) []string {
+	maxDist := len(name) / 3
+	if maxDist < 2 {
+		maxDist = 2
+	}
+	type match struct {
+		name string
+		dist int
+	}
+	matches := make([]match, 0, 4)
+	for _, c := range candidates {
+		if d := levenshtein(strings.ToLower(name), strings.ToLower(c)); d <= maxDist {
+			matches = append(matches, match{c, d})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].dist < matches[j].dist })
+	names := make([]string, 0, 3)
+	for i := 0; i < len(matches) && i < 3 && matches[i].dist == matches[0].dist; i++ {
+		names = append(names, matches[i].name)
+	}
+	return names
+}
+
+// didYouMean formats suggestions for an error message.
+func didYouMean(suggestions []string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+	return "; did you mean " + strings.Join(suggestions, " or ") + "?"
+}
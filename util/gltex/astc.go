@@ -0,0 +1,716 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package gltex
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// astcBits holds the 128 bits of an ASTC block.
+type astcBits struct {
+	lo, hi uint64
+}
+
+// get returns n bits starting at bit pos. Bits at end and beyond read as
+// zero.
+func (b astcBits) get(pos, n, end int) int {
+	if pos >= end || n == 0 {
+		return 0
+	}
+	if pos+n > end {
+		n = end - pos
+	}
+	var v uint64
+	if pos >= 64 {
+		v = b.hi >> uint(pos-64)
+	} else {
+		v = b.lo >> uint(pos)
+		if pos > 0 {
+			v |= b.hi << uint(64-pos)
+		}
+	}
+	return int(v & (1<<uint(n) - 1))
+}
+
+func (b *astcBits) set(pos, n, v int) {
+	for i := 0; i < n; i++ {
+		bit := uint64(v>>uint(i)) & 1
+		if p := pos + i; p >= 64 {
+			b.hi |= bit << uint(p-64)
+		} else {
+			b.lo |= bit << uint(p)
+		}
+	}
+}
+
+func (b astcBits) reverse() astcBits {
+	return astcBits{bits.Reverse64(b.hi), bits.Reverse64(b.lo)}
+}
+
+// astcRange is a range of the integer sequence encoding: values have
+// bits bits and one trit or quint or neither.
+type astcRange struct {
+	levels, trits, quints, bits int
+}
+
+var astcRanges = []astcRange{
+	{2, 0, 0, 1}, {3, 1, 0, 0}, {4, 0, 0, 2}, {5, 0, 1, 0}, {6, 1, 0, 1},
+	{8, 0, 0, 3}, {10, 0, 1, 1}, {12, 1, 0, 2}, {16, 0, 0, 4}, {20, 0, 1, 2},
+	{24, 1, 0, 3}, {32, 0, 0, 5}, {40, 0, 1, 3}, {48, 1, 0, 4}, {64, 0, 0, 6},
+	{80, 0, 1, 4}, {96, 1, 0, 5}, {128, 0, 0, 7}, {160, 0, 1, 5}, {192, 1, 0, 6},
+	{256, 0, 0, 8},
+}
+
+// Indices into astcRanges.
+const (
+	astcRange4   = 2
+	astcRange6   = 4
+	astcRange256 = 20
+)
+
+// size returns the number of bits of n values.
+func (r astcRange) size(n int) int {
+	return n*r.bits + (8*n*r.trits+4)/5 + (7*n*r.quints+2)/3
+}
+
+var astcTrits, astcQuints = makeASTCTrits(), makeASTCQuints()
+
+// makeASTCTrits returns the 5 trits of every 8 bit trit block.
+func makeASTCTrits() (t [256][5]int) {
+	for i := range t {
+		bit := func(n uint) int { return i >> n & 1 }
+		var c int
+		if i>>2&7 == 7 {
+			c = i>>5&7<<2 | i&3
+			t[i][4], t[i][3] = 2, 2
+		} else {
+			c = i & 0x1f
+			if i>>5&3 == 3 {
+				t[i][4], t[i][3] = 2, bit(7)
+			} else {
+				t[i][4], t[i][3] = bit(7), i>>5&3
+			}
+		}
+		switch {
+		case c&3 == 3:
+			t[i][2], t[i][1], t[i][0] = 2, c>>4&1, c>>3&1<<1|c>>2&1&^(c>>3&1)
+		case c>>2&3 == 3:
+			t[i][2], t[i][1], t[i][0] = 2, 2, c&3
+		default:
+			t[i][2], t[i][1], t[i][0] = c>>4&1, c>>2&3, c>>1&1<<1|c&1&^(c>>1&1)
+		}
+	}
+	return t
+}
+
+// makeASTCQuints returns the 3 quints of every 7 bit quint block.
+func makeASTCQuints() (q [128][3]int) {
+	for i := range q {
+		bit := func(n uint) int { return i >> n & 1 }
+		if i>>1&3 == 3 && i>>5&3 == 0 {
+			q[i][2] = bit(0)<<2 | (bit(4)&^bit(0))<<1 | bit(3)&^bit(0)
+			q[i][1], q[i][0] = 4, 4
+			continue
+		}
+		var c int
+		if i>>1&3 == 3 {
+			q[i][2] = 4
+			c = i>>3&3<<3 | (^i>>5&3)<<1 | bit(0)
+		} else {
+			q[i][2] = i >> 5 & 3
+			c = i & 0x1f
+		}
+		if c&7 == 5 {
+			q[i][1], q[i][0] = 4, c>>3&3
+		} else {
+			q[i][1], q[i][0] = c>>3&3, c&7
+		}
+	}
+	return q
+}
+
+// decodeISE decodes n values of range r starting at bit pos.
+func decodeISE(b astcBits, pos, n int, r astcRange) []int {
+	end := pos + r.size(n)
+	values := make([]int, n)
+	read := func(k int) int {
+		v := b.get(pos, k, end)
+		pos += k
+		return v
+	}
+	switch {
+	case r.trits != 0:
+		for i := 0; i < n; i += 5 {
+			var m [5]int
+			t := 0
+			for j, k := range [5]uint{2, 2, 1, 2, 1} {
+				m[j] = read(r.bits)
+				t |= read(int(k)) << [5]uint{0, 2, 4, 5, 7}[j]
+			}
+			for j := 0; j < 5 && i+j < n; j++ {
+				values[i+j] = astcTrits[t][j]<<uint(r.bits) | m[j]
+			}
+		}
+	case r.quints != 0:
+		for i := 0; i < n; i += 3 {
+			var m [3]int
+			q := 0
+			for j, k := range [3]uint{3, 2, 2} {
+				m[j] = read(r.bits)
+				q |= read(int(k)) << [3]uint{0, 3, 5}[j]
+			}
+			for j := 0; j < 3 && i+j < n; j++ {
+				values[i+j] = astcQuints[q][j]<<uint(r.bits) | m[j]
+			}
+		}
+	default:
+		for i := range values {
+			values[i] = read(r.bits)
+		}
+	}
+	return values
+}
+
+// bitReplicate replicates the n bit value v to m bits.
+func bitReplicate(v, n, m int) int {
+	r := 0
+	for shift := m - n; shift > -n; shift -= n {
+		if shift >= 0 {
+			r |= v << uint(shift)
+		} else {
+			r |= v >> uint(-shift)
+		}
+	}
+	return r
+}
+
+// unquantizeColor maps a color endpoint value of range r to [0, 255].
+func unquantizeColor(v int, r astcRange) int {
+	if r.trits == 0 && r.quints == 0 {
+		return bitReplicate(v, r.bits, 8)
+	}
+	m, d := v&(1<<uint(r.bits)-1), v>>uint(r.bits)
+	bit := func(n uint) int { return m >> n & 1 }
+	a := 0
+	if m&1 != 0 {
+		a = 0x1ff
+	}
+	b, c, d1, e, f := bit(1), bit(2), bit(3), bit(4), bit(5)
+	var B, C int
+	if r.trits != 0 {
+		switch r.bits {
+		case 1:
+			C = 204
+		case 2:
+			B, C = b<<8|b<<4|b<<2|b<<1, 93
+		case 3:
+			B, C = c<<8|b<<7|c<<3|b<<2|c<<1|b, 44
+		case 4:
+			B, C = d1<<8|c<<7|b<<6|d1<<2|c<<1|b, 22
+		case 5:
+			B, C = e<<8|d1<<7|c<<6|b<<5|e<<1|d1, 11
+		case 6:
+			B, C = f<<8|e<<7|d1<<6|c<<5|b<<4|f, 5
+		}
+	} else {
+		switch r.bits {
+		case 1:
+			C = 113
+		case 2:
+			B, C = b<<8|b<<3|b<<2, 54
+		case 3:
+			B, C = c<<8|b<<7|c<<2|b<<1|c, 26
+		case 4:
+			B, C = d1<<8|c<<7|b<<6|d1<<1|c, 13
+		case 5:
+			B, C = e<<8|d1<<7|c<<6|b<<5|e, 6
+		}
+	}
+	t := (d*C + B) ^ a
+	return a&0x80 | t>>2
+}
+
+// unquantizeWeight maps a weight of range r to [0, 64].
+func unquantizeWeight(v int, r astcRange) int {
+	var t int
+	switch {
+	case r.trits == 0 && r.quints == 0:
+		t = bitReplicate(v, r.bits, 6)
+	case r.bits == 0 && r.trits != 0:
+		t = [3]int{0, 32, 63}[v]
+	case r.bits == 0:
+		t = [5]int{0, 16, 32, 47, 63}[v]
+	default:
+		m, d := v&(1<<uint(r.bits)-1), v>>uint(r.bits)
+		b, c := m>>1&1, m>>2&1
+		a := 0
+		if m&1 != 0 {
+			a = 0x7f
+		}
+		var B, C int
+		switch {
+		case r.trits != 0 && r.bits == 1:
+			C = 50
+		case r.trits != 0 && r.bits == 2:
+			B, C = b<<6|b<<2|b, 23
+		case r.trits != 0:
+			B, C = c<<6|b<<5|c<<1|b, 11
+		case r.bits == 1:
+			C = 28
+		default:
+			B, C = b<<6|b<<1|b, 13
+		}
+		t = a&0x20 | ((d*C+B)^a)>>2
+	}
+	if t > 32 {
+		t++
+	}
+	return t
+}
+
+// astcBlockMode decodes the block mode of a 2D block: the size of the
+// weight grid, whether there are two weight planes and the range of the
+// weights.
+func astcBlockMode(mode int) (width, height int, dual bool, r astcRange, ok bool) {
+	q := mode >> 4 & 1
+	h, d := mode>>9&1, mode>>10&1
+	a := mode >> 5 & 3
+	if mode&3 != 0 {
+		q |= mode & 3 << 1
+		b := mode >> 7 & 3
+		switch mode >> 2 & 3 {
+		case 0:
+			width, height = b+4, a+2
+		case 1:
+			width, height = b+8, a+2
+		case 2:
+			width, height = a+2, b+8
+		default:
+			b &= 1
+			if mode&0x100 != 0 {
+				width, height = b+2, a+2
+			} else {
+				width, height = a+2, b+6
+			}
+		}
+	} else {
+		q |= mode >> 2 & 3 << 1
+		if mode>>2&3 == 0 {
+			return
+		}
+		b := mode >> 9 & 3
+		switch mode >> 7 & 3 {
+		case 0:
+			width, height = 12, a+2
+		case 1:
+			width, height = a+2, 12
+		case 2:
+			width, height, d, h = a+6, b+6, 0, 0
+		default:
+			switch a {
+			case 0:
+				width, height = 6, 10
+			case 1:
+				width, height = 10, 6
+			default:
+				return
+			}
+		}
+	}
+	r = astcRanges[q-2+6*h]
+	n := width * height * (d + 1)
+	size := r.size(n)
+	return width, height, d != 0, r, n <= 64 && size >= 24 && size <= 96
+}
+
+// astcHash is the hash of the partition function.
+func astcHash(v uint32) uint32 {
+	v ^= v >> 15
+	v *= 0xeede0891
+	v ^= v >> 5
+	v += v << 16
+	v ^= v >> 7
+	v ^= v >> 3
+	v ^= v << 6
+	v ^= v >> 17
+	return v
+}
+
+// astcPartition returns the partition of texel x, y in a block with n
+// partitions.
+func astcPartition(seed, x, y, n int, small bool) int {
+	if small {
+		x, y = x<<1, y<<1
+	}
+	seed += (n - 1) * 1024
+	rnum := astcHash(uint32(seed))
+	var s [8]uint
+	for i := range s {
+		v := rnum >> uint(4*i) & 0xf
+		s[i] = uint(v * v)
+	}
+	var sh1, sh2 uint
+	if seed&1 != 0 {
+		sh1, sh2 = 4, 5
+		if seed&2 == 0 {
+			sh1 = 5
+		}
+		if n == 3 {
+			sh2 = 6
+		}
+	} else {
+		sh1, sh2 = 5, 4
+		if n == 3 {
+			sh1 = 6
+		}
+		if seed&2 == 0 {
+			sh2 = 5
+		}
+	}
+	for i := range s {
+		if i&1 == 0 {
+			s[i] >>= sh1
+		} else {
+			s[i] >>= sh2
+		}
+	}
+	ux, uy := uint(x), uint(y)
+	p := [4]uint{
+		(s[0]*ux + s[1]*uy + uint(rnum>>14)) & 0x3f,
+		(s[2]*ux + s[3]*uy + uint(rnum>>10)) & 0x3f,
+		(s[4]*ux + s[5]*uy + uint(rnum>>6)) & 0x3f,
+		(s[6]*ux + s[7]*uy + uint(rnum>>2)) & 0x3f,
+	}
+	if n < 4 {
+		p[3] = 0
+	}
+	if n < 3 {
+		p[2] = 0
+	}
+	switch {
+	case p[0] >= p[1] && p[0] >= p[2] && p[0] >= p[3]:
+		return 0
+	case p[1] >= p[2] && p[1] >= p[3]:
+		return 1
+	case p[2] >= p[3]:
+		return 2
+	}
+	return 3
+}
+
+// bitTransferSigned moves the top bit of b to a and returns a as a signed
+// offset.
+func bitTransferSigned(a, b int) (int, int) {
+	b = b>>1 | a&0x80
+	a = a >> 1 & 0x3f
+	if a&0x20 != 0 {
+		a -= 0x40
+	}
+	return a, b
+}
+
+func blueContract(r, g, b, a int) [4]int {
+	return [4]int{(r + b) >> 1, (g + b) >> 1, b, a}
+}
+
+func clampColor(c [4]int) [4]int {
+	for i := range c {
+		c[i] = clamp255(c[i])
+	}
+	return c
+}
+
+// astcEndpoints decodes the endpoints of a LDR color endpoint mode. HDR
+// modes are not supported.
+func astcEndpoints(mode int, v []int) (e0, e1 [4]int, ok bool) {
+	switch mode {
+	case 0:
+		e0, e1 = [4]int{v[0], v[0], v[0], 255}, [4]int{v[1], v[1], v[1], 255}
+	case 1:
+		l0 := v[0]>>2 | v[1]&0xc0
+		l1 := l0 + v[1]&0x3f
+		if l1 > 255 {
+			l1 = 255
+		}
+		e0, e1 = [4]int{l0, l0, l0, 255}, [4]int{l1, l1, l1, 255}
+	case 4:
+		e0, e1 = [4]int{v[0], v[0], v[0], v[2]}, [4]int{v[1], v[1], v[1], v[3]}
+	case 5:
+		v1, v0 := bitTransferSigned(v[1], v[0])
+		v3, v2 := bitTransferSigned(v[3], v[2])
+		e0, e1 = [4]int{v0, v0, v0, v2}, clampColor([4]int{v0 + v1, v0 + v1, v0 + v1, v2 + v3})
+	case 6:
+		e0 = [4]int{v[0] * v[3] >> 8, v[1] * v[3] >> 8, v[2] * v[3] >> 8, 255}
+		e1 = [4]int{v[0], v[1], v[2], 255}
+	case 8, 12:
+		a0, a1 := 255, 255
+		if mode == 12 {
+			a0, a1 = v[6], v[7]
+		}
+		if v[1]+v[3]+v[5] >= v[0]+v[2]+v[4] {
+			e0, e1 = [4]int{v[0], v[2], v[4], a0}, [4]int{v[1], v[3], v[5], a1}
+		} else {
+			e0, e1 = blueContract(v[1], v[3], v[5], a1), blueContract(v[0], v[2], v[4], a0)
+		}
+	case 9, 13:
+		var s [4][2]int // offset and base
+		for i := range s {
+			s[i][0], s[i][1] = 255, 255
+		}
+		for i := 0; i < 3; i++ {
+			s[i][0], s[i][1] = bitTransferSigned(v[2*i+1], v[2*i])
+		}
+		if mode == 13 {
+			s[3][0], s[3][1] = bitTransferSigned(v[7], v[6])
+		} else {
+			s[3][0] = 0
+		}
+		if s[0][0]+s[1][0]+s[2][0] >= 0 {
+			e0 = [4]int{s[0][1], s[1][1], s[2][1], s[3][1]}
+			e1 = [4]int{s[0][1] + s[0][0], s[1][1] + s[1][0], s[2][1] + s[2][0], s[3][1] + s[3][0]}
+		} else {
+			e0 = blueContract(s[0][1]+s[0][0], s[1][1]+s[1][0], s[2][1]+s[2][0], s[3][1]+s[3][0])
+			e1 = blueContract(s[0][1], s[1][1], s[2][1], s[3][1])
+		}
+		e0, e1 = clampColor(e0), clampColor(e1)
+	case 10:
+		e0 = [4]int{v[0] * v[3] >> 8, v[1] * v[3] >> 8, v[2] * v[3] >> 8, v[4]}
+		e1 = [4]int{v[0], v[1], v[2], v[5]}
+	default:
+		return e0, e1, false
+	}
+	return e0, e1, true
+}
+
+// infillWeights interpolates the weights of a width*height grid to the
+// texels of a bw*bh block.
+func infillWeights(grid []int, width, height, bw, bh int) []int {
+	w := make([]int, bw*bh)
+	ds, dt := (1024+bw/2)/(bw-1), (1024+bh/2)/(bh-1)
+	at := func(i int) int {
+		if i < len(grid) {
+			return grid[i]
+		}
+		return 0
+	}
+	for t := 0; t < bh; t++ {
+		for s := 0; s < bw; s++ {
+			gs, gt := (ds*s*(width-1)+32)>>6, (dt*t*(height-1)+32)>>6
+			js, fs, jt, ft := gs>>4, gs&0xf, gt>>4, gt&0xf
+			v0 := js + jt*width
+			w11 := (fs*ft + 8) >> 4
+			w10, w01 := ft-w11, fs-w11
+			w00 := 16 - fs - ft + w11
+			w[t*bw+s] = (at(v0)*w00 + at(v0+1)*w01 + at(v0+width)*w10 + at(v0+width+1)*w11 + 8) >> 4
+		}
+	}
+	return w
+}
+
+func decodeASTC(block []byte, texels []channels, bw, bh int, srgb bool) {
+	if !decodeASTCBlock(block, texels, bw, bh, srgb) {
+		for i := range texels {
+			texels[i].setRGBA(255, 0, 255, 255)
+		}
+	}
+}
+
+func decodeASTCBlock(block []byte, texels []channels, bw, bh int, srgb bool) bool {
+	b := astcBits{binary.LittleEndian.Uint64(block), binary.LittleEndian.Uint64(block[8:])}
+	mode := b.get(0, 11, 128)
+	if mode&0x1ff == 0x1fc {
+		// void extent block of a single color
+		if mode&0x200 != 0 {
+			return false
+		}
+		var c channels
+		for i := 0; i < 4; i++ {
+			c[i] = uint16(b.get(64+16*i, 16, 128))
+		}
+		for i := range texels {
+			texels[i] = c
+		}
+		return true
+	}
+	gw, gh, dual, wr, ok := astcBlockMode(mode)
+	if !ok || gw > bw || gh > bh {
+		return false
+	}
+	partitions := b.get(11, 2, 128) + 1
+	if dual && partitions == 4 {
+		return false
+	}
+	nw := gw * gh
+	if dual {
+		nw *= 2
+	}
+	below := 128 - wr.size(nw)
+	var modes [4]int
+	seed, colorStart := 0, 17
+	if partitions == 1 {
+		modes[0] = b.get(13, 4, 128)
+	} else {
+		seed, colorStart = b.get(13, 10, 128), 29
+		m := b.get(23, 6, 128)
+		if m&3 == 0 {
+			for i := range modes {
+				modes[i] = m >> 2
+			}
+		} else {
+			extra := 3*partitions - 4
+			below -= extra
+			m |= b.get(below, extra, 128) << 6
+			class := m&3 - 1
+			m >>= 2
+			for i := 0; i < partitions; i++ {
+				modes[i] = (class+m>>uint(i)&1)<<2 | m>>uint(partitions+2*i)&3
+			}
+		}
+	}
+	plane2 := -1
+	if dual {
+		below -= 2
+		plane2 = b.get(below, 2, 128)
+	}
+	n := 0
+	for i := 0; i < partitions; i++ {
+		n += 2 * (modes[i]>>2 + 1)
+	}
+	if n > 18 {
+		return false
+	}
+	cr := -1
+	for i := astcRange256; i >= astcRange6; i-- {
+		if astcRanges[i].size(n) <= below-colorStart {
+			cr = i
+			break
+		}
+	}
+	if cr < 0 {
+		return false
+	}
+	values := decodeISE(b, colorStart, n, astcRanges[cr])
+	for i := range values {
+		values[i] = unquantizeColor(values[i], astcRanges[cr])
+	}
+	var endpoints [4][2][4]int
+	for i := 0; i < partitions; i++ {
+		k := 2 * (modes[i]>>2 + 1)
+		e0, e1, ok := astcEndpoints(modes[i], values[:k])
+		if !ok {
+			return false
+		}
+		endpoints[i] = [2][4]int{e0, e1}
+		values = values[k:]
+	}
+
+	weights := decodeISE(b.reverse(), 0, nw, wr)
+	for i := range weights {
+		weights[i] = unquantizeWeight(weights[i], wr)
+	}
+	var planes [2][]int
+	if dual {
+		var g [2][]int
+		for i, w := range weights {
+			g[i&1] = append(g[i&1], w)
+		}
+		planes[0], planes[1] = infillWeights(g[0], gw, gh, bw, bh), infillWeights(g[1], gw, gh, bw, bh)
+	} else {
+		planes[0] = infillWeights(weights, gw, gh, bw, bh)
+	}
+
+	for y := 0; y < bh; y++ {
+		for x := 0; x < bw; x++ {
+			i := y*bw + x
+			p := 0
+			if partitions > 1 {
+				p = astcPartition(seed, x, y, partitions, bw*bh < 31)
+			}
+			for ch := 0; ch < 4; ch++ {
+				w := planes[0][i]
+				if ch == plane2 {
+					w = planes[1][i]
+				}
+				c0, c1 := endpoints[p][0][ch]<<8, endpoints[p][1][ch]<<8
+				if srgb {
+					c0, c1 = c0|0x80, c1|0x80
+				} else {
+					c0, c1 = c0|c0>>8, c1|c1>>8
+				}
+				texels[i][ch] = uint16((c0*(64-w) + c1*w + 32) >> 6)
+			}
+		}
+	}
+	return true
+}
+
+// astcEncodeMode is the block mode of a 4x4 weight grid with 2 bit weights.
+const astcEncodeMode = 0x42
+
+// encodeASTC encodes texels as a block with a single partition, the RGBA
+// direct color endpoint mode with 8 bit endpoints at the diagonal of the
+// bounding box of the colors and a 4x4 grid of 2 bit weights.
+func encodeASTC(texels []channels, block []byte, bw, bh int) {
+	colors := make([][4]int, len(texels))
+	for i := range texels {
+		colors[i] = texels[i].rgba8()
+	}
+	lo, hi := boundingBox(colors, 4)
+	if hi[0]+hi[1]+hi[2] < lo[0]+lo[1]+lo[2] {
+		// the decoder applies blue contraction otherwise
+		lo, hi = hi, lo
+	}
+	var b astcBits
+	b.set(0, 11, astcEncodeMode)
+	b.set(13, 4, 12)
+	for ch := 0; ch < 4; ch++ {
+		b.set(17+16*ch, 8, lo[ch])
+		b.set(25+16*ch, 8, hi[ch])
+	}
+	// project every texel onto the endpoint line and average the
+	// projections of the texels nearest to every grid point
+	d := [4]int{hi[0] - lo[0], hi[1] - lo[1], hi[2] - lo[2], hi[3] - lo[3]}
+	dd := sqDist(d, [4]int{}, 4)
+	var sum, count [16]int
+	for y := 0; y < bh; y++ {
+		for x := 0; x < bw; x++ {
+			g := (y*3+(bh-1)/2)/(bh-1)*4 + (x*3+(bw-1)/2)/(bw-1)
+			if dd > 0 {
+				c := colors[y*bw+x]
+				p := 0
+				for ch := range c {
+					p += (c[ch] - lo[ch]) * d[ch]
+				}
+				sum[g] += 64 * p / dd
+			}
+			count[g]++
+		}
+	}
+	for g := range sum {
+		w := 0
+		if count[g] > 0 {
+			w = sum[g] / count[g]
+		}
+		q := 0
+		for i, u := range [4]int{0, 21, 43, 64} {
+			if abs(u-w) < abs([4]int{0, 21, 43, 64}[q]-w) {
+				q = i
+			}
+		}
+		// weights are stored from the top bit down
+		b.set(127-2*g, 1, q)
+		b.set(126-2*g, 1, q>>1)
+	}
+	binary.LittleEndian.PutUint64(block, b.lo)
+	binary.LittleEndian.PutUint64(block[8:], b.hi)
+}
+
+func astcCodec(bw, bh int, srgb bool) *blockCodec {
+	return &blockCodec{bw, bh, 16, glRGBA, false,
+		func(block []byte, texels []channels) { decodeASTC(block, texels, bw, bh, srgb) },
+		func(texels []channels, block []byte) { encodeASTC(texels, block, bw, bh) },
+	}
+}
@@ -0,0 +1,278 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package gltex
+
+import (
+	"encoding/binary"
+)
+
+// Interpretations of the color block of BC1 to BC3.
+const (
+	bc1RGB       = iota // 3 color mode has opaque black
+	bc1RGBA             // 3 color mode has transparent black
+	bcFourColors        // BC2 and BC3 always have 4 colors
+)
+
+func unpack565(c uint16) [4]int {
+	r, g, b := int(c>>11), int(c>>5&0x3f), int(c&0x1f)
+	return [4]int{r<<3 | r>>2, g<<2 | g>>4, b<<3 | b>>2, 255}
+}
+
+func pack565(c [4]int) uint16 {
+	return uint16((c[0]*31+127)/255<<11 | (c[1]*63+127)/255<<5 | (c[2]*31+127)/255)
+}
+
+// bc1Palette returns the 4 colors of a color block with the endpoints c0
+// and c1.
+func bc1Palette(c0, c1 uint16, mode int) [4][4]int {
+	var p [4][4]int
+	p[0], p[1] = unpack565(c0), unpack565(c1)
+	if c0 > c1 || mode == bcFourColors {
+		for i := 0; i < 3; i++ {
+			p[2][i] = (2*p[0][i] + p[1][i]) / 3
+			p[3][i] = (p[0][i] + 2*p[1][i]) / 3
+		}
+		p[2][3], p[3][3] = 255, 255
+		return p
+	}
+	for i := 0; i < 3; i++ {
+		p[2][i] = (p[0][i] + p[1][i]) / 2
+	}
+	p[2][3] = 255
+	if mode == bc1RGB {
+		p[3][3] = 255
+	}
+	return p
+}
+
+func decodeBC1Colors(block []byte, texels []channels, mode int) {
+	p := bc1Palette(binary.LittleEndian.Uint16(block), binary.LittleEndian.Uint16(block[2:]), mode)
+	indices := binary.LittleEndian.Uint32(block[4:])
+	for i := range texels {
+		c := p[indices>>uint(2*i)&3]
+		texels[i].setRGBA(c[0], c[1], c[2], c[3])
+	}
+}
+
+// encodeBC1Colors encodes the colors of texels with the diagonal of the
+// bounding box of the colors as endpoints. Texels with an alpha below one half are
+// encoded as transparent in bc1RGBA mode.
+func encodeBC1Colors(texels []channels, block []byte, mode int) {
+	var colors [][4]int
+	transparent := false
+	for i := range texels {
+		c := texels[i].rgba8()
+		if mode == bc1RGBA && c[3] < 128 {
+			transparent = true
+			continue
+		}
+		colors = append(colors, c)
+	}
+	lo, hi := boundingBox(colors, 3)
+	c0, c1 := pack565(hi), pack565(lo)
+	if c0 < c1 != transparent {
+		// 4 color mode needs c0 > c1, 3 color mode c0 <= c1
+		c0, c1 = c1, c0
+	}
+	binary.LittleEndian.PutUint16(block, c0)
+	binary.LittleEndian.PutUint16(block[2:], c1)
+	p := bc1Palette(c0, c1, mode)
+	var indices uint32
+	for i := range texels {
+		c := texels[i].rgba8()
+		best, bestDist := 3, -1
+		if !transparent || c[3] >= 128 {
+			for j, pc := range p {
+				if pc[3] == 0 {
+					continue
+				}
+				if d := sqDist(c, pc, 3); bestDist < 0 || d < bestDist {
+					best, bestDist = j, d
+				}
+			}
+		}
+		indices |= uint32(best) << uint(2*i)
+	}
+	binary.LittleEndian.PutUint32(block[4:], indices)
+}
+
+func bc1Codec(mode int) *blockCodec {
+	return &blockCodec{4, 4, 8, glRGBA, false,
+		func(block []byte, texels []channels) { decodeBC1Colors(block, texels, mode) },
+		func(texels []channels, block []byte) { encodeBC1Colors(texels, block, mode) },
+	}
+}
+
+func decodeBC2(block []byte, texels []channels) {
+	decodeBC1Colors(block[8:], texels, bcFourColors)
+	alpha := binary.LittleEndian.Uint64(block)
+	for i := range texels {
+		texels[i][chA] = uint16(alpha>>uint(4*i)&0xf) * 0x1111
+	}
+}
+
+func encodeBC2(texels []channels, block []byte) {
+	var alpha uint64
+	for i := range texels {
+		alpha |= uint64((int(texels[i][chA]>>8)*15+127)/255) << uint(4*i)
+	}
+	binary.LittleEndian.PutUint64(block, alpha)
+	encodeBC1Colors(texels, block[8:], bcFourColors)
+}
+
+// bc4Palette returns the 8 values of a BC4 block or the alpha block of
+// BC3 with the endpoints v0 and v1.
+func bc4Palette(v0, v1 int, signed bool) [8]int {
+	var p [8]int
+	p[0], p[1] = v0, v1
+	if v0 > v1 {
+		for i := 2; i < 8; i++ {
+			p[i] = ((8-i)*v0 + (i-1)*v1) / 7
+		}
+		return p
+	}
+	for i := 2; i < 6; i++ {
+		p[i] = ((6-i)*v0 + (i-1)*v1) / 5
+	}
+	p[6], p[7] = 0, 255
+	if signed {
+		p[6], p[7] = -127, 127
+	}
+	return p
+}
+
+// decodeBC4 decodes the values of a BC4 block. Signed values are mapped
+// to [0, 254].
+func decodeBC4(block []byte, values []int, signed bool) {
+	v0, v1 := int(block[0]), int(block[1])
+	if signed {
+		v0, v1 = signedEndpoint(block[0]), signedEndpoint(block[1])
+	}
+	p := bc4Palette(v0, v1, signed)
+	indices := binary.LittleEndian.Uint64(block) >> 16
+	for i := range values {
+		values[i] = p[indices>>uint(3*i)&7]
+		if signed {
+			values[i] += 127
+		}
+	}
+}
+
+// encodeBC4 encodes values from [0, 255], or [0, 254] if signed, with
+// their range as endpoints.
+func encodeBC4(values []int, block []byte, signed bool) {
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+	if signed {
+		lo, hi = lo-127, hi-127
+	}
+	p := bc4Palette(hi, lo, signed)
+	var indices uint64
+	for i, v := range values {
+		if signed {
+			v -= 127
+		}
+		best := 0
+		for j := range p {
+			if abs(p[j]-v) < abs(p[best]-v) {
+				best = j
+			}
+		}
+		indices |= uint64(best) << uint(3*i)
+	}
+	binary.LittleEndian.PutUint64(block, indices<<16|uint64(uint8(lo))<<8|uint64(uint8(hi)))
+}
+
+// signedEndpoint returns the signed value of b, with -128 read as -127.
+func signedEndpoint(b byte) int {
+	if v := int(int8(b)); v > -128 {
+		return v
+	}
+	return -127
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// signedTo16 maps a signed value from [0, 254] to 16 bits.
+func signedTo16(v int) uint16 {
+	return uint16((v*0xffff + 127) / 254)
+}
+
+// signedFrom16 maps a 16 bit value to a signed value in [0, 254].
+func signedFrom16(v uint16) int {
+	return (int(v)*254 + 0x7fff) / 0xffff
+}
+
+func decodeBC3(block []byte, texels []channels) {
+	decodeBC1Colors(block[8:], texels, bcFourColors)
+	var alpha [16]int
+	decodeBC4(block, alpha[:], false)
+	for i := range texels {
+		texels[i][chA] = uint16(alpha[i]) * 0x101
+	}
+}
+
+func encodeBC3(texels []channels, block []byte) {
+	var alpha [16]int
+	for i := range texels {
+		alpha[i] = int(texels[i][chA] >> 8)
+	}
+	encodeBC4(alpha[:], block, false)
+	encodeBC1Colors(texels, block[8:], bcFourColors)
+}
+
+func bc4Codec(signed bool) *blockCodec {
+	c := bc5Codec(signed)
+	c.size, c.format = 8, glRED
+	return c
+}
+
+// bc5Codec decodes and encodes a BC4 block for R and one for G. The
+// channels of a BC4 codec are decoded the same way with a smaller block.
+func bc5Codec(signed bool) *blockCodec {
+	return &blockCodec{4, 4, 16, glRGBA, false,
+		func(block []byte, texels []channels) {
+			var values [16]int
+			for i := range texels {
+				texels[i] = channels{chA: 0xffff}
+			}
+			for ch := 0; ch < len(block)/8; ch++ {
+				decodeBC4(block[8*ch:], values[:], signed)
+				for i := range texels {
+					if signed {
+						texels[i][ch] = signedTo16(values[i])
+					} else {
+						texels[i][ch] = uint16(values[i]) * 0x101
+					}
+				}
+			}
+		},
+		func(texels []channels, block []byte) {
+			var values [16]int
+			for ch := 0; ch < len(block)/8; ch++ {
+				for i := range texels {
+					if signed {
+						values[i] = signedFrom16(texels[i][ch])
+					} else {
+						values[i] = int(texels[i][ch] >> 8)
+					}
+				}
+				encodeBC4(values[:], block[8*ch:], signed)
+			}
+		},
+	}
+}
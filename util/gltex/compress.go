@@ -0,0 +1,212 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package gltex
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/chsc/gogl2/glt"
+)
+
+// blockCodec decodes and encodes the blocks of a compressed format.
+// Texels of a block are passed row by row with 16 bit channels.
+type blockCodec struct {
+	width, height int      // in texels
+	size          int      // in bytes
+	format        glt.Enum // RED or RGBA, the format of the decoded image
+	deep          bool     // decoded components have more than 8 bits
+	decode        func(block []byte, texels []channels)
+	encode        func(texels []channels, block []byte) // nil if not supported
+}
+
+// blockCodecs holds the codecs of the compressed internal formats.
+var blockCodecs = map[glt.Enum]*blockCodec{
+	glCOMPRESSED_RGB_S3TC_DXT1_EXT:        bc1Codec(bc1RGB),
+	glCOMPRESSED_SRGB_S3TC_DXT1_EXT:       bc1Codec(bc1RGB),
+	glCOMPRESSED_RGBA_S3TC_DXT1_EXT:       bc1Codec(bc1RGBA),
+	glCOMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT: bc1Codec(bc1RGBA),
+	glCOMPRESSED_RGBA_S3TC_DXT3_EXT:       {4, 4, 16, glRGBA, false, decodeBC2, encodeBC2},
+	glCOMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT: {4, 4, 16, glRGBA, false, decodeBC2, encodeBC2},
+	glCOMPRESSED_RGBA_S3TC_DXT5_EXT:       {4, 4, 16, glRGBA, false, decodeBC3, encodeBC3},
+	glCOMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT: {4, 4, 16, glRGBA, false, decodeBC3, encodeBC3},
+	glCOMPRESSED_RED_RGTC1:                bc4Codec(false),
+	glCOMPRESSED_SIGNED_RED_RGTC1:         bc4Codec(true),
+	glCOMPRESSED_RG_RGTC2:                 bc5Codec(false),
+	glCOMPRESSED_SIGNED_RG_RGTC2:          bc5Codec(true),
+
+	glETC1_RGB8_OES:                             etcCodec(etc1),
+	glCOMPRESSED_RGB8_ETC2:                      etcCodec(etc2),
+	glCOMPRESSED_SRGB8_ETC2:                     etcCodec(etc2),
+	glCOMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2:  etcCodec(etc2Punchthrough),
+	glCOMPRESSED_SRGB8_PUNCHTHROUGH_ALPHA1_ETC2: etcCodec(etc2Punchthrough),
+	glCOMPRESSED_RGBA8_ETC2_EAC:                 {4, 4, 16, glRGBA, false, decodeETC2EAC, encodeETC2EAC},
+	glCOMPRESSED_SRGB8_ALPHA8_ETC2_EAC:          {4, 4, 16, glRGBA, false, decodeETC2EAC, encodeETC2EAC},
+	glCOMPRESSED_R11_EAC:                        eacCodec(false, 1),
+	glCOMPRESSED_SIGNED_R11_EAC:                 eacCodec(true, 1),
+	glCOMPRESSED_RG11_EAC:                       eacCodec(false, 2),
+	glCOMPRESSED_SIGNED_RG11_EAC:                eacCodec(true, 2),
+}
+
+func init() {
+	for i, b := range astcBlocks {
+		blockCodecs[glCOMPRESSED_RGBA_ASTC_4x4_KHR+glt.Enum(i)] = astcCodec(b[0], b[1], false)
+		blockCodecs[glCOMPRESSED_SRGB8_ALPHA8_ASTC_4x4_KHR+glt.Enum(i)] = astcCodec(b[0], b[1], true)
+	}
+}
+
+// DecodeCompressed decodes compressed pixel data of internalFormat, as
+// passed to CompressedTexImage2D, into an image. Supported are the S3TC
+// and RGTC formats (BC1 to BC5), ETC1, the ETC2 and EAC formats and the
+// LDR profile of ASTC. RED formats give an *image.Gray, or an
+// *image.Gray16 for EAC, all others an *image.NRGBA or *image.NRGBA64.
+// Signed formats map -1 to 0 and 1 to the maximum. sRGB formats are not
+// converted, the image holds the sRGB encoded values. HDR ASTC blocks and
+// invalid blocks decode to magenta, as in GL.
+//
+// Unlike ConvertPixelData, the rows are not flipped: the first row of the
+// data is the first row of the image, see Texture.TopDown.
+func DecodeCompressed(internalFormat glt.Enum, data []byte, width, height int) (image.Image, error) {
+	c, ok := blockCodecs[internalFormat]
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	if width < 0 || height < 0 {
+		return nil, fmt.Errorf("gltex: invalid size %dx%d", width, height)
+	}
+	bw, bh := (width+c.width-1)/c.width, (height+c.height-1)/c.height
+	if len(data) < bw*bh*c.size {
+		return nil, fmt.Errorf("gltex: %d bytes of compressed data, %dx%d image needs %d", len(data), width, height, bw*bh*c.size)
+	}
+	r := image.Rect(0, 0, width, height)
+	var img image.Image
+	switch {
+	case c.format == glRED && c.deep:
+		img = image.NewGray16(r)
+	case c.format == glRED:
+		img = image.NewGray(r)
+	case c.deep:
+		img = image.NewNRGBA64(r)
+	default:
+		img = image.NewNRGBA(r)
+	}
+	p, _ := imagePixelsOf(img)
+	texels := make([]channels, c.width*c.height)
+	for by := 0; by < bh; by++ {
+		for bx := 0; bx < bw; bx++ {
+			c.decode(data[(by*bw+bx)*c.size:][:c.size], texels)
+			for ty := 0; ty < c.height; ty++ {
+				for tx := 0; tx < c.width; tx++ {
+					x, y := bx*c.width+tx, by*c.height+ty
+					if x < width && y < height {
+						p.set(p.offset(x, y), &texels[ty*c.width+tx])
+					}
+				}
+			}
+		}
+	}
+	return img, nil
+}
+
+// EncodeCompressed encodes img into compressed pixel data of
+// internalFormat for CompressedTexImage2D. The encoders are simple and
+// fast rather than good: they are meant to produce test data. All
+// formats of DecodeCompressed but the ETC2 punchthrough formats are
+// supported. ETC1 data is written for the ETC2 RGB formats and ASTC
+// blocks always use a single partition and a 4x4 weight grid. Rows are
+// not flipped.
+func EncodeCompressed(img image.Image, internalFormat glt.Enum) ([]byte, error) {
+	c, ok := blockCodecs[internalFormat]
+	if !ok || c.encode == nil {
+		return nil, ErrUnsupported
+	}
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	bw, bh := (width+c.width-1)/c.width, (height+c.height-1)/c.height
+	data := make([]byte, bw*bh*c.size)
+	texels := make([]channels, c.width*c.height)
+	for by := 0; by < bh; by++ {
+		for bx := 0; bx < bw; bx++ {
+			// texels outside the image repeat the edge
+			for ty := 0; ty < c.height; ty++ {
+				for tx := 0; tx < c.width; tx++ {
+					x, y := clampIndex(bx*c.width+tx, width), clampIndex(by*c.height+ty, height)
+					colorChannels(img.At(b.Min.X+x, b.Min.Y+y), StraightAlpha, &texels[ty*c.width+tx])
+				}
+			}
+			c.encode(texels, data[(by*bw+bx)*c.size:][:c.size])
+		}
+	}
+	return data, nil
+}
+
+// setRGBA sets the channels of t from 8 bit components.
+func (t *channels) setRGBA(r, g, b, a int) {
+	t[chR], t[chG], t[chB], t[chA] = uint16(r)*0x101, uint16(g)*0x101, uint16(b)*0x101, uint16(a)*0x101
+}
+
+// rgba8 returns the 8 bit components of t.
+func (t *channels) rgba8() [4]int {
+	return [4]int{int(t[chR] >> 8), int(t[chG] >> 8), int(t[chB] >> 8), int(t[chA] >> 8)}
+}
+
+func clamp255(v int) int {
+	switch {
+	case v < 0:
+		return 0
+	case v > 255:
+		return 255
+	}
+	return v
+}
+
+// boundingBox returns the endpoints of the diagonal of the bounding box of
+// the first n components of colors that follows the colors best: the
+// components that fall while the component with the largest range rises
+// are swapped.
+func boundingBox(colors [][4]int, n int) (lo, hi [4]int) {
+	if len(colors) == 0 {
+		return lo, hi
+	}
+	lo, hi = colors[0], colors[0]
+	var mean [4]int
+	for _, c := range colors {
+		for i := 0; i < n; i++ {
+			if c[i] < lo[i] {
+				lo[i] = c[i]
+			}
+			if c[i] > hi[i] {
+				hi[i] = c[i]
+			}
+			mean[i] += c[i]
+		}
+	}
+	ref := 0
+	for i := 0; i < n; i++ {
+		mean[i] /= len(colors)
+		if hi[i]-lo[i] > hi[ref]-lo[ref] {
+			ref = i
+		}
+	}
+	for i := 0; i < n; i++ {
+		cov := 0
+		for _, c := range colors {
+			cov += (c[i] - mean[i]) * (c[ref] - mean[ref])
+		}
+		if cov < 0 {
+			lo[i], hi[i] = hi[i], lo[i]
+		}
+	}
+	return lo, hi
+}
+
+// sqDist returns the squared distance of the first n components of a and b.
+func sqDist(a, b [4]int, n int) int {
+	d := 0
+	for i := 0; i < n; i++ {
+		d += (a[i] - b[i]) * (a[i] - b[i])
+	}
+	return d
+}
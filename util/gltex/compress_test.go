@@ -0,0 +1,274 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package gltex
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/chsc/gogl2/glt"
+)
+
+// gradient returns an image with a diagonal gradient in all channels.
+func gradient(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := (x + y) * 255 / (w + h)
+			img.Set(x, y, color.NRGBA{uint8(v), uint8(255 - v), uint8(v / 2), uint8(255 - v/3)})
+		}
+	}
+	return img
+}
+
+// maxError returns the largest difference of the 8 bit channels chs of a
+// and b.
+func maxError(a, b image.Image, chs []int) int {
+	var ca, cb channels
+	e := 0
+	r := a.Bounds()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			colorChannels(a.At(x, y), StraightAlpha, &ca)
+			colorChannels(b.At(x, y), StraightAlpha, &cb)
+			for _, ch := range chs {
+				if d := abs(int(ca[ch]>>8) - int(cb[ch]>>8)); d > e {
+					e = d
+				}
+			}
+		}
+	}
+	return e
+}
+
+var (
+	rgb  = []int{chR, chG, chB}
+	rgba = []int{chR, chG, chB, chA}
+)
+
+type compressTest struct {
+	InternalFormat glt.Enum
+	Img            image.Image
+	Channels       []int // compared
+	MaxError       int
+}
+
+var allTestsCompress = []compressTest{
+	{glCOMPRESSED_RGB_S3TC_DXT1_EXT, uniformImage(5, 3, color.NRGBA{10, 200, 30, 255}), rgb, 4},
+	{glCOMPRESSED_RGB_S3TC_DXT1_EXT, gradient(10, 7), rgb, 16},
+	{glCOMPRESSED_RGBA_S3TC_DXT1_EXT, testTransparent(), []int{chA}, 0},
+	{glCOMPRESSED_RGBA_S3TC_DXT3_EXT, gradient(10, 7), rgba, 16},
+	{glCOMPRESSED_RGBA_S3TC_DXT5_EXT, gradient(10, 7), rgba, 16},
+	{glCOMPRESSED_RED_RGTC1, gradient(10, 7), []int{chR}, 8},
+	{glCOMPRESSED_SIGNED_RED_RGTC1, gradient(10, 7), []int{chR}, 8},
+	{glCOMPRESSED_RG_RGTC2, gradient(10, 7), []int{chR, chG}, 8},
+	{glCOMPRESSED_SIGNED_RG_RGTC2, gradient(10, 7), []int{chR, chG}, 8},
+	{glETC1_RGB8_OES, uniformImage(5, 3, color.NRGBA{10, 200, 30, 255}), rgb, 8},
+	// ETC modifies all channels alike, red and green go opposite ways
+	{glETC1_RGB8_OES, gradient(10, 7), rgb, 48},
+	{glCOMPRESSED_SRGB8_ETC2, gradient(10, 7), rgb, 48},
+	{glCOMPRESSED_RGBA8_ETC2_EAC, gradient(10, 7), rgba, 48},
+	{glCOMPRESSED_R11_EAC, gradient(10, 7), []int{chR}, 8},
+	{glCOMPRESSED_SIGNED_R11_EAC, gradient(10, 7), []int{chR}, 8},
+	{glCOMPRESSED_RG11_EAC, gradient(10, 7), []int{chR, chG}, 8},
+	{glCOMPRESSED_SIGNED_RG11_EAC, gradient(10, 7), []int{chR, chG}, 8},
+	{glCOMPRESSED_RGBA_ASTC_4x4_KHR, uniformImage(5, 3, color.NRGBA{10, 200, 30, 128}), rgba, 0},
+	{glCOMPRESSED_RGBA_ASTC_4x4_KHR, gradient(10, 7), rgba, 16},
+	{glCOMPRESSED_RGBA_ASTC_4x4_KHR + 5, gradient(10, 7), rgba, 32},           // 8x5
+	{glCOMPRESSED_SRGB8_ALPHA8_ASTC_4x4_KHR + 13, gradient(13, 25), rgba, 32}, // 12x12
+}
+
+func TestCompress(t *testing.T) {
+	for i, test := range allTestsCompress {
+		data, err := EncodeCompressed(test.Img, test.InternalFormat)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		b := test.Img.Bounds()
+		info := compressedFormats[test.InternalFormat]
+		if size := info.imageSize(b.Dx(), b.Dy(), 1); len(data) != size {
+			t.Errorf("%d: %d bytes, want %d", i, len(data), size)
+		}
+		img, err := DecodeCompressed(test.InternalFormat, data, b.Dx(), b.Dy())
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if img.Bounds() != b {
+			t.Errorf("%d: bounds %v, want %v", i, img.Bounds(), b)
+		}
+		if e := maxError(img, test.Img, test.Channels); e > test.MaxError {
+			t.Errorf("%d: error %d, want at most %d", i, e, test.MaxError)
+		}
+	}
+}
+
+func le64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, v)
+	return b
+}
+
+func be64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+type decodeTest struct {
+	InternalFormat glt.Enum
+	Block          []byte
+	X, Y           int
+	Want           color.NRGBA
+}
+
+var allTestsDecode = []decodeTest{
+	// red and blue endpoints, all indices 2
+	{glCOMPRESSED_RGB_S3TC_DXT1_EXT, le64(0xaaaaaaaa<<32 | 0x001f<<16 | 0xf800), 1, 2, color.NRGBA{0xaa, 0, 0x55, 0xff}},
+	// 3 color mode, index 3
+	{glCOMPRESSED_RGB_S3TC_DXT1_EXT, le64(0xffffffff<<32 | 0xf800<<16 | 0x001f), 0, 0, color.NRGBA{0, 0, 0, 0xff}},
+	{glCOMPRESSED_RGBA_S3TC_DXT1_EXT, le64(0xffffffff<<32 | 0xf800<<16 | 0x001f), 0, 0, color.NRGBA{}},
+	// alpha endpoints 0 and 255, 6 value mode, index 7 at texel 1
+	{glCOMPRESSED_RED_RGTC1, le64(7<<19 | 0xff<<8), 1, 0, color.NRGBA{0xff, 0xff, 0xff, 0xff}},
+	{glCOMPRESSED_SIGNED_RED_RGTC1, le64(0x81), 0, 0, color.NRGBA{0, 0, 0, 0xff}},
+	// individual mode, R1 15, G2 15, table 1, texel 1, 2 in subblock 0
+	// has index 1
+	{glETC1_RGB8_OES, be64(0xf00f0000<<32 | 1<<37 | 1<<(4+2)), 1, 2, color.NRGBA{0xff, 0x11, 0x11, 0xff}},
+	// differential mode, red 16
+	{glETC1_RGB8_OES, be64(0x84<<56 | 1<<33), 0, 0, color.NRGBA{0x86, 0x02, 0x02, 0xff}},
+	// red 0 - 4 overflows, T mode with blue as first color
+	{glCOMPRESSED_RGB8_ETC2, be64(0x04<<56 | 0xf<<48 | 1<<33), 0, 0, color.NRGBA{0, 0, 0xff, 0xff}},
+	// blue 0 - 4 overflows, planar mode with all colors 0 but origin red
+	{glCOMPRESSED_RGB8_ETC2, be64(0x7e<<56 | 1<<42 | 1<<33), 1, 1, color.NRGBA{0x80, 0, 0, 0xff}},
+	// not opaque, index 2 is transparent
+	{glCOMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2, be64(1 << 16), 0, 0, color.NRGBA{}},
+	{glCOMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2, be64(1 << 33), 0, 0, color.NRGBA{0x02, 0x02, 0x02, 0xff}},
+	// alpha base 100, multiplier 2, table 0, index 0
+	{glCOMPRESSED_RGBA8_ETC2_EAC, append(be64(100<<56|2<<52), be64(0)...), 0, 0, color.NRGBA{0x02, 0x02, 0x02, 0x5e}},
+	// ASTC void extent
+	{glCOMPRESSED_RGBA_ASTC_4x4_KHR, append(le64(0xfffffffffffffdfc), le64(0x8000ffff12340000)...), 3, 3, color.NRGBA{0, 0x12, 0xff, 0x80}},
+	// ASTC reserved block mode
+	{glCOMPRESSED_RGBA_ASTC_4x4_KHR + 13, make([]byte, 16), 11, 11, color.NRGBA{0xff, 0, 0xff, 0xff}},
+}
+
+func TestDecodeCompressed(t *testing.T) {
+	for i, test := range allTestsDecode {
+		info := compressedFormats[test.InternalFormat]
+		img, err := DecodeCompressed(test.InternalFormat, test.Block, info.BlockWidth, info.BlockHeight)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if c := color.NRGBAModel.Convert(img.At(test.X, test.Y)); c != test.Want {
+			t.Errorf("%d: %v, want %v", i, c, test.Want)
+		}
+	}
+	if _, err := DecodeCompressed(glCOMPRESSED_RGBA_S3TC_DXT5_EXT, make([]byte, 16), 5, 4); err == nil {
+		t.Errorf("short data decoded")
+	}
+	if _, err := EncodeCompressed(gradient(4, 4), glCOMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2); err != ErrUnsupported {
+		t.Errorf("got %v, want ErrUnsupported", err)
+	}
+}
+
+func TestASTCTables(t *testing.T) {
+	trits := make(map[[5]int]bool)
+	for _, ts := range astcTrits {
+		trits[ts] = true
+	}
+	quints := make(map[[3]int]bool)
+	for _, qs := range astcQuints {
+		quints[qs] = true
+	}
+	if len(trits) != 243 || len(quints) != 125 {
+		t.Errorf("%d trit and %d quint blocks, want 243 and 125", len(trits), len(quints))
+	}
+	for i, r := range astcRanges {
+		colors, weights := make(map[int]bool), make(map[int]bool)
+		for v := 0; v < r.levels; v++ {
+			colors[unquantizeColor(v, r)] = true
+			if i <= 11 {
+				weights[unquantizeWeight(v, r)] = true
+			}
+		}
+		if r.bits > 0 && (len(colors) != r.levels || !colors[0] || !colors[255]) {
+			t.Errorf("range %d: color values %v", r.levels, colors)
+		}
+		if i <= 11 && (len(weights) != r.levels || !weights[0] || !weights[64]) {
+			t.Errorf("range %d: weights %v", r.levels, weights)
+		}
+	}
+	for _, n := range []int{2, 3, 4} {
+		used := make(map[int]bool)
+		for seed := 0; seed < 1024; seed++ {
+			for y := 0; y < 8; y++ {
+				for x := 0; x < 8; x++ {
+					used[astcPartition(seed, x, y, n, false)] = true
+				}
+			}
+		}
+		if len(used) != n {
+			t.Errorf("%d partitions: %v used", n, used)
+		}
+	}
+}
+
+func astcBlock(b astcBits) []byte {
+	return append(le64(b.lo), le64(b.hi)...)
+}
+
+func TestASTCBlocks(t *testing.T) {
+	// 2 partitions of luminance endpoints, black and white
+	var b astcBits
+	b.set(0, 11, astcEncodeMode)
+	b.set(11, 2, 1)
+	b.set(13, 10, 17)
+	b.set(29, 8, 0)
+	b.set(37, 8, 0)
+	b.set(45, 8, 255)
+	b.set(53, 8, 255)
+	img, err := DecodeCompressed(glCOMPRESSED_RGBA_ASTC_4x4_KHR, astcBlock(b), 4, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	used := make(map[int]bool)
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			p := astcPartition(17, x, y, 2, true)
+			used[p] = true
+			want := color.NRGBA{0, 0, 0, 0xff}
+			if p == 1 {
+				want = color.NRGBA{0xff, 0xff, 0xff, 0xff}
+			}
+			if c := img.At(x, y); c != want {
+				t.Errorf("partition %d at %d, %d: %v, want %v", p, x, y, c, want)
+			}
+		}
+	}
+	if len(used) != 2 {
+		t.Errorf("partitions %v used", used)
+	}
+
+	// dual plane luminance and alpha with alpha in plane 2, all
+	// luminance weights 64 and all alpha weights 0
+	b = astcBits{}
+	b.set(0, 11, astcEncodeMode|0x400)
+	b.set(13, 4, 4)
+	b.set(17, 32, 0xff00ff00)
+	b.set(62, 2, 3)
+	for i := 0; i < 32; i += 2 {
+		b.set(126-2*i, 2, 3)
+	}
+	img, err = DecodeCompressed(glCOMPRESSED_RGBA_ASTC_4x4_KHR, astcBlock(b), 4, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, want := img.At(1, 2), (color.NRGBA{0xff, 0xff, 0xff, 0}); c != want {
+		t.Errorf("dual plane: %v, want %v", c, want)
+	}
+}
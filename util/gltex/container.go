@@ -0,0 +1,221 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package gltex
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/chsc/gogl2/glt"
+)
+
+// Texture is a texture loaded from a KTX, KTX2 or DDS file. The level
+// data is ready to be passed to TexImage*D or, if the texture is
+// compressed, CompressedTexImage*D:
+//
+//	for i, l := range tex.Levels {
+//		gl.CompressedTexImage2D(tex.Target, int32(i), tex.InternalFormat,
+//			int32(l.Width), int32(l.Height), 0, int32(len(l.Data)), glt.Ptr(l.Data))
+//	}
+//
+// For cube maps that are not arrays every face is uploaded on its own
+// with the target TEXTURE_CUBE_MAP_POSITIVE_X+face and l.Image(face).
+type Texture struct {
+	Target         glt.Enum
+	InternalFormat glt.Enum
+	Format, Type_  glt.Enum   // 0 for compressed textures
+	Store          PixelStore // unpack parameters of uncompressed level data
+	Width, Height  int
+	Depth          int // 1 unless Target is TEXTURE_3D
+	Layers         int // array layers, 0 unless Target is an array target
+	Faces          int // 6 for cube maps, 1 otherwise
+
+	// TopDown is set if the first row of an image is the top row of the
+	// picture, as in DDS files and KTX files with the usual orientation.
+	// Flip the t texture coordinate of such textures.
+	TopDown bool
+
+	// GenerateMipmaps is set for KTX files that request mipmaps to be
+	// generated at load time. Levels holds the base level only.
+	GenerateMipmaps bool
+
+	Metadata map[string]string // key/value data of KTX files
+	Levels   []Level
+}
+
+// Level is a mipmap level of a texture.
+type Level struct {
+	Width, Height, Depth int
+
+	// Data holds the images of all layers and faces in that order, as
+	// expected by TexImage3D for array textures.
+	Data      []byte
+	ImageSize int // bytes per image
+}
+
+// Compressed reports whether the texture has a compressed format.
+func (t *Texture) Compressed() bool {
+	return t.Format == 0
+}
+
+// Image returns the data of face face of layer layer, where i is
+// layer*Faces + face.
+func (l *Level) Image(i int) []byte {
+	return l.Data[i*l.ImageSize : (i+1)*l.ImageSize]
+}
+
+// formatInfo describes a texture format: the enums to pass to GL and the
+// size of a block of pixels. Uncompressed formats have 1x1 blocks of one
+// pixel and a Format.
+type formatInfo struct {
+	InternalFormat glt.Enum
+	Format, Type_  glt.Enum
+	BlockWidth     int
+	BlockHeight    int
+	BlockSize      int // bytes
+}
+
+func uncompressed(internalFormat, format, type_ glt.Enum, size int) formatInfo {
+	return formatInfo{internalFormat, format, type_, 1, 1, size}
+}
+
+func compressed(internalFormat glt.Enum, blockWidth, blockHeight, blockSize int) formatInfo {
+	return formatInfo{internalFormat, 0, 0, blockWidth, blockHeight, blockSize}
+}
+
+// compressedFormats maps compressed internal formats to their block size.
+var compressedFormats = makeCompressedFormats()
+
+// astcBlocks lists the block sizes of the ASTC formats in enum order.
+var astcBlocks = [][2]int{
+	{4, 4}, {5, 4}, {5, 5}, {6, 5}, {6, 6}, {8, 5}, {8, 6}, {8, 8},
+	{10, 5}, {10, 6}, {10, 8}, {10, 10}, {12, 10}, {12, 12},
+}
+
+func makeCompressedFormats() map[glt.Enum]formatInfo {
+	formats := make(map[glt.Enum]formatInfo)
+	for _, e := range []glt.Enum{
+		glCOMPRESSED_RGB_S3TC_DXT1_EXT, glCOMPRESSED_RGBA_S3TC_DXT1_EXT,
+		glCOMPRESSED_SRGB_S3TC_DXT1_EXT, glCOMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT,
+		glCOMPRESSED_RED_RGTC1, glCOMPRESSED_SIGNED_RED_RGTC1,
+		glETC1_RGB8_OES, glCOMPRESSED_RGB8_ETC2, glCOMPRESSED_SRGB8_ETC2,
+		glCOMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2, glCOMPRESSED_SRGB8_PUNCHTHROUGH_ALPHA1_ETC2,
+		glCOMPRESSED_R11_EAC, glCOMPRESSED_SIGNED_R11_EAC,
+	} {
+		formats[e] = compressed(e, 4, 4, 8)
+	}
+	for _, e := range []glt.Enum{
+		glCOMPRESSED_RGBA_S3TC_DXT3_EXT, glCOMPRESSED_RGBA_S3TC_DXT5_EXT,
+		glCOMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT, glCOMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT,
+		glCOMPRESSED_RG_RGTC2, glCOMPRESSED_SIGNED_RG_RGTC2,
+		glCOMPRESSED_RGBA_BPTC_UNORM, glCOMPRESSED_SRGB_ALPHA_BPTC_UNORM,
+		glCOMPRESSED_RGB_BPTC_SIGNED_FLOAT, glCOMPRESSED_RGB_BPTC_UNSIGNED_FLOAT,
+		glCOMPRESSED_RGBA8_ETC2_EAC, glCOMPRESSED_SRGB8_ALPHA8_ETC2_EAC,
+		glCOMPRESSED_RG11_EAC, glCOMPRESSED_SIGNED_RG11_EAC,
+	} {
+		formats[e] = compressed(e, 4, 4, 16)
+	}
+	for i, b := range astcBlocks {
+		e := glCOMPRESSED_RGBA_ASTC_4x4_KHR + glt.Enum(i)
+		formats[e] = compressed(e, b[0], b[1], 16)
+		e = glCOMPRESSED_SRGB8_ALPHA8_ASTC_4x4_KHR + glt.Enum(i)
+		formats[e] = compressed(e, b[0], b[1], 16)
+	}
+	return formats
+}
+
+// imageSize returns the size in bytes of a tightly packed image of f.
+func (f *formatInfo) imageSize(width, height, depth int) int {
+	bw := (width + f.BlockWidth - 1) / f.BlockWidth
+	bh := (height + f.BlockHeight - 1) / f.BlockHeight
+	return bw * bh * depth * f.BlockSize
+}
+
+// mipSize returns the size of mipmap level level of a dimension.
+func mipSize(size, level int) int {
+	size >>= uint(level)
+	if size < 1 {
+		return 1
+	}
+	return size
+}
+
+// textureTarget returns the target of a texture of the given dimensions.
+// height is 0 for 1D and depth 0 for 1D and 2D textures.
+func textureTarget(height, depth, layers, faces int) (glt.Enum, error) {
+	switch {
+	case faces != 1 && faces != 6:
+		return 0, fmt.Errorf("gltex: %d faces", faces)
+	case faces == 6 && (height == 0 || depth > 0):
+		return 0, fmt.Errorf("gltex: cube map must be 2D")
+	case depth > 0 && layers > 0:
+		return 0, fmt.Errorf("gltex: arrays of 3D textures are not supported")
+	case depth > 0:
+		return glTEXTURE_3D, nil
+	case faces == 6 && layers > 0:
+		return glTEXTURE_CUBE_MAP_ARRAY, nil
+	case faces == 6:
+		return glTEXTURE_CUBE_MAP, nil
+	case height == 0 && layers > 0:
+		return glTEXTURE_1D_ARRAY, nil
+	case height == 0:
+		return glTEXTURE_1D, nil
+	case layers > 0:
+		return glTEXTURE_2D_ARRAY, nil
+	}
+	return glTEXTURE_2D, nil
+}
+
+// images returns the number of images per level.
+func (t *Texture) images() int {
+	if t.Layers > 0 {
+		return t.Layers * t.Faces
+	}
+	return t.Faces
+}
+
+// setSize sets target and size of t from the dimensions in a file
+// header, where 0 marks unused dimensions.
+func (t *Texture) setSize(width, height, depth, layers, faces int) error {
+	if width <= 0 || height < 0 || depth < 0 || layers < 0 {
+		return fmt.Errorf("gltex: invalid size %dx%dx%d", width, height, depth)
+	}
+	target, err := textureTarget(height, depth, layers, faces)
+	if err != nil {
+		return err
+	}
+	t.Target, t.Width, t.Height, t.Depth, t.Layers, t.Faces = target, width, height, depth, layers, faces
+	if t.Height == 0 {
+		t.Height = 1
+	}
+	if t.Depth == 0 {
+		t.Depth = 1
+	}
+	return nil
+}
+
+// level returns the size of mipmap level i of t.
+func (t *Texture) level(i int) Level {
+	return Level{Width: mipSize(t.Width, i), Height: mipSize(t.Height, i), Depth: mipSize(t.Depth, i)}
+}
+
+// ReadTexture reads a KTX, KTX2 or DDS file.
+func ReadTexture(r io.Reader) (*Texture, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(data, ktx1Identifier):
+		return ParseKTX(data)
+	case bytes.HasPrefix(data, ktx2Identifier):
+		return ParseKTX2(data)
+	case bytes.HasPrefix(data, ddsMagic):
+		return ParseDDS(data)
+	}
+	return nil, fmt.Errorf("gltex: unknown texture file format")
+}
@@ -0,0 +1,236 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package gltex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+var ddsMagic = []byte("DDS ")
+
+const (
+	ddsHeaderSize      = 4 + 124
+	ddsDX10HeaderSize  = 20
+	ddsdMipMapCount    = 0x20000
+	ddspfAlphaPixels   = 0x1
+	ddspfAlpha         = 0x2
+	ddspfFourCC        = 0x4
+	ddspfRGB           = 0x40
+	ddspfLuminance     = 0x20000
+	ddsCaps2Cubemap    = 0x200
+	ddsCaps2AllFaces   = 0xFC00
+	ddsCaps2Volume     = 0x200000
+	ddsMiscTextureCube = 0x4
+
+	d3d10ResourceDimensionTexture1D = 2
+	d3d10ResourceDimensionTexture3D = 4
+)
+
+// ddsFourCCs maps the four character codes of legacy DDS files to GL
+// formats. D3DFMT values of float formats are used as codes as well.
+var ddsFourCCs = map[string]formatInfo{
+	"DXT1": compressedFormats[glCOMPRESSED_RGBA_S3TC_DXT1_EXT],
+	"DXT2": compressedFormats[glCOMPRESSED_RGBA_S3TC_DXT3_EXT],
+	"DXT3": compressedFormats[glCOMPRESSED_RGBA_S3TC_DXT3_EXT],
+	"DXT4": compressedFormats[glCOMPRESSED_RGBA_S3TC_DXT5_EXT],
+	"DXT5": compressedFormats[glCOMPRESSED_RGBA_S3TC_DXT5_EXT],
+	"ATI1": compressedFormats[glCOMPRESSED_RED_RGTC1],
+	"BC4U": compressedFormats[glCOMPRESSED_RED_RGTC1],
+	"BC4S": compressedFormats[glCOMPRESSED_SIGNED_RED_RGTC1],
+	"ATI2": compressedFormats[glCOMPRESSED_RG_RGTC2],
+	"BC5U": compressedFormats[glCOMPRESSED_RG_RGTC2],
+	"BC5S": compressedFormats[glCOMPRESSED_SIGNED_RG_RGTC2],
+	"ETC1": compressedFormats[glETC1_RGB8_OES],
+
+	"\x24\x00\x00\x00": uncompressed(glRGBA16, glRGBA, glUNSIGNED_SHORT, 8),
+	"\x6f\x00\x00\x00": uncompressed(glR16F, glRED, glHALF_FLOAT, 2),
+	"\x70\x00\x00\x00": uncompressed(glRG16F, glRG, glHALF_FLOAT, 4),
+	"\x71\x00\x00\x00": uncompressed(glRGBA16F, glRGBA, glHALF_FLOAT, 8),
+	"\x72\x00\x00\x00": uncompressed(glR32F, glRED, glFLOAT, 4),
+	"\x73\x00\x00\x00": uncompressed(glRG32F, glRG, glFLOAT, 8),
+	"\x74\x00\x00\x00": uncompressed(glRGBA32F, glRGBA, glFLOAT, 16),
+}
+
+// ddsMasks describes an uncompressed legacy DDS pixel format by its
+// flags, bits per pixel and component masks.
+type ddsMasks struct {
+	Flags      uint32 // ddspfRGB, ddspfLuminance or ddspfAlpha
+	Bits       uint32
+	R, G, B, A uint32
+}
+
+var allDDSMasks = []struct {
+	Masks ddsMasks
+	Info  formatInfo
+}{
+	{ddsMasks{ddspfRGB, 32, 0xff, 0xff00, 0xff0000, 0xff000000}, uncompressed(glRGBA8, glRGBA, glUNSIGNED_BYTE, 4)},
+	{ddsMasks{ddspfRGB, 32, 0xff0000, 0xff00, 0xff, 0xff000000}, uncompressed(glRGBA8, glBGRA, glUNSIGNED_BYTE, 4)},
+	{ddsMasks{ddspfRGB, 32, 0xff, 0xff00, 0xff0000, 0}, uncompressed(glRGB8, glRGBA, glUNSIGNED_BYTE, 4)},
+	{ddsMasks{ddspfRGB, 32, 0xff0000, 0xff00, 0xff, 0}, uncompressed(glRGB8, glBGRA, glUNSIGNED_BYTE, 4)},
+	{ddsMasks{ddspfRGB, 24, 0xff, 0xff00, 0xff0000, 0}, uncompressed(glRGB8, glRGB, glUNSIGNED_BYTE, 3)},
+	{ddsMasks{ddspfRGB, 24, 0xff0000, 0xff00, 0xff, 0}, uncompressed(glRGB8, glBGR, glUNSIGNED_BYTE, 3)},
+	{ddsMasks{ddspfRGB, 32, 0x3ff, 0xffc00, 0x3ff00000, 0xc0000000}, uncompressed(glRGB10_A2, glRGBA, glUNSIGNED_INT_2_10_10_10_REV, 4)},
+	{ddsMasks{ddspfRGB, 16, 0xf800, 0x7e0, 0x1f, 0}, uncompressed(glRGB565, glRGB, glUNSIGNED_SHORT_5_6_5, 2)},
+	{ddsMasks{ddspfRGB, 16, 0x7c00, 0x3e0, 0x1f, 0x8000}, uncompressed(glRGB5_A1, glBGRA, glUNSIGNED_SHORT_1_5_5_5_REV, 2)},
+	{ddsMasks{ddspfRGB, 16, 0xf00, 0xf0, 0xf, 0xf000}, uncompressed(glRGBA4, glBGRA, glUNSIGNED_SHORT_4_4_4_4_REV, 2)},
+	{ddsMasks{ddspfLuminance, 8, 0xff, 0, 0, 0}, uncompressed(glLUMINANCE8, glLUMINANCE, glUNSIGNED_BYTE, 1)},
+	{ddsMasks{ddspfLuminance, 16, 0xffff, 0, 0, 0}, uncompressed(glLUMINANCE16, glLUMINANCE, glUNSIGNED_SHORT, 2)},
+	{ddsMasks{ddspfLuminance, 16, 0xff, 0, 0, 0xff00}, uncompressed(glLUMINANCE8_ALPHA8, glLUMINANCE_ALPHA, glUNSIGNED_BYTE, 2)},
+	{ddsMasks{ddspfAlpha, 8, 0, 0, 0, 0xff}, uncompressed(glALPHA8, glALPHA, glUNSIGNED_BYTE, 1)},
+}
+
+// dxgiFormats maps the DXGI_FORMAT values of DX10 headers to GL formats.
+var dxgiFormats = map[uint32]formatInfo{
+	2:  uncompressed(glRGBA32F, glRGBA, glFLOAT, 16),
+	3:  uncompressed(glRGBA32UI, glRGBA_INTEGER, glUNSIGNED_INT, 16),
+	6:  uncompressed(glRGB32F, glRGB, glFLOAT, 12),
+	10: uncompressed(glRGBA16F, glRGBA, glHALF_FLOAT, 8),
+	11: uncompressed(glRGBA16, glRGBA, glUNSIGNED_SHORT, 8),
+	16: uncompressed(glRG32F, glRG, glFLOAT, 8),
+	24: uncompressed(glRGB10_A2, glRGBA, glUNSIGNED_INT_2_10_10_10_REV, 4),
+	26: uncompressed(glR11F_G11F_B10F, glRGB, glUNSIGNED_INT_10F_11F_11F_REV, 4),
+	28: uncompressed(glRGBA8, glRGBA, glUNSIGNED_BYTE, 4),
+	29: uncompressed(glSRGB8_ALPHA8, glRGBA, glUNSIGNED_BYTE, 4),
+	30: uncompressed(glRGBA8UI, glRGBA_INTEGER, glUNSIGNED_BYTE, 4),
+	34: uncompressed(glRG16F, glRG, glHALF_FLOAT, 4),
+	35: uncompressed(glRG16, glRG, glUNSIGNED_SHORT, 4),
+	41: uncompressed(glR32F, glRED, glFLOAT, 4),
+	42: uncompressed(glR32UI, glRED_INTEGER, glUNSIGNED_INT, 4),
+	49: uncompressed(glRG8, glRG, glUNSIGNED_BYTE, 2),
+	54: uncompressed(glR16F, glRED, glHALF_FLOAT, 2),
+	56: uncompressed(glR16, glRED, glUNSIGNED_SHORT, 2),
+	61: uncompressed(glR8, glRED, glUNSIGNED_BYTE, 1),
+	67: uncompressed(glRGB9_E5, glRGB, glUNSIGNED_INT_5_9_9_9_REV, 4),
+	71: compressedFormats[glCOMPRESSED_RGBA_S3TC_DXT1_EXT],
+	72: compressedFormats[glCOMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT],
+	74: compressedFormats[glCOMPRESSED_RGBA_S3TC_DXT3_EXT],
+	75: compressedFormats[glCOMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT],
+	77: compressedFormats[glCOMPRESSED_RGBA_S3TC_DXT5_EXT],
+	78: compressedFormats[glCOMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT],
+	80: compressedFormats[glCOMPRESSED_RED_RGTC1],
+	81: compressedFormats[glCOMPRESSED_SIGNED_RED_RGTC1],
+	83: compressedFormats[glCOMPRESSED_RG_RGTC2],
+	84: compressedFormats[glCOMPRESSED_SIGNED_RG_RGTC2],
+	85: uncompressed(glRGB565, glRGB, glUNSIGNED_SHORT_5_6_5, 2),
+	86: uncompressed(glRGB5_A1, glBGRA, glUNSIGNED_SHORT_1_5_5_5_REV, 2),
+	87: uncompressed(glRGBA8, glBGRA, glUNSIGNED_BYTE, 4),
+	91: uncompressed(glSRGB8_ALPHA8, glBGRA, glUNSIGNED_BYTE, 4),
+	95: compressedFormats[glCOMPRESSED_RGB_BPTC_UNSIGNED_FLOAT],
+	96: compressedFormats[glCOMPRESSED_RGB_BPTC_SIGNED_FLOAT],
+	98: compressedFormats[glCOMPRESSED_RGBA_BPTC_UNORM],
+	99: compressedFormats[glCOMPRESSED_SRGB_ALPHA_BPTC_UNORM],
+}
+
+// ddsPixelFormat returns the format of the DDS_PIXELFORMAT structure p.
+func ddsPixelFormat(p []byte) (formatInfo, error) {
+	le := binary.LittleEndian
+	flags, fourCC := le.Uint32(p[4:]), p[8:12]
+	if flags&ddspfFourCC != 0 {
+		if f, ok := ddsFourCCs[string(fourCC)]; ok {
+			return f, nil
+		}
+		return formatInfo{}, fmt.Errorf("gltex: unsupported DDS format %q", fourCC)
+	}
+	m := ddsMasks{flags & (ddspfRGB | ddspfLuminance | ddspfAlpha), le.Uint32(p[12:]), le.Uint32(p[16:]), le.Uint32(p[20:]), le.Uint32(p[24:]), le.Uint32(p[28:])}
+	if flags&ddspfAlphaPixels == 0 {
+		m.A = 0
+	}
+	for _, dm := range allDDSMasks {
+		if dm.Masks == m {
+			return dm.Info, nil
+		}
+	}
+	return formatInfo{}, fmt.Errorf("gltex: unsupported DDS pixel format %+v", m)
+}
+
+// ParseDDS parses a DDS file with an optional DX10 header. Uncompressed
+// level data is tightly packed as described by t.Store. The images of a
+// DDS file start with the top row, so TopDown is always set.
+func ParseDDS(data []byte) (*Texture, error) {
+	if len(data) < ddsHeaderSize || !bytes.HasPrefix(data, ddsMagic) {
+		return nil, fmt.Errorf("gltex: not a DDS file")
+	}
+	le := binary.LittleEndian
+	field := func(offset int) int {
+		return int(le.Uint32(data[offset:]))
+	}
+	if field(4) != 124 {
+		return nil, fmt.Errorf("gltex: invalid DDS header size %d", field(4))
+	}
+	flags, height, width, depth, levels := field(8), field(12), field(16), field(24), field(28)
+	caps2 := field(112)
+	if flags&ddsdMipMapCount == 0 || levels == 0 {
+		levels = 1
+	}
+	if levels > maxLevels {
+		return nil, fmt.Errorf("gltex: %d mipmap levels", levels)
+	}
+	if caps2&ddsCaps2Volume == 0 {
+		depth = 0
+	}
+	faces, layers := 1, 0
+	if caps2&ddsCaps2Cubemap != 0 {
+		if caps2&ddsCaps2AllFaces != ddsCaps2AllFaces {
+			return nil, fmt.Errorf("gltex: DDS cube maps with missing faces are not supported")
+		}
+		faces = 6
+	}
+	offset := ddsHeaderSize
+	var f formatInfo
+	if string(data[84:88]) == "DX10" {
+		if len(data) < ddsHeaderSize+ddsDX10HeaderSize {
+			return nil, fmt.Errorf("gltex: truncated DDS DX10 header")
+		}
+		offset += ddsDX10HeaderSize
+		dxgiFormat, dimension, misc, arraySize := le.Uint32(data[128:]), field(132), field(136), field(140)
+		var ok bool
+		if f, ok = dxgiFormats[dxgiFormat]; !ok {
+			return nil, fmt.Errorf("gltex: unsupported DDS DXGI format %d", dxgiFormat)
+		}
+		switch dimension {
+		case d3d10ResourceDimensionTexture1D:
+			height = 0
+		case d3d10ResourceDimensionTexture3D:
+		default:
+			depth = 0
+		}
+		if misc&ddsMiscTextureCube != 0 {
+			faces = 6
+		}
+		if arraySize > 1 {
+			layers = arraySize
+		}
+	} else {
+		var err error
+		if f, err = ddsPixelFormat(data[76:108]); err != nil {
+			return nil, err
+		}
+	}
+	t := &Texture{InternalFormat: f.InternalFormat, Format: f.Format, Type_: f.Type_, Store: TightlyPacked, TopDown: true}
+	if err := t.setSize(width, height, depth, layers, faces); err != nil {
+		return nil, err
+	}
+	for i := 0; i < levels; i++ {
+		l := t.level(i)
+		l.ImageSize = f.imageSize(l.Width, l.Height, l.Depth)
+		l.Data = make([]byte, 0, l.ImageSize*t.images())
+		t.Levels = append(t.Levels, l)
+	}
+	// DDS files store all levels of an image, then the next image
+	for i := 0; i < t.images(); i++ {
+		for j := range t.Levels {
+			l := &t.Levels[j]
+			img, err := checkedSlice(data, uint64(offset), uint64(l.ImageSize), fmt.Sprintf("image %d level %d", i, j))
+			if err != nil {
+				return nil, err
+			}
+			l.Data = append(l.Data, img...)
+			offset += l.ImageSize
+		}
+	}
+	return t, nil
+}
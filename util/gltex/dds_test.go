@@ -0,0 +1,102 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package gltex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// ddsFile describes a DDS file for makeDDS. DXGIFormat is written to a
+// DX10 header if it is not 0.
+type ddsFile struct {
+	Width, Height, Depth, Levels uint32
+	Caps2                        uint32
+	PixelFormat                  ddsMasks
+	FourCC                       string
+	DXGIFormat                   uint32
+	Dimension, Misc, ArraySize   uint32
+	Data                         []byte
+}
+
+func makeDDS(f ddsFile) []byte {
+	le := binary.LittleEndian
+	b := make([]byte, ddsHeaderSize)
+	copy(b, ddsMagic)
+	le.PutUint32(b[4:], 124)
+	le.PutUint32(b[8:], ddsdMipMapCount)
+	le.PutUint32(b[12:], f.Height)
+	le.PutUint32(b[16:], f.Width)
+	le.PutUint32(b[24:], f.Depth)
+	le.PutUint32(b[28:], f.Levels)
+	le.PutUint32(b[76:], 32)
+	if f.DXGIFormat != 0 {
+		f.FourCC = "DX10"
+	}
+	if f.FourCC != "" {
+		le.PutUint32(b[80:], ddspfFourCC)
+		copy(b[84:], f.FourCC)
+	} else {
+		flags := f.PixelFormat.Flags
+		if f.PixelFormat.A != 0 {
+			flags |= ddspfAlphaPixels
+		}
+		for i, v := range []uint32{flags, 0, f.PixelFormat.Bits, f.PixelFormat.R, f.PixelFormat.G, f.PixelFormat.B, f.PixelFormat.A} {
+			le.PutUint32(b[80+4*i:], v)
+		}
+	}
+	le.PutUint32(b[112:], f.Caps2)
+	if f.DXGIFormat != 0 {
+		dx10 := make([]byte, ddsDX10HeaderSize)
+		for i, v := range []uint32{f.DXGIFormat, f.Dimension, f.Misc, f.ArraySize} {
+			le.PutUint32(dx10[4*i:], v)
+		}
+		b = append(b, dx10...)
+	}
+	return append(b, f.Data...)
+}
+
+var allTestsDDS = []textureTest{
+	{makeDDS(ddsFile{Width: 8, Height: 8, Levels: 4, FourCC: "DXT1", Data: sequence(32 + 8 + 8 + 8)}),
+		glTEXTURE_2D, glCOMPRESSED_RGBA_S3TC_DXT1_EXT, 0, 0, [5]int{8, 8, 1, 0, 1}, []int{32, 8, 8, 8}, true, ""},
+	{makeDDS(ddsFile{Width: 4, Height: 4, Levels: 2, DXGIFormat: 98, Dimension: 3, ArraySize: 2, Data: sequence(2 * (16 + 16))}),
+		glTEXTURE_2D_ARRAY, glCOMPRESSED_RGBA_BPTC_UNORM, 0, 0, [5]int{4, 4, 1, 2, 1}, []int{16, 16}, true, ""},
+	{makeDDS(ddsFile{Width: 4, Height: 4, Levels: 1, DXGIFormat: 99, Dimension: 3, Misc: ddsMiscTextureCube, ArraySize: 1, Data: sequence(6 * 16)}),
+		glTEXTURE_CUBE_MAP, glCOMPRESSED_SRGB_ALPHA_BPTC_UNORM, 0, 0, [5]int{4, 4, 1, 0, 6}, []int{16}, true, ""},
+	{makeDDS(ddsFile{Width: 2, Height: 2, Levels: 1, Caps2: ddsCaps2Cubemap | ddsCaps2AllFaces,
+		PixelFormat: ddsMasks{ddspfRGB, 32, 0xff0000, 0xff00, 0xff, 0xff000000}, Data: sequence(6 * 16)}),
+		glTEXTURE_CUBE_MAP, glRGBA8, glBGRA, glUNSIGNED_BYTE, [5]int{2, 2, 1, 0, 6}, []int{16}, true, ""},
+	{makeDDS(ddsFile{Width: 3, Height: 3, Depth: 2, Levels: 2, Caps2: ddsCaps2Volume,
+		PixelFormat: ddsMasks{ddspfRGB, 24, 0xff0000, 0xff00, 0xff, 0}, Data: sequence(54 + 3)}),
+		glTEXTURE_3D, glRGB8, glBGR, glUNSIGNED_BYTE, [5]int{3, 3, 2, 0, 1}, []int{54, 3}, true, ""},
+	{makeDDS(ddsFile{Width: 5, Height: 1, Levels: 1, PixelFormat: ddsMasks{ddspfRGB, 16, 0xf800, 0x7e0, 0x1f, 0}, Data: sequence(10)}),
+		glTEXTURE_2D, glRGB565, glRGB, glUNSIGNED_SHORT_5_6_5, [5]int{5, 1, 1, 0, 1}, []int{10}, true, ""},
+	{makeDDS(ddsFile{Width: 4, Height: 4, Levels: 1, FourCC: "\x71\x00\x00\x00", Data: sequence(128)}),
+		glTEXTURE_2D, glRGBA16F, glRGBA, glHALF_FLOAT, [5]int{4, 4, 1, 0, 1}, []int{128}, true, ""},
+	{makeDDS(ddsFile{Width: 4, Height: 4, Levels: 1, FourCC: "PVR2", Data: sequence(16)}), 0, 0, 0, 0, [5]int{}, nil, false, "unsupported DDS format \"PVR2\""},
+	{makeDDS(ddsFile{Width: 4, Height: 4, Levels: 1, DXGIFormat: 1000, Data: sequence(16)}), 0, 0, 0, 0, [5]int{}, nil, false, "DXGI format 1000"},
+	{makeDDS(ddsFile{Width: 8, Height: 8, Levels: 2, FourCC: "DXT5", Data: sequence(64)}), 0, 0, 0, 0, [5]int{}, nil, false, "image 0 level 1"},
+	{makeDDS(ddsFile{Width: 2, Height: 2, Levels: 1, Caps2: ddsCaps2Cubemap | 0x400, FourCC: "DXT1", Data: sequence(8)}), 0, 0, 0, 0, [5]int{}, nil, false, "missing faces"},
+}
+
+func TestDDS(t *testing.T) {
+	testTextures(t, allTestsDDS)
+}
+
+func TestDDSLayout(t *testing.T) {
+	// DDS files store the levels of each layer together
+	tex, err := ParseDDS(allTestsDDS[1].File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := sequence(64)
+	if l := tex.Levels[0]; !bytes.Equal(l.Image(0), data[0:16]) || !bytes.Equal(l.Image(1), data[32:48]) {
+		t.Errorf("level 0 = %v", l.Data)
+	}
+	if l := tex.Levels[1]; !bytes.Equal(l.Image(0), data[16:32]) || !bytes.Equal(l.Image(1), data[48:64]) {
+		t.Errorf("level 1 = %v", l.Data)
+	}
+}
@@ -0,0 +1,477 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package gltex
+
+import (
+	"encoding/binary"
+)
+
+// Variants of the ETC color block.
+const (
+	etc1             = iota
+	etc2             // adds the T, H and planar modes
+	etc2Punchthrough // the differential bit is the opaque bit
+)
+
+// etcModifiers are the intensity modifiers of the 8 tables of ETC1, for
+// the pixel indices 0 and 1. Indices 2 and 3 negate them.
+var etcModifiers = [8][2]int{
+	{2, 8}, {5, 17}, {9, 29}, {13, 42}, {18, 60}, {24, 80}, {33, 106}, {47, 183},
+}
+
+// etcDistances are the distances of the T and H modes.
+var etcDistances = [8]int{3, 6, 11, 16, 23, 32, 41, 64}
+
+// eacModifiers are the modifiers of the 16 tables of EAC.
+var eacModifiers = [16][8]int{
+	{-3, -6, -9, -15, 2, 5, 8, 14},
+	{-3, -7, -10, -13, 2, 6, 9, 12},
+	{-2, -5, -8, -13, 1, 4, 7, 12},
+	{-2, -4, -6, -13, 1, 3, 5, 12},
+	{-3, -6, -8, -12, 2, 5, 7, 11},
+	{-3, -7, -9, -11, 2, 6, 8, 10},
+	{-4, -7, -8, -11, 3, 6, 7, 10},
+	{-3, -5, -8, -11, 2, 4, 7, 10},
+	{-2, -6, -8, -10, 1, 5, 7, 9},
+	{-2, -5, -8, -10, 1, 4, 7, 9},
+	{-2, -4, -8, -10, 1, 3, 7, 9},
+	{-2, -5, -7, -10, 1, 4, 6, 9},
+	{-3, -4, -7, -10, 2, 3, 6, 9},
+	{-1, -2, -3, -10, 0, 1, 2, 9},
+	{-4, -6, -8, -9, 3, 5, 7, 8},
+	{-3, -5, -7, -9, 2, 4, 6, 8},
+}
+
+// etcBits returns bits hi to lo of v.
+func etcBits(v uint64, hi, lo uint) int {
+	return int(v >> lo & (1<<(hi-lo+1) - 1))
+}
+
+// etcModifier returns the modifier of pixel index i in table t.
+func etcModifier(t, i int) int {
+	m := etcModifiers[t][i&1]
+	if i&2 != 0 {
+		return -m
+	}
+	return m
+}
+
+// Pixels of ETC and EAC blocks are numbered column by column, texels row
+// by row.
+func etcIndex(v uint64, p int) int {
+	return int(v>>uint(16+p)&1)<<1 | int(v>>uint(p)&1)
+}
+
+func decodeETC(block []byte, texels []channels, variant int) {
+	v := binary.BigEndian.Uint64(block)
+	diff, flip, opaque := v>>33&1 != 0, v>>32&1 != 0, true
+	if variant == etc2Punchthrough {
+		diff, opaque = true, diff
+	}
+	var base [2][4]int
+	if !diff {
+		for ch := uint(0); ch < 3; ch++ {
+			base[0][ch] = etcBits(v, 63-8*ch, 60-8*ch) * 17
+			base[1][ch] = etcBits(v, 59-8*ch, 56-8*ch) * 17
+		}
+	} else {
+		for ch := uint(0); ch < 3; ch++ {
+			b, d := etcBits(v, 63-8*ch, 59-8*ch), etcBits(v, 58-8*ch, 56-8*ch)
+			if d >= 4 {
+				d -= 8
+			}
+			if variant != etc1 && (b+d < 0 || b+d > 31) {
+				switch ch {
+				case 0:
+					decodeETCT(v, texels, opaque)
+				case 1:
+					decodeETCH(v, texels, opaque)
+				default:
+					decodeETCPlanar(v, texels)
+				}
+				return
+			}
+			base[0][ch], base[1][ch] = b<<3|b>>2, (b+d)<<3|(b+d)>>2
+		}
+	}
+	tables := [2]int{etcBits(v, 39, 37), etcBits(v, 36, 34)}
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			t := &texels[y*4+x]
+			i := etcIndex(v, x*4+y)
+			if !opaque && i == 2 {
+				*t = channels{}
+				continue
+			}
+			s := x >> 1
+			if flip {
+				s = y >> 1
+			}
+			m := etcModifier(tables[s], i)
+			if !opaque && i == 0 {
+				m = 0
+			}
+			b := base[s]
+			t.setRGBA(clamp255(b[0]+m), clamp255(b[1]+m), clamp255(b[2]+m), 255)
+		}
+	}
+}
+
+// setETCPaint sets the texels from the 4 paint colors of the T and H
+// modes.
+func setETCPaint(v uint64, texels []channels, paint *[4][3]int, opaque bool) {
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			t := &texels[y*4+x]
+			i := etcIndex(v, x*4+y)
+			if !opaque && i == 2 {
+				*t = channels{}
+				continue
+			}
+			c := paint[i]
+			t.setRGBA(clamp255(c[0]), clamp255(c[1]), clamp255(c[2]), 255)
+		}
+	}
+}
+
+func decodeETCT(v uint64, texels []channels, opaque bool) {
+	c1 := [3]int{etcBits(v, 60, 59)<<2 | etcBits(v, 57, 56), etcBits(v, 55, 52), etcBits(v, 51, 48)}
+	c2 := [3]int{etcBits(v, 47, 44), etcBits(v, 43, 40), etcBits(v, 39, 36)}
+	d := etcDistances[etcBits(v, 35, 34)<<1|etcBits(v, 32, 32)]
+	var paint [4][3]int
+	for ch := range c1 {
+		paint[0][ch] = c1[ch] * 17
+		paint[1][ch] = c2[ch]*17 + d
+		paint[2][ch] = c2[ch] * 17
+		paint[3][ch] = c2[ch]*17 - d
+	}
+	setETCPaint(v, texels, &paint, opaque)
+}
+
+func decodeETCH(v uint64, texels []channels, opaque bool) {
+	c1 := [3]int{etcBits(v, 62, 59), etcBits(v, 58, 56)<<1 | etcBits(v, 52, 52), etcBits(v, 51, 51)<<3 | etcBits(v, 49, 47)}
+	c2 := [3]int{etcBits(v, 46, 43), etcBits(v, 42, 39), etcBits(v, 38, 35)}
+	di := etcBits(v, 34, 34)<<2 | etcBits(v, 32, 32)<<1
+	if c1[0]<<8|c1[1]<<4|c1[2] >= c2[0]<<8|c2[1]<<4|c2[2] {
+		di |= 1
+	}
+	d := etcDistances[di]
+	var paint [4][3]int
+	for ch := range c1 {
+		paint[0][ch] = c1[ch]*17 + d
+		paint[1][ch] = c1[ch]*17 - d
+		paint[2][ch] = c2[ch]*17 + d
+		paint[3][ch] = c2[ch]*17 - d
+	}
+	setETCPaint(v, texels, &paint, opaque)
+}
+
+func decodeETCPlanar(v uint64, texels []channels) {
+	// origin, horizontal and vertical colors with 6, 7 and 6 bits
+	o := [3]int{
+		etcBits(v, 62, 57),
+		etcBits(v, 56, 56)<<6 | etcBits(v, 54, 49),
+		etcBits(v, 48, 48)<<5 | etcBits(v, 44, 43)<<3 | etcBits(v, 41, 39),
+	}
+	h := [3]int{etcBits(v, 38, 34)<<1 | etcBits(v, 32, 32), etcBits(v, 31, 25), etcBits(v, 24, 19)}
+	vc := [3]int{etcBits(v, 18, 13), etcBits(v, 12, 6), etcBits(v, 5, 0)}
+	for _, c := range []*[3]int{&o, &h, &vc} {
+		c[0], c[1], c[2] = c[0]<<2|c[0]>>4, c[1]<<1|c[1]>>6, c[2]<<2|c[2]>>4
+	}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			var c [3]int
+			for ch := range c {
+				c[ch] = clamp255((x*(h[ch]-o[ch]) + y*(vc[ch]-o[ch]) + 4*o[ch] + 2) >> 2)
+			}
+			texels[y*4+x].setRGBA(c[0], c[1], c[2], 255)
+		}
+	}
+}
+
+// etcSubblock returns the 8 texels of subblock s.
+func etcSubblock(texels []channels, flip bool, s int) (sub [8][4]int, pixels [8]int) {
+	n := 0
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			if (flip && y>>1 == s) || (!flip && x>>1 == s) {
+				sub[n], pixels[n] = texels[y*4+x].rgba8(), x*4+y
+				n++
+			}
+		}
+	}
+	return sub, pixels
+}
+
+// etcFitTable returns the table and pixel indices that encode sub best
+// with base color b, and the error.
+func etcFitTable(sub *[8][4]int, b [4]int) (table int, indices [8]int, err int) {
+	err = -1
+	for t := range etcModifiers {
+		var is [8]int
+		e := 0
+		for k, c := range sub {
+			best := -1
+			for i := 0; i < 4; i++ {
+				m := etcModifier(t, i)
+				d := sqDist(c, [4]int{clamp255(b[0] + m), clamp255(b[1] + m), clamp255(b[2] + m)}, 3)
+				if best < 0 || d < best {
+					best, is[k] = d, i
+				}
+			}
+			e += best
+		}
+		if err < 0 || e < err {
+			table, indices, err = t, is, e
+		}
+	}
+	return table, indices, err
+}
+
+// encodeETC1 encodes texels as an ETC1 block, which is also a valid ETC2
+// block. The base colors are the averages of the subblocks in the
+// differential mode if they are close enough, in the individual mode
+// otherwise, with the flip that gives the smaller error.
+func encodeETC1(texels []channels, block []byte) {
+	var best uint64
+	bestErr := -1
+	for f := 0; f < 2; f++ {
+		flip := f == 1
+		var subs [2][8][4]int
+		var pixels [2][8]int
+		var avg [2][3]int
+		for s := range subs {
+			subs[s], pixels[s] = etcSubblock(texels, flip, s)
+			for _, c := range subs[s] {
+				for ch := range avg[s] {
+					avg[s][ch] += c[ch]
+				}
+			}
+			for ch := range avg[s] {
+				avg[s][ch] = (avg[s][ch] + 4) / 8
+			}
+		}
+		var v uint64
+		var base [2][4]int
+		var q5 [2][3]int
+		diff := true
+		for ch := uint(0); ch < 3; ch++ {
+			for s := range q5 {
+				q5[s][ch] = (avg[s][ch]*31 + 127) / 255
+				base[s][ch] = q5[s][ch]<<3 | q5[s][ch]>>2
+			}
+			d := q5[1][ch] - q5[0][ch]
+			if d < -4 || d > 3 {
+				diff = false
+				break
+			}
+			v |= uint64(q5[0][ch])<<(59-8*ch) | uint64(d&7)<<(56-8*ch)
+		}
+		if diff {
+			v |= 1 << 33
+		} else {
+			v = 0
+			for ch := uint(0); ch < 3; ch++ {
+				for s := range avg {
+					q := (avg[s][ch]*15 + 127) / 255
+					base[s][ch] = q * 17
+					v |= uint64(q) << (60 - 8*ch - 4*uint(s))
+				}
+			}
+		}
+		if flip {
+			v |= 1 << 32
+		}
+		e := 0
+		for s := range subs {
+			t, indices, err := etcFitTable(&subs[s], base[s])
+			e += err
+			v |= uint64(t) << (37 - 3*uint(s))
+			for k, i := range indices {
+				p := uint(pixels[s][k])
+				v |= uint64(i>>1)<<(16+p) | uint64(i&1)<<p
+			}
+		}
+		if bestErr < 0 || e < bestErr {
+			best, bestErr = v, e
+		}
+	}
+	binary.BigEndian.PutUint64(block, best)
+}
+
+func etcCodec(variant int) *blockCodec {
+	c := &blockCodec{4, 4, 8, glRGBA, false, func(block []byte, texels []channels) {
+		decodeETC(block, texels, variant)
+	}, encodeETC1}
+	if variant == etc2Punchthrough {
+		c.encode = nil
+	}
+	return c
+}
+
+func decodeETC2EAC(block []byte, texels []channels) {
+	decodeETC(block[8:], texels, etc2)
+	v := binary.BigEndian.Uint64(block)
+	base, mul, t := int(v>>56), int(v>>52&0xf), int(v>>48&0xf)
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			i := int(v >> uint(45-3*(x*4+y)) & 7)
+			texels[y*4+x][chA] = uint16(clamp255(base+eacModifiers[t][i]*mul)) * 0x101
+		}
+	}
+}
+
+// eacFit searches the base, multiplier and table of an EAC block that
+// encode values best and returns the block. The value of a texel is
+// value(base, modifier*multiplier*scale), base is derived from the center
+// of the values by baseFor.
+func eacFit(values *[16]int, scale int, value func(base, offset int) int, baseFor func(center int) int) uint64 {
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+	var best uint64
+	bestErr := -1
+	for t, mods := range eacModifiers {
+		for mul := 1; mul < 16; mul++ {
+			base := baseFor((lo+hi)/2 - (mods[3]+mods[7])*mul*scale/2)
+			v := uint64(base&0xff)<<56 | uint64(mul)<<52 | uint64(t)<<48
+			e := 0
+			for x := 0; x < 4; x++ {
+				for y := 0; y < 4; y++ {
+					c := values[y*4+x]
+					bi, bd := 0, -1
+					for i, m := range mods {
+						if d := abs(value(base, m*mul*scale) - c); bd < 0 || d < bd {
+							bi, bd = i, d
+						}
+					}
+					e += bd * bd
+					v |= uint64(bi) << uint(45-3*(x*4+y))
+				}
+			}
+			if bestErr < 0 || e < bestErr {
+				best, bestErr = v, e
+			}
+		}
+	}
+	return best
+}
+
+func encodeETC2EAC(texels []channels, block []byte) {
+	var alpha [16]int
+	for i := range texels {
+		alpha[i] = int(texels[i][chA] >> 8)
+	}
+	v := eacFit(&alpha, 1, func(base, offset int) int {
+		return clamp255(base + offset)
+	}, clamp255)
+	binary.BigEndian.PutUint64(block, v)
+	encodeETC1(texels, block[8:])
+}
+
+// eac11 returns the 11 bit value of an R11 or RG11 EAC texel from the
+// base and the offset of its modifier. Signed values are in
+// [-1023, 1023].
+func eac11(base, offset int, signed bool) int {
+	if signed {
+		v := base*8 + offset
+		switch {
+		case v < -1023:
+			return -1023
+		case v > 1023:
+			return 1023
+		}
+		return v
+	}
+	v := base*8 + 4 + offset
+	switch {
+	case v < 0:
+		return 0
+	case v > 2047:
+		return 2047
+	}
+	return v
+}
+
+func decodeEAC11(block []byte, values *[16]int, signed bool) {
+	v := binary.BigEndian.Uint64(block)
+	base, mul, t := int(v>>56), int(v>>52&0xf), int(v>>48&0xf)
+	if signed {
+		base = signedEndpoint(uint8(base))
+	}
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			m := eacModifiers[t][v>>uint(45-3*(x*4+y))&7]
+			if mul == 0 {
+				// the modifier is used unscaled
+				values[y*4+x] = eac11(base, m, signed)
+			} else {
+				values[y*4+x] = eac11(base, m*mul*8, signed)
+			}
+		}
+	}
+}
+
+func eacCodec(signed bool, n int) *blockCodec {
+	format := glRGBA
+	if n == 1 {
+		format = glRED
+	}
+	return &blockCodec{4, 4, 8 * n, format, true,
+		func(block []byte, texels []channels) {
+			var values [16]int
+			for i := range texels {
+				texels[i] = channels{chA: 0xffff}
+			}
+			for ch := 0; ch < n; ch++ {
+				decodeEAC11(block[8*ch:], &values, signed)
+				for i, v := range values {
+					if signed {
+						texels[i][ch] = uint16(((v+1023)*0xffff + 1023) / 2046)
+					} else {
+						texels[i][ch] = uint16(v<<5 | v>>6)
+					}
+				}
+			}
+		},
+		func(texels []channels, block []byte) {
+			var values [16]int
+			for ch := 0; ch < n; ch++ {
+				for i := range texels {
+					if signed {
+						values[i] = (int(texels[i][ch])*2046+0x7fff)/0xffff - 1023
+					} else {
+						values[i] = int(texels[i][ch] >> 5)
+					}
+				}
+				v := eacFit(&values, 8, func(base, offset int) int {
+					return eac11(base, offset, signed)
+				}, func(center int) int {
+					if signed {
+						return clampSigned(center / 8)
+					}
+					return clamp255((center - 4) / 8)
+				})
+				binary.BigEndian.PutUint64(block[8*ch:], v)
+			}
+		},
+	}
+}
+
+func clampSigned(v int) int {
+	switch {
+	case v < -127:
+		return -127
+	case v > 127:
+		return 127
+	}
+	return v
+}
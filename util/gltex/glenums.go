@@ -0,0 +1,131 @@
+// GoGL2 - automatically generated OpenGL binding: http://github.com/chsc/gogl2
+//
+// Generated by gogl2 0.2.0 from gl.xml (sha256 fb6de11160cb5bf7f74468ab72bb9d79e8f87a411eec248468a2e8fdef16e649).
+// Regenerate it with: gogl2 enums -dir=<package directory> -prefix=gl -o=glenums.go
+
+package gltex
+
+import "github.com/chsc/gogl2/glt"
+
+const (
+	glALPHA                                     glt.Enum     = 0x1906
+	glALPHA16                                   glt.Enum     = 0x803E
+	glALPHA8                                    glt.Enum     = 0x803C
+	glALREADY_SIGNALED                          glt.Enum     = 0x911A
+	glBACK                                      glt.Enum     = 0x0405
+	glBGR                                       glt.Enum     = 0x80E0
+	glBGRA                                      glt.Enum     = 0x80E1
+	glCOLOR_ATTACHMENT0                         glt.Enum     = 0x8CE0
+	glCOLOR_ATTACHMENT1                         glt.Enum     = 0x8CE1
+	glCOMPRESSED_R11_EAC                        glt.Enum     = 0x9270
+	glCOMPRESSED_RED_RGTC1                      glt.Enum     = 0x8DBB
+	glCOMPRESSED_RG11_EAC                       glt.Enum     = 0x9272
+	glCOMPRESSED_RGB8_ETC2                      glt.Enum     = 0x9274
+	glCOMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2  glt.Enum     = 0x9276
+	glCOMPRESSED_RGBA8_ETC2_EAC                 glt.Enum     = 0x9278
+	glCOMPRESSED_RGBA_ASTC_4x4_KHR              glt.Enum     = 0x93B0
+	glCOMPRESSED_RGBA_BPTC_UNORM                glt.Enum     = 0x8E8C
+	glCOMPRESSED_RGBA_S3TC_DXT1_EXT             glt.Enum     = 0x83F1
+	glCOMPRESSED_RGBA_S3TC_DXT3_EXT             glt.Enum     = 0x83F2
+	glCOMPRESSED_RGBA_S3TC_DXT5_EXT             glt.Enum     = 0x83F3
+	glCOMPRESSED_RGB_BPTC_SIGNED_FLOAT          glt.Enum     = 0x8E8E
+	glCOMPRESSED_RGB_BPTC_UNSIGNED_FLOAT        glt.Enum     = 0x8E8F
+	glCOMPRESSED_RGB_S3TC_DXT1_EXT              glt.Enum     = 0x83F0
+	glCOMPRESSED_RG_RGTC2                       glt.Enum     = 0x8DBD
+	glCOMPRESSED_SIGNED_R11_EAC                 glt.Enum     = 0x9271
+	glCOMPRESSED_SIGNED_RED_RGTC1               glt.Enum     = 0x8DBC
+	glCOMPRESSED_SIGNED_RG11_EAC                glt.Enum     = 0x9273
+	glCOMPRESSED_SIGNED_RG_RGTC2                glt.Enum     = 0x8DBE
+	glCOMPRESSED_SRGB8_ALPHA8_ASTC_4x4_KHR      glt.Enum     = 0x93D0
+	glCOMPRESSED_SRGB8_ALPHA8_ETC2_EAC          glt.Enum     = 0x9279
+	glCOMPRESSED_SRGB8_ETC2                     glt.Enum     = 0x9275
+	glCOMPRESSED_SRGB8_PUNCHTHROUGH_ALPHA1_ETC2 glt.Enum     = 0x9277
+	glCOMPRESSED_SRGB_ALPHA_BPTC_UNORM          glt.Enum     = 0x8E8D
+	glCOMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT       glt.Enum     = 0x8C4D
+	glCOMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT       glt.Enum     = 0x8C4E
+	glCOMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT       glt.Enum     = 0x8C4F
+	glCOMPRESSED_SRGB_S3TC_DXT1_EXT             glt.Enum     = 0x8C4C
+	glETC1_RGB8_OES                             glt.Enum     = 0x8D64
+	glFLOAT                                     glt.Enum     = 0x1406
+	glHALF_FLOAT                                glt.Enum     = 0x140B
+	glLUMINANCE                                 glt.Enum     = 0x1909
+	glLUMINANCE16                               glt.Enum     = 0x8042
+	glLUMINANCE8                                glt.Enum     = 0x8040
+	glLUMINANCE8_ALPHA8                         glt.Enum     = 0x8045
+	glLUMINANCE_ALPHA                           glt.Enum     = 0x190A
+	glONE                                       glt.Enum     = 1
+	glPACK_ALIGNMENT                            glt.Enum     = 0x0D05
+	glPACK_ROW_LENGTH                           glt.Enum     = 0x0D02
+	glPACK_SKIP_PIXELS                          glt.Enum     = 0x0D04
+	glPACK_SKIP_ROWS                            glt.Enum     = 0x0D03
+	glPIXEL_PACK_BUFFER                         glt.Enum     = 0x88EB
+	glPIXEL_PACK_BUFFER_BINDING                 glt.Enum     = 0x88ED
+	glR11F_G11F_B10F                            glt.Enum     = 0x8C3A
+	glR16                                       glt.Enum     = 0x822A
+	glR16F                                      glt.Enum     = 0x822D
+	glR32F                                      glt.Enum     = 0x822E
+	glR32UI                                     glt.Enum     = 0x8236
+	glR8                                        glt.Enum     = 0x8229
+	glREAD_BUFFER                               glt.Enum     = 0x0C02
+	glREAD_FRAMEBUFFER                          glt.Enum     = 0x8CA8
+	glREAD_FRAMEBUFFER_BINDING                  glt.Enum     = 0x8CAA
+	glREAD_ONLY                                 glt.Enum     = 0x88B8
+	glRED                                       glt.Enum     = 0x1903
+	glRED_INTEGER                               glt.Enum     = 0x8D94
+	glRG                                        glt.Enum     = 0x8227
+	glRG16                                      glt.Enum     = 0x822C
+	glRG16F                                     glt.Enum     = 0x822F
+	glRG32F                                     glt.Enum     = 0x8230
+	glRG8                                       glt.Enum     = 0x822B
+	glRGB                                       glt.Enum     = 0x1907
+	glRGB10_A2                                  glt.Enum     = 0x8059
+	glRGB16                                     glt.Enum     = 0x8054
+	glRGB16F                                    glt.Enum     = 0x881B
+	glRGB32F                                    glt.Enum     = 0x8815
+	glRGB565                                    glt.Enum     = 0x8D62
+	glRGB5_A1                                   glt.Enum     = 0x8057
+	glRGB8                                      glt.Enum     = 0x8051
+	glRGB9_E5                                   glt.Enum     = 0x8C3D
+	glRGBA                                      glt.Enum     = 0x1908
+	glRGBA16                                    glt.Enum     = 0x805B
+	glRGBA16F                                   glt.Enum     = 0x881A
+	glRGBA32F                                   glt.Enum     = 0x8814
+	glRGBA32UI                                  glt.Enum     = 0x8D70
+	glRGBA4                                     glt.Enum     = 0x8056
+	glRGBA8                                     glt.Enum     = 0x8058
+	glRGBA8UI                                   glt.Enum     = 0x8D7C
+	glRGBA_INTEGER                              glt.Enum     = 0x8D99
+	glSRGB8                                     glt.Enum     = 0x8C41
+	glSRGB8_ALPHA8                              glt.Enum     = 0x8C43
+	glSTREAM_READ                               glt.Enum     = 0x88E1
+	glSYNC_FLUSH_COMMANDS_BIT                   glt.Bitfield = 0x00000001
+	glSYNC_GPU_COMMANDS_COMPLETE                glt.Enum     = 0x9117
+	glTEXTURE_1D                                glt.Enum     = 0x0DE0
+	glTEXTURE_1D_ARRAY                          glt.Enum     = 0x8C18
+	glTEXTURE_2D                                glt.Enum     = 0x0DE1
+	glTEXTURE_2D_ARRAY                          glt.Enum     = 0x8C1A
+	glTEXTURE_3D                                glt.Enum     = 0x806F
+	glTEXTURE_CUBE_MAP                          glt.Enum     = 0x8513
+	glTEXTURE_CUBE_MAP_ARRAY                    glt.Enum     = 0x9009
+	glTEXTURE_CUBE_MAP_NEGATIVE_Z               glt.Enum     = 0x851A
+	glTEXTURE_CUBE_MAP_POSITIVE_X               glt.Enum     = 0x8515
+	glTEXTURE_SWIZZLE_R                         glt.Enum     = 0x8E42
+	glTIMEOUT_EXPIRED                           glt.Enum     = 0x911B
+	glUNPACK_ALIGNMENT                          glt.Enum     = 0x0CF5
+	glUNPACK_ROW_LENGTH                         glt.Enum     = 0x0CF2
+	glUNPACK_SKIP_PIXELS                        glt.Enum     = 0x0CF4
+	glUNPACK_SKIP_ROWS                          glt.Enum     = 0x0CF3
+	glUNSIGNED_BYTE                             glt.Enum     = 0x1401
+	glUNSIGNED_INT                              glt.Enum     = 0x1405
+	glUNSIGNED_INT_10F_11F_11F_REV              glt.Enum     = 0x8C3B
+	glUNSIGNED_INT_2_10_10_10_REV               glt.Enum     = 0x8368
+	glUNSIGNED_INT_5_9_9_9_REV                  glt.Enum     = 0x8C3E
+	glUNSIGNED_SHORT                            glt.Enum     = 0x1403
+	glUNSIGNED_SHORT_1_5_5_5_REV                glt.Enum     = 0x8366
+	glUNSIGNED_SHORT_4_4_4_4                    glt.Enum     = 0x8033
+	glUNSIGNED_SHORT_4_4_4_4_REV                glt.Enum     = 0x8365
+	glUNSIGNED_SHORT_5_5_5_1                    glt.Enum     = 0x8034
+	glUNSIGNED_SHORT_5_6_5                      glt.Enum     = 0x8363
+	glWAIT_FAILED                               glt.Enum     = 0x911D
+	glZERO                                      glt.Enum     = 0
+)
@@ -0,0 +1,320 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package gltex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/chsc/gogl2/glt"
+)
+
+var (
+	ktx1Identifier = []byte{0xAB, 'K', 'T', 'X', ' ', '1', '1', 0xBB, '\r', '\n', 0x1A, '\n'}
+	ktx2Identifier = []byte{0xAB, 'K', 'T', 'X', ' ', '2', '0', 0xBB, '\r', '\n', 0x1A, '\n'}
+)
+
+const (
+	ktx1HeaderSize = 64
+	ktx2HeaderSize = 80
+	ktxOrientation = "KTXorientation"
+	maxLevels      = 32
+)
+
+// pad4 rounds n up to a multiple of 4.
+func pad4(n int) int {
+	return (n + 3) &^ 3
+}
+
+// checkedSlice returns data[offset:offset+size] or an error if that is
+// out of range.
+func checkedSlice(data []byte, offset, size uint64, what string) ([]byte, error) {
+	if offset > uint64(len(data)) || size > uint64(len(data))-offset {
+		return nil, fmt.Errorf("gltex: %s at %d with %d bytes exceeds the file size of %d", what, offset, size, len(data))
+	}
+	return data[offset : offset+size], nil
+}
+
+// parseKeyValues parses the key/value data of a KTX file: entries of a
+// 32 bit size, the NUL terminated key and the value, padded to 4 bytes.
+func parseKeyValues(data []byte, order binary.ByteOrder) (map[string]string, error) {
+	kv := make(map[string]string)
+	for len(data) >= 4 {
+		size := uint64(order.Uint32(data))
+		entry, err := checkedSlice(data, 4, size, "key/value pair")
+		if err != nil {
+			return nil, err
+		}
+		i := bytes.IndexByte(entry, 0)
+		if i < 0 {
+			return nil, fmt.Errorf("gltex: key/value pair without NUL terminated key")
+		}
+		kv[string(entry[:i])] = strings.TrimRight(string(entry[i+1:]), "\x00")
+		next := pad4(4 + int(size))
+		if next > len(data) {
+			next = len(data)
+		}
+		data = data[next:]
+	}
+	return kv, nil
+}
+
+// swapBytes converts the elements of size 2 or 4 in data between byte
+// orders in place.
+func swapBytes(data []byte, size int) {
+	for i := 0; i+size <= len(data); i += size {
+		for j := 0; j < size/2; j++ {
+			data[i+j], data[i+size-1-j] = data[i+size-1-j], data[i+j]
+		}
+	}
+}
+
+// expectedImageSize returns the size of an image of level l, or -1 if
+// the format is unknown.
+func (t *Texture) expectedImageSize(l *Level) int {
+	if t.Compressed() {
+		if f, ok := compressedFormats[t.InternalFormat]; ok {
+			return f.imageSize(l.Width, l.Height, l.Depth)
+		}
+		return -1
+	}
+	pl, err := newPixelLayout(t.Format, t.Type_)
+	if err != nil {
+		return -1
+	}
+	return t.Store.stride(pl, l.Width) * l.Height * l.Depth
+}
+
+// checkLevels verifies that the images of all levels have the size of
+// their format.
+func (t *Texture) checkLevels() error {
+	for i := range t.Levels {
+		l := &t.Levels[i]
+		if size := t.expectedImageSize(l); size >= 0 && l.ImageSize != size {
+			return fmt.Errorf("gltex: level %d has images of %d bytes, %dx%dx%d of format %#x needs %d",
+				i, l.ImageSize, l.Width, l.Height, l.Depth, t.InternalFormat, size)
+		}
+	}
+	return nil
+}
+
+// ParseKTX parses a KTX 1.1 file. Uncompressed level data is laid out
+// with the default unpack alignment of 4 as described by t.Store. Files
+// in big endian byte order are converted to native byte order.
+func ParseKTX(data []byte) (*Texture, error) {
+	if len(data) < ktx1HeaderSize || !bytes.HasPrefix(data, ktx1Identifier) {
+		return nil, fmt.Errorf("gltex: not a KTX file")
+	}
+	var order binary.ByteOrder
+	switch binary.LittleEndian.Uint32(data[12:]) {
+	case 0x04030201:
+		order = binary.LittleEndian
+	case 0x01020304:
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("gltex: invalid KTX endianness")
+	}
+	field := func(offset int) int {
+		return int(order.Uint32(data[offset:]))
+	}
+	typeSize := field(20)
+	t := &Texture{
+		Type_:          glt.Enum(field(16)),
+		Format:         glt.Enum(field(24)),
+		InternalFormat: glt.Enum(field(28)),
+	}
+	if err := t.setSize(field(36), field(40), field(44), field(48), field(52)); err != nil {
+		return nil, err
+	}
+	levels := field(56)
+	if levels == 0 {
+		levels, t.GenerateMipmaps = 1, true
+	}
+	if levels > maxLevels {
+		return nil, fmt.Errorf("gltex: %d mipmap levels", levels)
+	}
+	kvData, err := checkedSlice(data, ktx1HeaderSize, uint64(order.Uint32(data[60:])), "key/value data")
+	if err != nil {
+		return nil, err
+	}
+	if t.Metadata, err = parseKeyValues(kvData, order); err != nil {
+		return nil, err
+	}
+	// the orientation is given as "S=r,T=d"
+	t.TopDown = strings.Contains(t.Metadata[ktxOrientation], "T=d")
+	swap := order != nativeEndian && (typeSize == 2 || typeSize == 4)
+	// the image size of cube maps that are not arrays is the size of one
+	// face, every face is padded to 4 bytes
+	cube := t.Faces == 6 && t.Layers == 0
+	offset := uint64(ktx1HeaderSize + len(kvData))
+	for i := 0; i < levels; i++ {
+		sizeData, err := checkedSlice(data, offset, 4, fmt.Sprintf("size of level %d", i))
+		if err != nil {
+			return nil, err
+		}
+		size := uint64(order.Uint32(sizeData))
+		offset += 4
+		l := t.level(i)
+		if cube {
+			l.ImageSize = int(size)
+			for face := 0; face < 6; face++ {
+				img, err := checkedSlice(data, offset, size, fmt.Sprintf("level %d face %d", i, face))
+				if err != nil {
+					return nil, err
+				}
+				l.Data = append(l.Data, img...)
+				offset += uint64(pad4(int(size)))
+			}
+		} else {
+			img, err := checkedSlice(data, offset, size, fmt.Sprintf("level %d", i))
+			if err != nil {
+				return nil, err
+			}
+			l.Data = append([]byte(nil), img...)
+			l.ImageSize = int(size) / t.images()
+			offset += uint64(pad4(int(size)))
+		}
+		if swap {
+			swapBytes(l.Data, typeSize)
+		}
+		t.Levels = append(t.Levels, l)
+	}
+	if err := t.checkLevels(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ParseKTX2 parses a KTX2 file that is not supercompressed. Uncompressed
+// level data is tightly packed as described by t.Store.
+func ParseKTX2(data []byte) (*Texture, error) {
+	if len(data) < ktx2HeaderSize || !bytes.HasPrefix(data, ktx2Identifier) {
+		return nil, fmt.Errorf("gltex: not a KTX2 file")
+	}
+	order := binary.LittleEndian
+	field := func(offset int) int {
+		return int(order.Uint32(data[offset:]))
+	}
+	vkFormat := field(12)
+	f, ok := vkFormats[vkFormat]
+	if !ok {
+		return nil, fmt.Errorf("gltex: unsupported KTX2 format VkFormat %d", vkFormat)
+	}
+	if scheme := field(44); scheme != 0 {
+		return nil, fmt.Errorf("gltex: unsupported KTX2 supercompression scheme %d", scheme)
+	}
+	t := &Texture{InternalFormat: f.InternalFormat, Format: f.Format, Type_: f.Type_, Store: TightlyPacked}
+	if err := t.setSize(field(20), field(24), field(28), field(32), field(36)); err != nil {
+		return nil, err
+	}
+	levels := field(40)
+	if levels == 0 {
+		levels, t.GenerateMipmaps = 1, true
+	}
+	if levels > maxLevels {
+		return nil, fmt.Errorf("gltex: %d mipmap levels", levels)
+	}
+	kvData, err := checkedSlice(data, uint64(field(56)), uint64(field(60)), "key/value data")
+	if err != nil {
+		return nil, err
+	}
+	if t.Metadata, err = parseKeyValues(kvData, order); err != nil {
+		return nil, err
+	}
+	// the orientation is given as "rd", which is the default
+	orientation := t.Metadata[ktxOrientation]
+	t.TopDown = len(orientation) < 2 || orientation[1] == 'd'
+	index, err := checkedSlice(data, ktx2HeaderSize, uint64(levels)*24, "level index")
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < levels; i++ {
+		e := index[i*24:]
+		img, err := checkedSlice(data, order.Uint64(e), order.Uint64(e[8:]), fmt.Sprintf("level %d", i))
+		if err != nil {
+			return nil, err
+		}
+		l := t.level(i)
+		l.Data = append([]byte(nil), img...)
+		l.ImageSize = len(img) / t.images()
+		t.Levels = append(t.Levels, l)
+	}
+	if err := t.checkLevels(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// vkFormats maps the VkFormat values of KTX2 files to GL formats.
+var vkFormats = map[int]formatInfo{
+	2:   uncompressed(glRGBA4, glRGBA, glUNSIGNED_SHORT_4_4_4_4, 2),
+	4:   uncompressed(glRGB565, glRGB, glUNSIGNED_SHORT_5_6_5, 2),
+	6:   uncompressed(glRGB5_A1, glRGBA, glUNSIGNED_SHORT_5_5_5_1, 2),
+	9:   uncompressed(glR8, glRED, glUNSIGNED_BYTE, 1),
+	16:  uncompressed(glRG8, glRG, glUNSIGNED_BYTE, 2),
+	23:  uncompressed(glRGB8, glRGB, glUNSIGNED_BYTE, 3),
+	29:  uncompressed(glSRGB8, glRGB, glUNSIGNED_BYTE, 3),
+	30:  uncompressed(glRGB8, glBGR, glUNSIGNED_BYTE, 3),
+	36:  uncompressed(glSRGB8, glBGR, glUNSIGNED_BYTE, 3),
+	37:  uncompressed(glRGBA8, glRGBA, glUNSIGNED_BYTE, 4),
+	41:  uncompressed(glRGBA8UI, glRGBA_INTEGER, glUNSIGNED_BYTE, 4),
+	43:  uncompressed(glSRGB8_ALPHA8, glRGBA, glUNSIGNED_BYTE, 4),
+	44:  uncompressed(glRGBA8, glBGRA, glUNSIGNED_BYTE, 4),
+	50:  uncompressed(glSRGB8_ALPHA8, glBGRA, glUNSIGNED_BYTE, 4),
+	64:  uncompressed(glRGB10_A2, glRGBA, glUNSIGNED_INT_2_10_10_10_REV, 4),
+	70:  uncompressed(glR16, glRED, glUNSIGNED_SHORT, 2),
+	76:  uncompressed(glR16F, glRED, glHALF_FLOAT, 2),
+	77:  uncompressed(glRG16, glRG, glUNSIGNED_SHORT, 4),
+	83:  uncompressed(glRG16F, glRG, glHALF_FLOAT, 4),
+	84:  uncompressed(glRGB16, glRGB, glUNSIGNED_SHORT, 6),
+	90:  uncompressed(glRGB16F, glRGB, glHALF_FLOAT, 6),
+	91:  uncompressed(glRGBA16, glRGBA, glUNSIGNED_SHORT, 8),
+	97:  uncompressed(glRGBA16F, glRGBA, glHALF_FLOAT, 8),
+	98:  uncompressed(glR32UI, glRED_INTEGER, glUNSIGNED_INT, 4),
+	100: uncompressed(glR32F, glRED, glFLOAT, 4),
+	103: uncompressed(glRG32F, glRG, glFLOAT, 8),
+	106: uncompressed(glRGB32F, glRGB, glFLOAT, 12),
+	107: uncompressed(glRGBA32UI, glRGBA_INTEGER, glUNSIGNED_INT, 16),
+	109: uncompressed(glRGBA32F, glRGBA, glFLOAT, 16),
+	122: uncompressed(glR11F_G11F_B10F, glRGB, glUNSIGNED_INT_10F_11F_11F_REV, 4),
+	123: uncompressed(glRGB9_E5, glRGB, glUNSIGNED_INT_5_9_9_9_REV, 4),
+	131: compressedFormats[glCOMPRESSED_RGB_S3TC_DXT1_EXT],
+	132: compressedFormats[glCOMPRESSED_SRGB_S3TC_DXT1_EXT],
+	133: compressedFormats[glCOMPRESSED_RGBA_S3TC_DXT1_EXT],
+	134: compressedFormats[glCOMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT],
+	135: compressedFormats[glCOMPRESSED_RGBA_S3TC_DXT3_EXT],
+	136: compressedFormats[glCOMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT],
+	137: compressedFormats[glCOMPRESSED_RGBA_S3TC_DXT5_EXT],
+	138: compressedFormats[glCOMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT],
+	139: compressedFormats[glCOMPRESSED_RED_RGTC1],
+	140: compressedFormats[glCOMPRESSED_SIGNED_RED_RGTC1],
+	141: compressedFormats[glCOMPRESSED_RG_RGTC2],
+	142: compressedFormats[glCOMPRESSED_SIGNED_RG_RGTC2],
+	143: compressedFormats[glCOMPRESSED_RGB_BPTC_UNSIGNED_FLOAT],
+	144: compressedFormats[glCOMPRESSED_RGB_BPTC_SIGNED_FLOAT],
+	145: compressedFormats[glCOMPRESSED_RGBA_BPTC_UNORM],
+	146: compressedFormats[glCOMPRESSED_SRGB_ALPHA_BPTC_UNORM],
+	147: compressedFormats[glCOMPRESSED_RGB8_ETC2],
+	148: compressedFormats[glCOMPRESSED_SRGB8_ETC2],
+	149: compressedFormats[glCOMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2],
+	150: compressedFormats[glCOMPRESSED_SRGB8_PUNCHTHROUGH_ALPHA1_ETC2],
+	151: compressedFormats[glCOMPRESSED_RGBA8_ETC2_EAC],
+	152: compressedFormats[glCOMPRESSED_SRGB8_ALPHA8_ETC2_EAC],
+	153: compressedFormats[glCOMPRESSED_R11_EAC],
+	154: compressedFormats[glCOMPRESSED_SIGNED_R11_EAC],
+	155: compressedFormats[glCOMPRESSED_RG11_EAC],
+	156: compressedFormats[glCOMPRESSED_SIGNED_RG11_EAC],
+}
+
+func init() {
+	// VK_FORMAT_ASTC_4x4_UNORM_BLOCK and VK_FORMAT_ASTC_4x4_SRGB_BLOCK
+	// are followed by the other block sizes in the order of the GL enums
+	for i := range astcBlocks {
+		vkFormats[157+2*i] = compressedFormats[glCOMPRESSED_RGBA_ASTC_4x4_KHR+glt.Enum(i)]
+		vkFormats[158+2*i] = compressedFormats[glCOMPRESSED_SRGB8_ALPHA8_ASTC_4x4_KHR+glt.Enum(i)]
+	}
+}
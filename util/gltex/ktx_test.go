@@ -0,0 +1,228 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package gltex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/chsc/gogl2/glt"
+)
+
+// ktxFile describes a KTX 1.1 file for makeKTX.
+type ktxFile struct {
+	Order                               binary.ByteOrder
+	Type_, TypeSize, Format, Internal   uint32
+	Width, Height, Depth, Layers, Faces uint32
+	Orientation                         string
+	Levels                              [][]byte // level data, for cube maps the data of all faces
+	NoLevels                            bool     // write 0 mipmap levels
+}
+
+func makeKTX(f ktxFile) []byte {
+	var b bytes.Buffer
+	w := func(v uint32) {
+		binary.Write(&b, f.Order, v)
+	}
+	b.Write(ktx1Identifier)
+	w(0x04030201)
+	w(f.Type_)
+	w(f.TypeSize)
+	w(f.Format)
+	w(f.Internal)
+	w(f.Format)
+	w(f.Width)
+	w(f.Height)
+	w(f.Depth)
+	w(f.Layers)
+	w(f.Faces)
+	if f.NoLevels {
+		w(0)
+	} else {
+		w(uint32(len(f.Levels)))
+	}
+	var kv bytes.Buffer
+	if f.Orientation != "" {
+		entry := ktxOrientation + "\x00" + f.Orientation + "\x00"
+		binary.Write(&kv, f.Order, uint32(len(entry)))
+		kv.WriteString(entry)
+		kv.Write(make([]byte, pad4(len(entry))-len(entry)))
+	}
+	w(uint32(kv.Len()))
+	b.Write(kv.Bytes())
+	for _, l := range f.Levels {
+		if f.Faces == 6 && f.Layers == 0 {
+			size := len(l) / 6
+			w(uint32(size))
+			for face := 0; face < 6; face++ {
+				b.Write(l[face*size : (face+1)*size])
+				b.Write(make([]byte, pad4(size)-size))
+			}
+			continue
+		}
+		w(uint32(len(l)))
+		b.Write(l)
+		b.Write(make([]byte, pad4(len(l))-len(l)))
+	}
+	return b.Bytes()
+}
+
+func sequence(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i + 1)
+	}
+	return b
+}
+
+// textureTest describes a texture file and the expected result of
+// parsing it.
+type textureTest struct {
+	File           []byte
+	Target         glt.Enum
+	InternalFormat glt.Enum
+	Format, Type_  glt.Enum
+	Size           [5]int // width, height, depth, layers, faces
+	ImageSizes     []int
+	TopDown        bool
+	Err            string // expected error substring, empty if valid
+}
+
+var allTestsKTX = []textureTest{
+	// RGB rows are padded to 4 bytes
+	{makeKTX(ktxFile{Order: binary.LittleEndian, Type_: 0x1401, TypeSize: 1, Format: 0x1907, Internal: 0x8051, Width: 3, Height: 2, Faces: 1, Orientation: "S=r,T=d", Levels: [][]byte{sequence(24), sequence(4)}}),
+		glTEXTURE_2D, glRGB8, glRGB, glUNSIGNED_BYTE, [5]int{3, 2, 1, 0, 1}, []int{24, 4}, true, ""},
+	{makeKTX(ktxFile{Order: binary.BigEndian, Type_: 0x1403, TypeSize: 2, Format: 0x1908, Internal: 0x805B, Width: 1, Height: 1, Faces: 1, Orientation: "S=r,T=u", Levels: [][]byte{sequence(8)}}),
+		glTEXTURE_2D, glRGBA16, glRGBA, glUNSIGNED_SHORT, [5]int{1, 1, 1, 0, 1}, []int{8}, false, ""},
+	{makeKTX(ktxFile{Order: binary.LittleEndian, Internal: 0x9274, Width: 4, Height: 4, Faces: 6, Levels: [][]byte{sequence(48)}}),
+		glTEXTURE_CUBE_MAP, glCOMPRESSED_RGB8_ETC2, 0, 0, [5]int{4, 4, 1, 0, 6}, []int{8}, false, ""},
+	{makeKTX(ktxFile{Order: binary.LittleEndian, Internal: 0x8E8C, Width: 8, Height: 4, Layers: 2, Faces: 1, Levels: [][]byte{sequence(64), sequence(32), sequence(32), sequence(32)}}),
+		glTEXTURE_2D_ARRAY, glCOMPRESSED_RGBA_BPTC_UNORM, 0, 0, [5]int{8, 4, 1, 2, 1}, []int{32, 16, 16, 16}, false, ""},
+	{makeKTX(ktxFile{Order: binary.LittleEndian, Internal: 0x93B7, Width: 10, Height: 10, Depth: 3, Faces: 1, Levels: [][]byte{sequence(4 * 16 * 3)}}),
+		glTEXTURE_3D, glCOMPRESSED_RGBA_ASTC_4x4_KHR + 7, 0, 0, [5]int{10, 10, 3, 0, 1}, []int{4 * 16 * 3}, false, ""},
+	{makeKTX(ktxFile{Order: binary.LittleEndian, Type_: 0x1401, TypeSize: 1, Format: 0x1903, Internal: 0x8229, Width: 5, Layers: 3, Faces: 1, Levels: [][]byte{sequence(24)}}),
+		glTEXTURE_1D_ARRAY, glR8, glRED, glUNSIGNED_BYTE, [5]int{5, 1, 1, 3, 1}, []int{8}, false, ""},
+	{makeKTX(ktxFile{Order: binary.LittleEndian, Type_: 0x1401, TypeSize: 1, Format: 0x1907, Internal: 0x8051, Width: 3, Height: 2, Faces: 1, Levels: [][]byte{sequence(18)}}),
+		0, 0, 0, 0, [5]int{}, nil, false, "level 0 has images of 18 bytes, 3x2x1 of format 0x8051 needs 24"},
+	{makeKTX(ktxFile{Order: binary.LittleEndian, Internal: 0x8E8C, Width: 8, Height: 8, Faces: 1, Levels: [][]byte{sequence(64)}})[:100],
+		0, 0, 0, 0, [5]int{}, nil, false, "level 0 at 68 with 64 bytes exceeds the file size of 100"},
+	{makeKTX(ktxFile{Order: binary.LittleEndian, Internal: 0x8E8C, Width: 8, Height: 8, Faces: 4, Levels: [][]byte{sequence(64)}}),
+		0, 0, 0, 0, [5]int{}, nil, false, "4 faces"},
+	{[]byte("not a texture"), 0, 0, 0, 0, [5]int{}, nil, false, "unknown texture file format"},
+}
+
+func testTextures(t *testing.T, tests []textureTest) {
+	for i, te := range tests {
+		tex, err := ReadTexture(bytes.NewReader(te.File))
+		if te.Err != "" {
+			if err == nil || !strings.Contains(err.Error(), te.Err) {
+				t.Errorf("test %d: ReadTexture() = %v, expected error '%s'", i, err, te.Err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: ReadTexture() failed: %s", i, err)
+			continue
+		}
+		if tex.Target != te.Target || tex.InternalFormat != te.InternalFormat || tex.Format != te.Format || tex.Type_ != te.Type_ {
+			t.Errorf("test %d: ReadTexture() = %#x, %#x, %#x, %#x, expected %#x, %#x, %#x, %#x", i,
+				tex.Target, tex.InternalFormat, tex.Format, tex.Type_, te.Target, te.InternalFormat, te.Format, te.Type_)
+		}
+		if size := [5]int{tex.Width, tex.Height, tex.Depth, tex.Layers, tex.Faces}; size != te.Size {
+			t.Errorf("test %d: ReadTexture() size = %v, expected %v", i, size, te.Size)
+		}
+		if tex.TopDown != te.TopDown {
+			t.Errorf("test %d: ReadTexture() top down = %v", i, tex.TopDown)
+		}
+		if len(tex.Levels) != len(te.ImageSizes) {
+			t.Errorf("test %d: ReadTexture() has %d levels, expected %d", i, len(tex.Levels), len(te.ImageSizes))
+			continue
+		}
+		for j, l := range tex.Levels {
+			if l.ImageSize != te.ImageSizes[j] || len(l.Data) != l.ImageSize*tex.images() {
+				t.Errorf("test %d: level %d has %d bytes in images of %d, expected images of %d", i, j, len(l.Data), l.ImageSize, te.ImageSizes[j])
+			}
+		}
+	}
+}
+
+func TestKTX(t *testing.T) {
+	testTextures(t, allTestsKTX)
+}
+
+func TestKTXData(t *testing.T) {
+	tex, err := ParseKTX(allTestsKTX[1].File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	if nativeEndian == binary.LittleEndian {
+		expected = []byte{2, 1, 4, 3, 6, 5, 8, 7}
+	}
+	if !bytes.Equal(tex.Levels[0].Data, expected) {
+		t.Errorf("big endian KTX data = %v, expected %v", tex.Levels[0].Data, expected)
+	}
+	if tex.Metadata[ktxOrientation] != "S=r,T=u" {
+		t.Errorf("KTX metadata = %v", tex.Metadata)
+	}
+	tex, err = ParseKTX(allTestsKTX[2].File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img := tex.Levels[0].Image(3); !bytes.Equal(img, sequence(48)[24:32]) {
+		t.Errorf("cube map face 3 = %v", img)
+	}
+	tex, err = ParseKTX(makeKTX(ktxFile{Order: binary.LittleEndian, Internal: 0x8E8C, Width: 4, Height: 4, Faces: 1, NoLevels: true, Levels: [][]byte{sequence(16)}}))
+	if err != nil || !tex.GenerateMipmaps || len(tex.Levels) != 1 {
+		t.Errorf("ParseKTX() without levels = %+v, %v", tex, err)
+	}
+}
+
+// makeKTX2 returns a KTX2 file with the images of all levels in data.
+func makeKTX2(vkFormat, width, height, layers, faces, scheme uint32, orientation string, levels [][]byte) []byte {
+	le := binary.LittleEndian
+	var kv []byte
+	if orientation != "" {
+		entry := ktxOrientation + "\x00" + orientation + "\x00"
+		kv = make([]byte, 4+pad4(len(entry)))
+		le.PutUint32(kv, uint32(len(entry)))
+		copy(kv[4:], entry)
+	}
+	header := make([]byte, ktx2HeaderSize+24*len(levels))
+	copy(header, ktx2Identifier)
+	for i, v := range []uint32{vkFormat, 1, width, height, 0, layers, faces, uint32(len(levels)), scheme} {
+		le.PutUint32(header[12+4*i:], v)
+	}
+	le.PutUint32(header[56:], uint32(len(header)))
+	le.PutUint32(header[60:], uint32(len(kv)))
+	file := append(header, kv...)
+	for i, l := range levels {
+		le.PutUint64(file[ktx2HeaderSize+24*i:], uint64(len(file)))
+		le.PutUint64(file[ktx2HeaderSize+24*i+8:], uint64(len(l)))
+		le.PutUint64(file[ktx2HeaderSize+24*i+16:], uint64(len(l)))
+		file = append(file, l...)
+	}
+	return file
+}
+
+var allTestsKTX2 = []textureTest{
+	{makeKTX2(145, 8, 8, 2, 1, 0, "", [][]byte{sequence(128), sequence(32)}),
+		glTEXTURE_2D_ARRAY, glCOMPRESSED_RGBA_BPTC_UNORM, 0, 0, [5]int{8, 8, 1, 2, 1}, []int{64, 16}, true, ""},
+	{makeKTX2(152, 4, 4, 0, 6, 0, "ru", [][]byte{sequence(96)}),
+		glTEXTURE_CUBE_MAP, glCOMPRESSED_SRGB8_ALPHA8_ETC2_EAC, 0, 0, [5]int{4, 4, 1, 0, 6}, []int{16}, false, ""},
+	{makeKTX2(23, 3, 1, 0, 1, 0, "rd", [][]byte{sequence(9), sequence(3)}),
+		glTEXTURE_2D, glRGB8, glRGB, glUNSIGNED_BYTE, [5]int{3, 1, 1, 0, 1}, []int{9, 3}, true, ""},
+	{makeKTX2(184, 24, 24, 0, 1, 0, "", [][]byte{sequence(64)}),
+		glTEXTURE_2D, glCOMPRESSED_SRGB8_ALPHA8_ASTC_4x4_KHR + 13, 0, 0, [5]int{24, 24, 1, 0, 1}, []int{64}, true, ""},
+	{makeKTX2(145, 8, 8, 0, 1, 1, "", [][]byte{sequence(64)}), 0, 0, 0, 0, [5]int{}, nil, false, "supercompression scheme 1"},
+	{makeKTX2(0, 8, 8, 0, 1, 0, "", [][]byte{sequence(64)}), 0, 0, 0, 0, [5]int{}, nil, false, "VkFormat 0"},
+	{makeKTX2(145, 8, 8, 0, 1, 0, "", [][]byte{sequence(48)}), 0, 0, 0, 0, [5]int{}, nil, false, "needs 64"},
+}
+
+func TestKTX2(t *testing.T) {
+	testTextures(t, allTestsKTX2)
+}
@@ -0,0 +1,307 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package gltex
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sync"
+
+	"github.com/chsc/gogl2/glt"
+)
+
+// Filter selects the resampling filter of Resize and GenerateMipmaps.
+type Filter int
+
+const (
+	// BoxFilter averages the source pixels covered by a destination
+	// pixel, weighted by the covered area.
+	BoxFilter Filter = iota
+	// LanczosFilter is a Lanczos filter with 3 lobes. It keeps more
+	// detail than the box filter but may ring at hard edges.
+	LanczosFilter
+)
+
+// MipmapOptions control GenerateMipmaps. The zero value gives a full
+// chain of straight alpha RGBA data with 8 bit components filtered with a
+// box filter, laid out for the initial unpack parameters.
+type MipmapOptions struct {
+	Filter Filter
+
+	// SRGB is set if the color components are sRGB encoded, as for the
+	// SRGB8 and SRGB8_ALPHA8 internal formats. They are filtered in linear
+	// space then.
+	SRGB bool
+
+	Format, Type_ glt.Enum // of the level data, 0 for RGBA and UNSIGNED_BYTE
+	Alpha         AlphaMode
+	Store         PixelStore
+	Levels        int // maximum number of levels, 0 for the full chain
+}
+
+// MipmapLevels returns the number of levels of a full mipmap chain of a
+// width*height texture.
+func MipmapLevels(width, height int) int {
+	n := 1
+	for width > 1 || height > 1 {
+		width, height = width>>1, height>>1
+		n++
+	}
+	return n
+}
+
+// GenerateMipmaps builds the mipmap chain of img, including img itself as
+// level 0, as pixel data to pass to TexImage2D for each level. Level i is
+// max(1, width>>i) by max(1, height>>i) pixels as required by GL, which
+// works for sizes that are not powers of two. Filtering is done on
+// premultiplied colors, so transparent pixels do not bleed into their
+// neighbours.
+func GenerateMipmaps(img image.Image, opts MipmapOptions) ([]Level, error) {
+	format, type_ := opts.Format, opts.Type_
+	if format == 0 {
+		format, type_ = glRGBA, glUNSIGNED_BYTE
+	}
+	if _, err := newPixelLayout(format, type_); err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	if b.Empty() {
+		return nil, fmt.Errorf("gltex: empty image")
+	}
+	n := MipmapLevels(b.Dx(), b.Dy())
+	if opts.Levels > 0 && opts.Levels < n {
+		n = opts.Levels
+	}
+	m := newLinearImage(img, opts.SRGB)
+	levels := make([]Level, n)
+	for i := range levels {
+		w, h := mipSize(b.Dx(), i), mipSize(b.Dy(), i)
+		var src image.Image = img
+		if i > 0 {
+			// every level is resampled from the previous one
+			m = m.resize(w, h, opts.Filter)
+			src = m.image(opts.SRGB)
+		}
+		data, _, _, err := ConvertImage(src, format, type_, opts.Alpha, opts.Store)
+		if err != nil {
+			return nil, err
+		}
+		levels[i] = Level{Width: w, Height: h, Depth: 1, Data: data, ImageSize: len(data)}
+	}
+	return levels, nil
+}
+
+// Resize resamples img to width*height pixels. If srgb is set the colors
+// are resampled in linear space.
+func Resize(img image.Image, width, height int, filter Filter, srgb bool) *image.NRGBA64 {
+	if width <= 0 || height <= 0 || img.Bounds().Empty() {
+		return image.NewNRGBA64(image.Rectangle{})
+	}
+	return newLinearImage(img, srgb).resize(width, height, filter).image(srgb)
+}
+
+// linearImage holds premultiplied RGBA colors in linear space.
+type linearImage struct {
+	width, height int
+	pix           []float32
+}
+
+var (
+	srgbToLinearOnce  sync.Once
+	srgbToLinearTable []float32
+)
+
+// srgbToLinear converts a 16 bit sRGB encoded component to linear space.
+func srgbToLinear(v uint16) float32 {
+	srgbToLinearOnce.Do(func() {
+		srgbToLinearTable = make([]float32, 1<<16)
+		for i := range srgbToLinearTable {
+			c := float64(i) / 0xffff
+			if c <= 0.04045 {
+				c /= 12.92
+			} else {
+				c = math.Pow((c+0.055)/1.055, 2.4)
+			}
+			srgbToLinearTable[i] = float32(c)
+		}
+	})
+	return srgbToLinearTable[v]
+}
+
+// linearToSRGB converts a linear component to sRGB encoding.
+func linearToSRGB(c float32) float32 {
+	if c <= 0.0031308 {
+		return c * 12.92
+	}
+	return float32(1.055*math.Pow(float64(c), 1/2.4) - 0.055)
+}
+
+func newLinearImage(img image.Image, srgb bool) *linearImage {
+	b := img.Bounds()
+	m := &linearImage{b.Dx(), b.Dy(), make([]float32, 4*b.Dx()*b.Dy())}
+	var c channels
+	i := 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			colorChannels(img.At(x, y), StraightAlpha, &c)
+			a := float32(c[chA]) / 0xffff
+			for j := 0; j < 3; j++ {
+				v := float32(c[j]) / 0xffff
+				if srgb {
+					v = srgbToLinear(c[j])
+				}
+				m.pix[i+j] = v * a
+			}
+			m.pix[i+3] = a
+			i += 4
+		}
+	}
+	return m
+}
+
+// image converts m to straight alpha colors, sRGB encoded if srgb is set.
+func (m *linearImage) image(srgb bool) *image.NRGBA64 {
+	img := image.NewNRGBA64(image.Rect(0, 0, m.width, m.height))
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			p := m.pix[4*(y*m.width+x):]
+			a := clamp01(p[3])
+			var c [3]float32
+			if a > 0 {
+				for j := range c {
+					c[j] = clamp01(p[j] / a)
+					if srgb {
+						c[j] = linearToSRGB(c[j])
+					}
+				}
+			}
+			img.SetNRGBA64(x, y, color.NRGBA64{unorm16(c[0]), unorm16(c[1]), unorm16(c[2]), unorm16(a)})
+		}
+	}
+	return img
+}
+
+func clamp01(v float32) float32 {
+	switch {
+	case !(v > 0): // also NaN
+		return 0
+	case v > 1:
+		return 1
+	}
+	return v
+}
+
+// contribution lists the source pixels of a destination pixel and their
+// weights.
+type contribution struct {
+	index  []int
+	weight []float32
+}
+
+// add adds the weight w of source pixel i. Pixels are added in
+// ascending order.
+func (c *contribution) add(i int, w float32) {
+	if n := len(c.index); n > 0 && c.index[n-1] == i {
+		c.weight[n-1] += w
+		return
+	}
+	c.index = append(c.index, i)
+	c.weight = append(c.weight, w)
+}
+
+// clampIndex clamps i to [0, size-1].
+func clampIndex(i, size int) int {
+	switch {
+	case i < 0:
+		return 0
+	case i >= size:
+		return size - 1
+	}
+	return i
+}
+
+func lanczos3(x float64) float64 {
+	switch {
+	case x == 0:
+		return 1
+	case x <= -3 || x >= 3:
+		return 0
+	}
+	px := math.Pi * x
+	return 3 * math.Sin(px) * math.Sin(px/3) / (px * px)
+}
+
+// contributions computes the source pixels and weights of every
+// destination pixel when resampling srcSize pixels to dstSize pixels.
+// Pixels outside the source are clamped to the edge.
+func contributions(srcSize, dstSize int, filter Filter) []contribution {
+	scale := float64(srcSize) / float64(dstSize)
+	cs := make([]contribution, dstSize)
+	for i := range cs {
+		c := &cs[i]
+		if filter == BoxFilter {
+			// area of [lo, hi) covered by every source pixel
+			lo, hi := float64(i)*scale, float64(i+1)*scale
+			for j := int(lo); float64(j) < hi && j < srcSize; j++ {
+				if w := math.Min(hi, float64(j+1)) - math.Max(lo, float64(j)); w > 0 {
+					c.add(j, float32(w))
+				}
+			}
+		} else {
+			center := (float64(i)+0.5)*scale - 0.5
+			fscale := math.Max(scale, 1)
+			support := 3 * fscale
+			for j := int(math.Ceil(center - support)); float64(j) <= center+support; j++ {
+				if w := lanczos3((float64(j) - center) / fscale); w != 0 {
+					c.add(clampIndex(j, srcSize), float32(w))
+				}
+			}
+		}
+		var sum float32
+		for _, w := range c.weight {
+			sum += w
+		}
+		for k := range c.weight {
+			c.weight[k] /= sum
+		}
+	}
+	return cs
+}
+
+// resize resamples m to width*height pixels, horizontally then
+// vertically.
+func (m *linearImage) resize(width, height int, filter Filter) *linearImage {
+	tmp := &linearImage{width, m.height, make([]float32, 4*width*m.height)}
+	cs := contributions(m.width, width, filter)
+	for y := 0; y < m.height; y++ {
+		src, dest := m.pix[4*y*m.width:], tmp.pix[4*y*width:]
+		for x, c := range cs {
+			var p [4]float32
+			for k, j := range c.index {
+				w := c.weight[k]
+				p[0] += src[4*j] * w
+				p[1] += src[4*j+1] * w
+				p[2] += src[4*j+2] * w
+				p[3] += src[4*j+3] * w
+			}
+			copy(dest[4*x:], p[:])
+		}
+	}
+	out := &linearImage{width, height, make([]float32, 4*width*height)}
+	cs = contributions(m.height, height, filter)
+	for y, c := range cs {
+		dest := out.pix[4*y*width : 4*(y+1)*width]
+		for k, j := range c.index {
+			w := c.weight[k]
+			src := tmp.pix[4*j*width:]
+			for i := range dest {
+				dest[i] += src[i] * w
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,145 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package gltex
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func uniformImage(w, h int, c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func checkerboard(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if (x+y)%2 == 0 {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+	return img
+}
+
+type mipmapTest struct {
+	Img    image.Image
+	Opts   MipmapOptions
+	Sizes  [][2]int
+	Last   []byte // pixel data of the last level
+	Length int    // of the pixel data of level 0
+}
+
+var allTestsMipmap = []mipmapTest{
+	{checkerboard(4, 4), MipmapOptions{}, [][2]int{{4, 4}, {2, 2}, {1, 1}}, []byte{0x80, 0x80, 0x80, 0xff}, 64},
+	// sRGB gray of linear 0.5
+	{checkerboard(4, 4), MipmapOptions{SRGB: true}, [][2]int{{4, 4}, {2, 2}, {1, 1}}, []byte{0xbc, 0xbc, 0xbc, 0xff}, 64},
+	// 8 of 15 pixels are white, the last row is not padded
+	{checkerboard(5, 3), MipmapOptions{Format: glRGB, Type_: glUNSIGNED_BYTE}, [][2]int{{5, 3}, {2, 1}, {1, 1}}, []byte{0x88, 0x88, 0x88}, 2*16 + 15},
+	{checkerboard(5, 3), MipmapOptions{Format: glRGB, Type_: glUNSIGNED_BYTE, Store: TightlyPacked, Levels: 2}, [][2]int{{5, 3}, {2, 1}}, nil, 45},
+	{checkerboard(1, 7), MipmapOptions{Filter: LanczosFilter}, [][2]int{{1, 7}, {1, 3}, {1, 1}}, nil, 28},
+	// transparent pixels must not darken or tint
+	{testTransparent(), MipmapOptions{}, [][2]int{{2, 2}, {1, 1}}, []byte{0xff, 0, 0, 0x80}, 16},
+	{testTransparent(), MipmapOptions{Alpha: PremultipliedAlpha}, [][2]int{{2, 2}, {1, 1}}, []byte{0x80, 0, 0, 0x80}, 16},
+	{uniformImage(13, 6, color.NRGBA{10, 200, 30, 255}), MipmapOptions{Filter: LanczosFilter, SRGB: true}, [][2]int{{13, 6}, {6, 3}, {3, 1}, {1, 1}}, []byte{10, 200, 30, 255}, 13 * 6 * 4},
+}
+
+// testTransparent returns an image with two red pixels and two
+// transparent green pixels.
+func testTransparent() image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.NRGBA{0xff, 0, 0, 0xff})
+	img.Set(1, 1, color.NRGBA{0xff, 0, 0, 0xff})
+	img.Set(1, 0, color.NRGBA{0, 0xff, 0, 0})
+	img.Set(0, 1, color.NRGBA{0, 0xff, 0, 0})
+	return img
+}
+
+func TestGenerateMipmaps(t *testing.T) {
+	for i, te := range allTestsMipmap {
+		levels, err := GenerateMipmaps(te.Img, te.Opts)
+		if err != nil {
+			t.Errorf("test %d: GenerateMipmaps() failed: %s", i, err)
+			continue
+		}
+		if len(levels) != len(te.Sizes) {
+			t.Errorf("test %d: GenerateMipmaps() = %d levels, expected %d", i, len(levels), len(te.Sizes))
+			continue
+		}
+		for j, l := range levels {
+			if l.Width != te.Sizes[j][0] || l.Height != te.Sizes[j][1] {
+				t.Errorf("test %d: level %d is %dx%d, expected %v", i, j, l.Width, l.Height, te.Sizes[j])
+			}
+		}
+		if n := len(levels[0].Data); n != te.Length {
+			t.Errorf("test %d: level 0 has %d bytes, expected %d", i, n, te.Length)
+		}
+		if last := levels[len(levels)-1].Data; te.Last != nil && !bytes.Equal(last, te.Last) {
+			t.Errorf("test %d: last level = %v, expected %v", i, last, te.Last)
+		}
+	}
+	if _, err := GenerateMipmaps(image.NewNRGBA(image.Rect(0, 0, 0, 0)), MipmapOptions{}); err == nil {
+		t.Errorf("GenerateMipmaps() of an empty image succeeded")
+	}
+	if _, err := GenerateMipmaps(checkerboard(2, 2), MipmapOptions{Format: 0x1234, Type_: glUNSIGNED_BYTE}); err != ErrUnsupported {
+		t.Errorf("GenerateMipmaps() with unknown format = %v", err)
+	}
+}
+
+func TestMipmapLevels(t *testing.T) {
+	for _, te := range [][3]int{{1, 1, 1}, {2, 1, 2}, {256, 256, 9}, {257, 3, 9}, {1, 1000, 10}} {
+		if n := MipmapLevels(te[0], te[1]); n != te[2] {
+			t.Errorf("MipmapLevels(%d, %d) = %d, expected %d", te[0], te[1], n, te[2])
+		}
+	}
+}
+
+func TestContributions(t *testing.T) {
+	// 5 pixels to 2: the middle pixel is shared
+	cs := contributions(5, 2, BoxFilter)
+	if len(cs[0].index) != 3 || cs[0].index[2] != 2 || cs[0].weight[2] != 0.2 || cs[1].index[0] != 2 || cs[1].weight[0] != 0.2 {
+		t.Errorf("contributions(5, 2) = %+v", cs)
+	}
+	for _, filter := range []Filter{BoxFilter, LanczosFilter} {
+		for _, sizes := range [][2]int{{8, 4}, {7, 3}, {3, 7}, {1000, 1}} {
+			for _, c := range contributions(sizes[0], sizes[1], filter) {
+				var sum float32
+				for k, j := range c.index {
+					sum += c.weight[k]
+					if j < 0 || j >= sizes[0] {
+						t.Errorf("contributions(%v, %d) uses pixel %d", sizes, filter, j)
+					}
+				}
+				if sum < 0.9999 || sum > 1.0001 {
+					t.Errorf("contributions(%v, %d) weights sum to %g", sizes, filter, sum)
+				}
+			}
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	img := Resize(uniformImage(3, 3, color.NRGBA{1, 2, 3, 4}), 7, 5, LanczosFilter, false)
+	if b := img.Bounds(); b.Dx() != 7 || b.Dy() != 5 {
+		t.Fatalf("Resize() = %v", b)
+	}
+	if c := img.NRGBA64At(3, 2); c.R>>8 != 1 || c.G>>8 != 2 || c.B>>8 != 3 || c.A>>8 != 4 {
+		t.Errorf("Resize() of a uniform image = %v", c)
+	}
+	if img := Resize(uniformImage(3, 3, color.White), -1, 5, BoxFilter, false); !img.Bounds().Empty() {
+		t.Errorf("Resize() to a negative width = %v", img.Bounds())
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package gltex
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/chsc/gogl2/glt"
+)
+
+// Channels of a pixel. Conversions store each channel as a 16 bit value.
+const (
+	chR = iota
+	chG
+	chB
+	chA
+	chL // luminance
+	numChannels
+)
+
+type channels [numChannels]uint16
+
+// formatChannels lists the channel of each component of a format.
+var formatChannels = map[glt.Enum][]int{
+	glRED:             {chR},
+	glRG:              {chR, chG},
+	glRGB:             {chR, chG, chB},
+	glBGR:             {chB, chG, chR},
+	glRGBA:            {chR, chG, chB, chA},
+	glBGRA:            {chB, chG, chR, chA},
+	glALPHA:           {chA},
+	glLUMINANCE:       {chL},
+	glLUMINANCE_ALPHA: {chL, chA},
+}
+
+// componentSizes holds the size in bytes of one component of the
+// unpacked types.
+var componentSizes = map[glt.Enum]int{
+	glUNSIGNED_BYTE:  1,
+	glUNSIGNED_SHORT: 2,
+	glHALF_FLOAT:     2,
+	glFLOAT:          4,
+}
+
+// packedType describes a type that packs all components of a pixel into
+// one integer: the bit offset and width of each component.
+type packedType struct {
+	size  int // bytes per pixel
+	shift []uint
+	bits  []uint
+}
+
+var packedTypes = map[glt.Enum]*packedType{
+	glUNSIGNED_SHORT_5_6_5:        {2, []uint{11, 5, 0}, []uint{5, 6, 5}},
+	glUNSIGNED_SHORT_4_4_4_4:      {2, []uint{12, 8, 4, 0}, []uint{4, 4, 4, 4}},
+	glUNSIGNED_SHORT_5_5_5_1:      {2, []uint{11, 6, 1, 0}, []uint{5, 5, 5, 1}},
+	glUNSIGNED_INT_2_10_10_10_REV: {4, []uint{0, 10, 20, 30}, []uint{10, 10, 10, 2}},
+}
+
+// pixelLayout describes how the pixels of a format and type are stored.
+type pixelLayout struct {
+	channels []int
+	type_    glt.Enum
+	packed   *packedType // nil for unpacked types
+	size     int         // bytes per pixel
+}
+
+func newPixelLayout(format, type_ glt.Enum) (*pixelLayout, error) {
+	chs, ok := formatChannels[format]
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	l := &pixelLayout{channels: chs, type_: type_}
+	if p, ok := packedTypes[type_]; ok {
+		if len(p.bits) != len(chs) {
+			return nil, ErrUnsupported
+		}
+		l.packed, l.size = p, p.size
+		return l, nil
+	}
+	size, ok := componentSizes[type_]
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	l.size = size * len(chs)
+	return l, nil
+}
+
+// deep reports whether the components of the layout have more than 8 bits.
+func (l *pixelLayout) deep() bool {
+	if l.packed != nil {
+		for _, b := range l.packed.bits {
+			if b > 8 {
+				return true
+			}
+		}
+		return false
+	}
+	return l.type_ != glUNSIGNED_BYTE
+}
+
+// hasChannel reports whether the layout stores channel ch.
+func (l *pixelLayout) hasChannel(ch int) bool {
+	for _, c := range l.channels {
+		if c == ch {
+			return true
+		}
+	}
+	return false
+}
+
+// decode reads the pixel at the start of p into the channels of c.
+// Floating point components are clamped to [0, 1].
+func (l *pixelLayout) decode(p []byte, c *channels) {
+	if l.packed != nil {
+		var v uint32
+		if l.packed.size == 2 {
+			v = uint32(nativeEndian.Uint16(p))
+		} else {
+			v = nativeEndian.Uint32(p)
+		}
+		for i, ch := range l.channels {
+			max := uint32(1)<<l.packed.bits[i] - 1
+			c[ch] = uint16(((v>>l.packed.shift[i])&max*0xffff + max/2) / max)
+		}
+		return
+	}
+	for i, ch := range l.channels {
+		switch l.type_ {
+		case glUNSIGNED_BYTE:
+			c[ch] = uint16(p[i]) * 0x101
+		case glUNSIGNED_SHORT:
+			c[ch] = nativeEndian.Uint16(p[2*i:])
+		case glHALF_FLOAT:
+			c[ch] = unorm16(halfToFloat32(nativeEndian.Uint16(p[2*i:])))
+		case glFLOAT:
+			c[ch] = unorm16(math.Float32frombits(nativeEndian.Uint32(p[4*i:])))
+		}
+	}
+}
+
+// encode writes the channels of c as a pixel to the start of p.
+func (l *pixelLayout) encode(p []byte, c *channels) {
+	if l.packed != nil {
+		var v uint32
+		for i, ch := range l.channels {
+			max := uint32(1)<<l.packed.bits[i] - 1
+			v |= (uint32(c[ch])*max + 0x7fff) / 0xffff << l.packed.shift[i]
+		}
+		if l.packed.size == 2 {
+			nativeEndian.PutUint16(p, uint16(v))
+		} else {
+			nativeEndian.PutUint32(p, v)
+		}
+		return
+	}
+	for i, ch := range l.channels {
+		switch l.type_ {
+		case glUNSIGNED_BYTE:
+			p[i] = uint8(c[ch] >> 8)
+		case glUNSIGNED_SHORT:
+			nativeEndian.PutUint16(p[2*i:], c[ch])
+		case glHALF_FLOAT:
+			nativeEndian.PutUint16(p[2*i:], float32ToHalf(float32(c[ch])/0xffff))
+		case glFLOAT:
+			nativeEndian.PutUint32(p[4*i:], math.Float32bits(float32(c[ch])/0xffff))
+		}
+	}
+}
+
+// colorChannels stores the channels of col in c, with straight or
+// premultiplied alpha.
+func colorChannels(col color.Color, alpha AlphaMode, c *channels) {
+	var r, g, b, a uint32
+	switch n := col.(type) {
+	case nil: // e.g. a paletted image with a too short palette
+		*c = channels{}
+		return
+	case color.NRGBA:
+		if alpha == StraightAlpha {
+			r, g, b, a = uint32(n.R)*0x101, uint32(n.G)*0x101, uint32(n.B)*0x101, uint32(n.A)*0x101
+			break
+		}
+		r, g, b, a = n.RGBA()
+	case color.NRGBA64:
+		if alpha == StraightAlpha {
+			r, g, b, a = uint32(n.R), uint32(n.G), uint32(n.B), uint32(n.A)
+			break
+		}
+		r, g, b, a = n.RGBA()
+	default:
+		if alpha == StraightAlpha {
+			n := color.NRGBA64Model.Convert(col).(color.NRGBA64)
+			r, g, b, a = uint32(n.R), uint32(n.G), uint32(n.B), uint32(n.A)
+			break
+		}
+		r, g, b, a = col.RGBA()
+	}
+	c[chR], c[chG], c[chB], c[chA] = uint16(r), uint16(g), uint16(b), uint16(a)
+	// same weights as color.Gray16Model
+	c[chL] = uint16((19595*r + 38470*g + 7471*b + 1<<15) >> 16)
+}
+
+// unorm16 converts f from [0, 1] to a 16 bit value.
+func unorm16(f float32) uint16 {
+	switch {
+	case !(f > 0): // also NaN
+		return 0
+	case f >= 1:
+		return 0xffff
+	}
+	return uint16(f*0xffff + 0.5)
+}
+
+// halfToFloat32 converts an IEEE 754 half precision float.
+func halfToFloat32(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+	switch {
+	case exp == 0x1f: // infinity and NaN
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	case exp == 0: // zero and subnormal
+		f := float32(mant) / (1 << 24)
+		if sign != 0 {
+			f = -f
+		}
+		return f
+	}
+	return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+}
+
+// float32ToHalf converts f to an IEEE 754 half precision float, rounding
+// to nearest even.
+func float32ToHalf(f float32) uint16 {
+	b := math.Float32bits(f)
+	sign := uint16(b>>16) & 0x8000
+	exp := int(b>>23&0xff) - 127 + 15
+	mant := b & 0x7fffff
+	switch {
+	case b&0x7fffffff > 0x7f800000: // NaN
+		return sign | 0x7e00
+	case exp >= 0x1f: // overflow and infinity
+		return sign | 0x7c00
+	case exp <= 0: // subnormal or zero
+		if exp < -10 {
+			return sign
+		}
+		mant |= 0x800000
+		shift := uint(14 - exp)
+		h := mant >> shift
+		rem, half := mant&(1<<shift-1), uint32(1)<<(shift-1)
+		if rem > half || rem == half && h&1 != 0 {
+			h++
+		}
+		return sign | uint16(h)
+	}
+	h := uint32(exp)<<10 | mant>>13
+	if rem := mant & 0x1fff; rem > 0x1000 || rem == 0x1000 && h&1 != 0 {
+		h++ // may round up to infinity
+	}
+	return sign | uint16(h)
+}
@@ -0,0 +1,114 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package gltex
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/chsc/gogl2/glt"
+)
+
+type encodeTest struct {
+	Color         color.Color
+	Alpha         AlphaMode
+	Format, Type_ glt.Enum
+	Pixel         uint64 // the components or the packed pixel, first component in the low bits
+}
+
+var allTestsEncode = []encodeTest{
+	{color.NRGBA{0xff, 0, 0, 0xff}, StraightAlpha, glRGB, glUNSIGNED_SHORT_5_6_5, 0xf800},
+	{color.NRGBA{0, 0xff, 0, 0xff}, StraightAlpha, glRGB, glUNSIGNED_SHORT_5_6_5, 0x07e0},
+	{color.NRGBA{0, 0, 0xff, 0xff}, StraightAlpha, glBGR, glUNSIGNED_SHORT_5_6_5, 0xf800},
+	{color.NRGBA{0x80, 0x80, 0x80, 0x80}, StraightAlpha, glRGBA, glUNSIGNED_SHORT_4_4_4_4, 0x8888},
+	{color.NRGBA{0xff, 0, 0, 0xff}, StraightAlpha, glRGBA, glUNSIGNED_SHORT_5_5_5_1, 0xf801},
+	{color.NRGBA{0xff, 0, 0, 0x00}, StraightAlpha, glRGBA, glUNSIGNED_SHORT_5_5_5_1, 0xf800},
+	{color.NRGBA{0xff, 0, 0, 0xff}, StraightAlpha, glRGBA, glUNSIGNED_INT_2_10_10_10_REV, 0xc00003ff},
+	{color.NRGBA{0xff, 0, 0, 0xff}, StraightAlpha, glBGRA, glUNSIGNED_INT_2_10_10_10_REV, 0xfff00000},
+	{color.NRGBA{1, 2, 3, 4}, StraightAlpha, glBGRA, glUNSIGNED_BYTE, 0x04010203},
+	{color.NRGBA{0xff, 0, 0x40, 0x80}, StraightAlpha, glRGBA, glUNSIGNED_BYTE, 0x804000ff},
+	{color.NRGBA{0xff, 0, 0x40, 0x80}, PremultipliedAlpha, glRGBA, glUNSIGNED_BYTE, 0x80200080},
+	{color.RGBA{0x40, 0, 0x20, 0x80}, StraightAlpha, glRGBA, glUNSIGNED_BYTE, 0x803f007f}, // color.NRGBA64Model truncates
+	{color.NRGBA{0x80, 0x40, 0x20, 0x10}, StraightAlpha, glLUMINANCE_ALPHA, glUNSIGNED_BYTE, 0x104f},
+	{color.Gray{0x33}, StraightAlpha, glLUMINANCE, glUNSIGNED_BYTE, 0x33},
+	{color.NRGBA{0xff, 0, 0, 0x80}, StraightAlpha, glRG, glHALF_FLOAT, 0x3c00},
+	{color.NRGBA{0x80, 0, 0, 0xff}, StraightAlpha, glALPHA, glFLOAT, uint64(math.Float32bits(1))},
+}
+
+func TestEncode(t *testing.T) {
+	for _, te := range allTestsEncode {
+		l, err := newPixelLayout(te.Format, te.Type_)
+		if err != nil {
+			t.Errorf("newPixelLayout(%#x, %#x) failed: %s", te.Format, te.Type_, err)
+			continue
+		}
+		p := make([]byte, l.size)
+		var c channels
+		colorChannels(te.Color, te.Alpha, &c)
+		l.encode(p, &c)
+		var pixel uint64
+		switch {
+		case l.packed != nil && l.size == 2:
+			pixel = uint64(nativeEndian.Uint16(p))
+		case l.packed != nil:
+			pixel = uint64(nativeEndian.Uint32(p))
+		default:
+			size := l.size / len(l.channels)
+			for i := len(l.channels) - 1; i >= 0; i-- {
+				var v uint64
+				switch size {
+				case 1:
+					v = uint64(p[i])
+				case 2:
+					v = uint64(nativeEndian.Uint16(p[2*i:]))
+				case 4:
+					v = uint64(nativeEndian.Uint32(p[4*i:]))
+				}
+				pixel = pixel<<uint(8*size) | v
+			}
+		}
+		if pixel != te.Pixel {
+			t.Errorf("encode(%v, %#x, %#x) = %#x, expected %#x", te.Color, te.Format, te.Type_, pixel, te.Pixel)
+		}
+		var d channels
+		l.decode(p, &d)
+		l.encode(p, &d)
+		var c2 channels
+		l.decode(p, &c2)
+		if c2 != d {
+			t.Errorf("decode(%#x, %#x) of %#x is not stable: %v, %v", te.Format, te.Type_, pixel, d, c2)
+		}
+	}
+}
+
+func TestHalf(t *testing.T) {
+	for h := 0; h < 0x10000; h++ {
+		f := halfToFloat32(uint16(h))
+		if f != f {
+			if h&0x7c00 != 0x7c00 || h&0x3ff == 0 {
+				t.Errorf("halfToFloat32(%#x) = NaN", h)
+			}
+			continue
+		}
+		if r := float32ToHalf(f); r != uint16(h) {
+			t.Errorf("float32ToHalf(halfToFloat32(%#x)) = %#x", h, r)
+		}
+	}
+	for _, te := range []struct {
+		F float32
+		H uint16
+	}{
+		{1, 0x3c00}, {0.5, 0x3800}, {-2, 0xc000}, {65504, 0x7bff}, {65520, 0x7c00},
+		{1.0 / (1 << 24), 0x0001}, {1.0 / (1 << 25), 0x0000}, {1 + 1.0/2048, 0x3c00}, {1 + 3.0/2048, 0x3c02},
+	} {
+		if h := float32ToHalf(te.F); h != te.H {
+			t.Errorf("float32ToHalf(%g) = %#x, expected %#x", te.F, h, te.H)
+		}
+	}
+	if v := unorm16(halfToFloat32(0x7e00)); v != 0 {
+		t.Errorf("unorm16(NaN) = %d", v)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package gltex
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"runtime"
+	"unsafe"
+
+	"github.com/chsc/gogl2/glt"
+)
+
+var packParams = [4]glt.Enum{glPACK_ALIGNMENT, glPACK_ROW_LENGTH, glPACK_SKIP_PIXELS, glPACK_SKIP_ROWS}
+
+// syncTimeout is the time in nanoseconds ClientWaitSync waits before
+// it is called again.
+const syncTimeout = 1e9
+
+// ReadImage reads the width x height rectangle of the read buffer with
+// the lower left corner x, y in window coordinates into an image, with
+// the top row of the rectangle as its first row. The components are read
+// as 8 bit RGBA values with straight alpha. ReadImage must not be called
+// while a pixel pack buffer is bound.
+func (gl *GL) ReadImage(x, y, width, height int) (*image.NRGBA, error) {
+	pixels, err := gl.readPixels(x, y, width, height, glUNSIGNED_BYTE)
+	if err != nil {
+		return nil, err
+	}
+	img, err := ImageFromPixelData(glRGBA, glUNSIGNED_BYTE, pixels, width, height)
+	if err != nil {
+		return nil, err
+	}
+	return img.(*image.NRGBA), nil
+}
+
+// ReadImage64 is like ReadImage but reads 16 bit components, for
+// framebuffers with more than 8 bits per component. The framebuffer is
+// taken to hold premultiplied colors.
+func (gl *GL) ReadImage64(x, y, width, height int) (*image.RGBA64, error) {
+	pixels, err := gl.readPixels(x, y, width, height, glUNSIGNED_SHORT)
+	if err != nil {
+		return nil, err
+	}
+	img, err := ConvertPixelData(glRGBA, glUNSIGNED_SHORT, PremultipliedAlpha, TightlyPacked, pixels, width, height)
+	if err != nil {
+		return nil, err
+	}
+	return img.(*image.RGBA64), nil
+}
+
+func (gl *GL) readPixels(x, y, width, height int, type_ glt.Enum) ([]byte, error) {
+	if width < 0 || height < 0 {
+		return nil, fmt.Errorf("gltex: invalid size %dx%d", width, height)
+	}
+	size, err := TightlyPacked.Size(glRGBA, type_, width, height)
+	if err != nil {
+		return nil, err
+	}
+	pixels := make([]byte, size)
+	restore := gl.pixelStore(&packParams, TightlyPacked)
+	gl.ReadPixels(int32(x), int32(y), int32(width), int32(height), glRGBA, type_, glt.SlicePtr(pixels))
+	runtime.KeepAlive(pixels)
+	restore()
+	return pixels, nil
+}
+
+// SelectReadBuffer binds framebuffer to READ_FRAMEBUFFER and selects
+// attachment, such as COLOR_ATTACHMENT0, as its read buffer. It returns a
+// function that restores the previous read buffer and binding:
+//
+//	defer gl.SelectReadBuffer(fbo, gl.COLOR_ATTACHMENT0)()
+func (gl *GL) SelectReadBuffer(framebuffer uint32, attachment glt.Enum) (restore func()) {
+	var oldFramebuffer, oldBuffer int32
+	gl.GetIntegerv(glREAD_FRAMEBUFFER_BINDING, &oldFramebuffer)
+	gl.BindFramebuffer(glREAD_FRAMEBUFFER, framebuffer)
+	// the read buffer is state of the framebuffer
+	gl.GetIntegerv(glREAD_BUFFER, &oldBuffer)
+	gl.ReadBuffer(attachment)
+	return func() {
+		gl.BindFramebuffer(glREAD_FRAMEBUFFER, framebuffer)
+		gl.ReadBuffer(glt.Enum(oldBuffer))
+		gl.BindFramebuffer(glREAD_FRAMEBUFFER, uint32(oldFramebuffer))
+	}
+}
+
+// AsyncReader reads the framebuffer into pixel buffer objects without
+// waiting for the GPU: Read starts a readback and returns the image of
+// the previous one, which has usually completed by the next frame. Use
+// it to capture video.
+type AsyncReader struct {
+	gl      *GL
+	buffers [2]uint32
+	reads   [2]asyncRead
+	next    int // index of the next read
+}
+
+type asyncRead struct {
+	sync          glt.Pointer // nil if no read is pending
+	width, height int
+	size          int // size of the buffer
+}
+
+// NewAsyncReader creates the pixel buffer objects of an AsyncReader.
+// Call Close to delete them.
+func (gl *GL) NewAsyncReader() *AsyncReader {
+	r := &AsyncReader{gl: gl}
+	gl.GenBuffers(int32(len(r.buffers)), &r.buffers[0])
+	return r
+}
+
+// Read starts reading the rectangle of the read buffer as ReadImage does
+// and returns the image of the previous call to Read, nil on the first
+// call.
+func (r *AsyncReader) Read(x, y, width, height int) (*image.NRGBA, error) {
+	if width < 0 || height < 0 {
+		return nil, fmt.Errorf("gltex: invalid size %dx%d", width, height)
+	}
+	gl, i := r.gl, r.next
+	read := &r.reads[i]
+	restoreBuffer := r.bindBuffer(i)
+	read.width, read.height = width, height
+	if size := width * height * 4; size > read.size {
+		gl.BufferData(glPIXEL_PACK_BUFFER, size, nil, glSTREAM_READ)
+		read.size = size
+	}
+	restore := gl.pixelStore(&packParams, TightlyPacked)
+	gl.ReadPixels(int32(x), int32(y), int32(width), int32(height), glRGBA, glUNSIGNED_BYTE, nil) // offset 0 into the buffer
+	restore()
+	read.sync = gl.FenceSync(glSYNC_GPU_COMMANDS_COMPLETE, 0)
+	restoreBuffer()
+	r.next = 1 - i
+	return r.finish(r.next)
+}
+
+// Flush waits for the pending read and returns its image, nil if there
+// is none.
+func (r *AsyncReader) Flush() (*image.NRGBA, error) {
+	return r.finish(1 - r.next)
+}
+
+// Close deletes the pixel buffer objects and the fences of pending
+// reads.
+func (r *AsyncReader) Close() {
+	for i := range r.reads {
+		if r.reads[i].sync != nil {
+			r.gl.DeleteSync(r.reads[i].sync)
+			r.reads[i].sync = nil
+		}
+	}
+	r.gl.DeleteBuffers(int32(len(r.buffers)), &r.buffers[0])
+}
+
+// bindBuffer binds buffer i to PIXEL_PACK_BUFFER and returns a function
+// that restores the previous binding.
+func (r *AsyncReader) bindBuffer(i int) (restore func()) {
+	var old int32
+	r.gl.GetIntegerv(glPIXEL_PACK_BUFFER_BINDING, &old)
+	r.gl.BindBuffer(glPIXEL_PACK_BUFFER, r.buffers[i])
+	return func() { r.gl.BindBuffer(glPIXEL_PACK_BUFFER, uint32(old)) }
+}
+
+// finish waits for read i and returns its image.
+func (r *AsyncReader) finish(i int) (*image.NRGBA, error) {
+	gl, read := r.gl, &r.reads[i]
+	if read.sync == nil {
+		return nil, nil
+	}
+	status := glTIMEOUT_EXPIRED
+	for status == glTIMEOUT_EXPIRED {
+		status = gl.ClientWaitSync(read.sync, glSYNC_FLUSH_COMMANDS_BIT, syncTimeout)
+	}
+	gl.DeleteSync(read.sync)
+	read.sync = nil
+	if status == glWAIT_FAILED {
+		return nil, errors.New("gltex: waiting for the pixel buffer failed")
+	}
+	defer r.bindBuffer(i)()
+	size := read.width * read.height * 4
+	var pixels []byte
+	if size > 0 {
+		p := gl.MapBuffer(glPIXEL_PACK_BUFFER, glREAD_ONLY)
+		if p == nil {
+			return nil, errors.New("gltex: mapping the pixel buffer failed")
+		}
+		pixels = unsafe.Slice((*byte)(p), size)
+	}
+	img, err := ImageFromPixelData(glRGBA, glUNSIGNED_BYTE, pixels, read.width, read.height)
+	if size > 0 && !gl.UnmapBuffer(glPIXEL_PACK_BUFFER) {
+		// the data store was lost, as on a mode switch
+		return nil, errors.New("gltex: pixel buffer was corrupted")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return img.(*image.NRGBA), nil
+}
@@ -0,0 +1,243 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package gltex
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"unsafe"
+
+	"github.com/chsc/gogl2/glt"
+)
+
+// boundPackBuffer is bound to PIXEL_PACK_BUFFER before the AsyncReader
+// test.
+const boundPackBuffer uint32 = 9
+
+// fakeFramebuffer adds a framebuffer, pixel buffer objects and fences to
+// a fake GL. The framebuffer changes with every frame.
+type fakeFramebuffer struct {
+	*fakeGL
+	frame       int
+	readBuffers map[uint32]glt.Enum // read buffer of each framebuffer
+	buffers     map[uint32][]byte
+	syncs       map[glt.Pointer]int // number of waits
+	names       uint32
+	pack        [4]int32 // pack state during ReadPixels
+}
+
+// fakePixel returns the color of the framebuffer at x, y.
+func fakePixel(x, y, frame int) color.RGBA64 {
+	v := uint16(x*0x1111 + y*0x0707 + frame*0x3030)
+	return color.RGBA64{v, v ^ 0xffff, 0x8080, 0xffff}
+}
+
+func newFakeFramebuffer() (*fakeFramebuffer, *GL) {
+	f, gl := newFakeGL(map[glt.Enum]int32{glPACK_ALIGNMENT: 4})
+	fb := &fakeFramebuffer{
+		fakeGL:      f,
+		readBuffers: map[uint32]glt.Enum{0: glBACK},
+		buffers:     map[uint32][]byte{},
+		syncs:       map[glt.Pointer]int{},
+		names:       100,
+	}
+	getIntegerv := gl.GetIntegerv
+	gl.GetIntegerv = func(pname glt.Enum, data *int32) {
+		if pname == glREAD_BUFFER {
+			*data = int32(fb.readBuffers[uint32(f.state[glREAD_FRAMEBUFFER_BINDING])])
+			return
+		}
+		getIntegerv(pname, data)
+	}
+	gl.ReadPixels = func(x, y, width, height int32, format, type_ glt.Enum, pixels glt.Pointer) {
+		for i, pname := range packParams {
+			fb.pack[i] = f.state[pname]
+		}
+		size := 4
+		if type_ == glUNSIGNED_SHORT {
+			size = 8
+		}
+		n := int(width*height) * size
+		var data []byte
+		if b := uint32(f.state[glPIXEL_PACK_BUFFER_BINDING]); b != 0 {
+			data = fb.buffers[b][uintptr(pixels):]
+		} else if n > 0 {
+			data = unsafe.Slice((*byte)(pixels), n)
+		}
+		for j := 0; j < int(height); j++ {
+			for i := 0; i < int(width); i++ {
+				c := fakePixel(int(x)+i, int(y)+j, fb.frame)
+				for k, v := range []uint16{c.R, c.G, c.B, c.A} {
+					o := (j*int(width)+i)*size + k*size/4
+					if size == 4 {
+						data[o] = byte(v >> 8)
+					} else {
+						nativeEndian.PutUint16(data[o:], v)
+					}
+				}
+			}
+		}
+	}
+	gl.BindFramebuffer = func(target glt.Enum, framebuffer uint32) {
+		f.state[glREAD_FRAMEBUFFER_BINDING] = int32(framebuffer)
+	}
+	gl.ReadBuffer = func(src glt.Enum) {
+		fb.readBuffers[uint32(f.state[glREAD_FRAMEBUFFER_BINDING])] = src
+	}
+	gl.GenBuffers = func(n int32, buffers *uint32) {
+		names := unsafe.Slice(buffers, n)
+		for i := range names {
+			fb.names++
+			names[i] = fb.names
+			fb.buffers[fb.names] = nil
+		}
+	}
+	gl.DeleteBuffers = func(n int32, buffers *uint32) {
+		for _, b := range unsafe.Slice(buffers, n) {
+			delete(fb.buffers, b)
+		}
+	}
+	gl.BindBuffer = func(target glt.Enum, buffer uint32) {
+		f.state[glPIXEL_PACK_BUFFER_BINDING] = int32(buffer)
+	}
+	gl.BufferData = func(target glt.Enum, size int, data glt.Pointer, usage glt.Enum) {
+		fb.buffers[uint32(f.state[glPIXEL_PACK_BUFFER_BINDING])] = make([]byte, size)
+	}
+	gl.MapBuffer = func(target, access glt.Enum) glt.Pointer {
+		return glt.SlicePtr(fb.buffers[uint32(f.state[glPIXEL_PACK_BUFFER_BINDING])])
+	}
+	gl.UnmapBuffer = func(target glt.Enum) bool { return true }
+	gl.FenceSync = func(condition glt.Enum, flags glt.Bitfield) glt.Pointer {
+		sync := glt.Pointer(new(byte))
+		fb.syncs[sync] = 0
+		return sync
+	}
+	gl.ClientWaitSync = func(sync glt.Pointer, flags glt.Bitfield, timeout uint64) glt.Enum {
+		// time out once
+		if fb.syncs[sync]++; fb.syncs[sync] == 1 {
+			return glTIMEOUT_EXPIRED
+		}
+		return glALREADY_SIGNALED
+	}
+	gl.DeleteSync = func(sync glt.Pointer) { delete(fb.syncs, sync) }
+	return fb, gl
+}
+
+// checkFramebuffer checks that img holds the rectangle of frame with the
+// lower left corner x, y, top row first.
+func checkFramebuffer(t *testing.T, name string, img image.Image, x, y, width, height, frame int) {
+	b := img.Bounds()
+	if b.Dx() != width || b.Dy() != height {
+		t.Errorf("%s: size %dx%d, want %dx%d", name, b.Dx(), b.Dy(), width, height)
+		return
+	}
+	m := img.ColorModel()
+	for j := 0; j < height; j++ {
+		for i := 0; i < width; i++ {
+			want := m.Convert(fakePixel(x+i, y+height-1-j, frame))
+			if got := img.At(b.Min.X+i, b.Min.Y+j); got != want {
+				t.Errorf("%s: pixel %d,%d = %v, want %v", name, i, j, got, want)
+				return
+			}
+		}
+	}
+}
+
+type readImageTest struct {
+	X, Y, Width, Height int
+}
+
+var allTestsReadImage = []readImageTest{
+	{0, 0, 4, 4},
+	{3, 5, 7, 2},
+	{1, 1, 1, 9},
+	{2, 2, 0, 0},
+}
+
+func TestReadImage(t *testing.T) {
+	for i, test := range allTestsReadImage {
+		fb, gl := newFakeFramebuffer()
+		img, err := gl.ReadImage(test.X, test.Y, test.Width, test.Height)
+		if err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		checkFramebuffer(t, "ReadImage", img, test.X, test.Y, test.Width, test.Height, 0)
+		if fb.pack != [4]int32{1, 0, 0, 0} {
+			t.Errorf("test %d: pack state %v", i, fb.pack)
+		}
+		if fb.state[glPACK_ALIGNMENT] != 4 {
+			t.Errorf("test %d: pack alignment not restored", i)
+		}
+		img64, err := gl.ReadImage64(test.X, test.Y, test.Width, test.Height)
+		if err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		checkFramebuffer(t, "ReadImage64", img64, test.X, test.Y, test.Width, test.Height, 0)
+	}
+	_, gl := newFakeFramebuffer()
+	if _, err := gl.ReadImage(0, 0, -1, 1); err == nil {
+		t.Errorf("ReadImage() of negative size succeeded")
+	}
+}
+
+func TestSelectReadBuffer(t *testing.T) {
+	fb, gl := newFakeFramebuffer()
+	fb.readBuffers[5] = glCOLOR_ATTACHMENT1
+	restore := gl.SelectReadBuffer(5, glCOLOR_ATTACHMENT0)
+	if fb.state[glREAD_FRAMEBUFFER_BINDING] != 5 || fb.readBuffers[5] != glCOLOR_ATTACHMENT0 {
+		t.Errorf("framebuffer %d with read buffer %#x selected", fb.state[glREAD_FRAMEBUFFER_BINDING], fb.readBuffers[5])
+	}
+	restore()
+	if fb.state[glREAD_FRAMEBUFFER_BINDING] != 0 || fb.readBuffers[5] != glCOLOR_ATTACHMENT1 || fb.readBuffers[0] != glBACK {
+		t.Errorf("framebuffer %d with read buffers %v after restore", fb.state[glREAD_FRAMEBUFFER_BINDING], fb.readBuffers)
+	}
+}
+
+func TestAsyncReader(t *testing.T) {
+	fb, gl := newFakeFramebuffer()
+	fb.state[glPIXEL_PACK_BUFFER_BINDING] = int32(boundPackBuffer)
+	r := gl.NewAsyncReader()
+	if len(fb.buffers) != 2 {
+		t.Fatalf("%d buffers created", len(fb.buffers))
+	}
+	for frame, test := range allTestsReadImage {
+		fb.frame = frame
+		img, err := r.Read(test.X, test.Y, test.Width, test.Height)
+		if err != nil {
+			t.Fatalf("frame %d: %v", frame, err)
+		}
+		if frame == 0 {
+			if img != nil {
+				t.Errorf("frame %d: image returned by first read", frame)
+			}
+			continue
+		}
+		prev := allTestsReadImage[frame-1]
+		checkFramebuffer(t, "Read", img, prev.X, prev.Y, prev.Width, prev.Height, frame-1)
+		if fb.state[glPIXEL_PACK_BUFFER_BINDING] != int32(boundPackBuffer) {
+			t.Errorf("frame %d: pixel pack buffer %d bound", frame, fb.state[glPIXEL_PACK_BUFFER_BINDING])
+		}
+		if len(fb.syncs) != 1 {
+			t.Errorf("frame %d: %d fences", frame, len(fb.syncs))
+		}
+	}
+	img, err := r.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := allTestsReadImage[len(allTestsReadImage)-1]
+	checkFramebuffer(t, "Flush", img, last.X, last.Y, last.Width, last.Height, len(allTestsReadImage)-1)
+	if img, err := r.Flush(); img != nil || err != nil {
+		t.Errorf("second Flush() = %v, %v", img, err)
+	}
+	r.Read(0, 0, 1, 1)
+	r.Close()
+	if len(fb.buffers) != 0 || len(fb.syncs) != 0 {
+		t.Errorf("%d buffers and %d fences left after Close", len(fb.buffers), len(fb.syncs))
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package gltex
+
+import (
+	"fmt"
+
+	"github.com/chsc/gogl2/glt"
+)
+
+// PixelStore holds the pixel storage parameters set with PixelStorei
+// that define the layout of pixel data in client memory: the PACK_
+// parameters for ReadPixels and GetTexImage, the UNPACK_ parameters for
+// TexImage2D and TexSubImage2D. The zero value is the initial GL state.
+type PixelStore struct {
+	Alignment  int // row alignment in bytes: 1, 2, 4 or 8, 0 for the default of 4
+	RowLength  int // pixels per row, 0 for the width of the image
+	SkipPixels int
+	SkipRows   int
+}
+
+// TightlyPacked stores rows without padding.
+var TightlyPacked = PixelStore{Alignment: 1}
+
+func (s PixelStore) alignment() int {
+	if s.Alignment == 0 {
+		return 4
+	}
+	return s.Alignment
+}
+
+func (s PixelStore) check() error {
+	switch s.Alignment {
+	case 0, 1, 2, 4, 8:
+	default:
+		return fmt.Errorf("gltex: invalid alignment %d", s.Alignment)
+	}
+	if s.RowLength < 0 || s.SkipPixels < 0 || s.SkipRows < 0 {
+		return fmt.Errorf("gltex: negative pixel store parameter %+v", s)
+	}
+	return nil
+}
+
+// stride returns the distance in bytes between the starts of two rows of
+// pixel data with layout l, computed as in section 8.4.4.1 of the GL 4.5
+// spec: rows are padded to a multiple of the alignment unless the size of
+// one element, a component or a packed pixel, is at least the alignment.
+func (s PixelStore) stride(l *pixelLayout, width int) int {
+	rowLength := s.RowLength
+	if rowLength == 0 {
+		rowLength = width
+	}
+	elemSize := l.size / len(l.channels)
+	if l.packed != nil {
+		elemSize = l.size
+	}
+	stride := rowLength * l.size
+	if a := s.alignment(); elemSize < a {
+		stride = (stride + a - 1) / a * a
+	}
+	return stride
+}
+
+// layout returns the offset of the first pixel and the stride of pixel
+// data with layout l and the minimum size of the data.
+func (s PixelStore) layout(l *pixelLayout, width, height int) (offset, stride, size int) {
+	stride = s.stride(l, width)
+	offset = s.SkipRows*stride + s.SkipPixels*l.size
+	if width > 0 && height > 0 {
+		size = offset + (height-1)*stride + width*l.size
+	}
+	return offset, stride, size
+}
+
+// Stride returns the distance in bytes between the starts of two rows of
+// width pixels of format and type_ in client memory.
+func (s PixelStore) Stride(format, type_ glt.Enum, width int) (int, error) {
+	if err := s.check(); err != nil {
+		return 0, err
+	}
+	l, err := newPixelLayout(format, type_)
+	if err != nil {
+		return 0, err
+	}
+	return s.stride(l, width), nil
+}
+
+// Size returns the minimum size in bytes of a buffer for ReadPixels of a
+// width*height rectangle of format and type_, including skipped rows and
+// pixels.
+func (s PixelStore) Size(format, type_ glt.Enum, width, height int) (int, error) {
+	if err := s.check(); err != nil {
+		return 0, err
+	}
+	l, err := newPixelLayout(format, type_)
+	if err != nil {
+		return 0, err
+	}
+	_, _, size := s.layout(l, width, height)
+	return size, nil
+}
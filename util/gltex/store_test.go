@@ -0,0 +1,139 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package gltex
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/chsc/gogl2/glt"
+)
+
+type strideTest struct {
+	Store         PixelStore
+	Format, Type_ glt.Enum
+	Width         int
+	Stride        int
+}
+
+var allTestsStride = []strideTest{
+	{PixelStore{}, glRGB, glUNSIGNED_BYTE, 3, 12},
+	{PixelStore{}, glRGB, glUNSIGNED_BYTE, 4, 12},
+	{PixelStore{}, glRGBA, glUNSIGNED_BYTE, 3, 12},
+	{PixelStore{}, glRED, glUNSIGNED_BYTE, 5, 8},
+	{TightlyPacked, glRGB, glUNSIGNED_BYTE, 3, 9},
+	{PixelStore{Alignment: 2}, glRGB, glUNSIGNED_BYTE, 3, 10},
+	{PixelStore{Alignment: 8}, glRGBA, glUNSIGNED_BYTE, 3, 16},
+	{PixelStore{}, glRGB, glUNSIGNED_SHORT, 3, 20},
+	{PixelStore{Alignment: 2}, glRGB, glUNSIGNED_SHORT, 3, 18},
+	{PixelStore{}, glRGB, glFLOAT, 1, 12}, // components are aligned already
+	{PixelStore{Alignment: 8}, glRGB, glFLOAT, 1, 16},
+	{PixelStore{}, glRGB, glUNSIGNED_SHORT_5_6_5, 3, 8},
+	{PixelStore{Alignment: 2}, glRGB, glUNSIGNED_SHORT_5_6_5, 3, 6},
+	{PixelStore{Alignment: 8}, glRGBA, glUNSIGNED_INT_2_10_10_10_REV, 3, 16},
+	{PixelStore{RowLength: 10}, glRGB, glUNSIGNED_BYTE, 3, 32},
+	{PixelStore{RowLength: 10, SkipPixels: 2, SkipRows: 1}, glRGB, glUNSIGNED_BYTE, 3, 32},
+}
+
+func TestStride(t *testing.T) {
+	for _, te := range allTestsStride {
+		stride, err := te.Store.Stride(te.Format, te.Type_, te.Width)
+		if err != nil || stride != te.Stride {
+			t.Errorf("%+v.Stride(%#x, %#x, %d) = %d, %v, expected %d", te.Store, te.Format, te.Type_, te.Width, stride, err, te.Stride)
+		}
+	}
+	if _, err := (PixelStore{Alignment: 3}).Stride(glRGB, glUNSIGNED_BYTE, 1); err == nil {
+		t.Errorf("Stride() with alignment 3 succeeded")
+	}
+	if _, err := (PixelStore{SkipRows: -1}).Size(glRGB, glUNSIGNED_BYTE, 1, 1); err == nil {
+		t.Errorf("Size() with negative skip rows succeeded")
+	}
+}
+
+func TestSize(t *testing.T) {
+	s := PixelStore{RowLength: 10, SkipPixels: 2, SkipRows: 1}
+	// 1 skipped row, 2 rows with a stride of 32, 2 skipped pixels and 3 pixels of the last row
+	if size, err := s.Size(glRGB, glUNSIGNED_BYTE, 3, 3); err != nil || size != 32+2*32+6+9 {
+		t.Errorf("Size() = %d, %v, expected %d", size, err, 32+2*32+6+9)
+	}
+	if size, err := (PixelStore{}).Size(glRGB, glUNSIGNED_BYTE, 5, 0); err != nil || size != 0 {
+		t.Errorf("Size() of an empty image = %d, %v", size, err)
+	}
+}
+
+// TestReadPixelsLayout decodes pixel data laid out as ReadPixels does with
+// the default PACK_ALIGNMENT of 4.
+func TestReadPixelsLayout(t *testing.T) {
+	pixels := []byte{
+		1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 0, 0, // bottom row, padded to 12 bytes
+		11, 12, 13, 14, 15, 16, 17, 18, 19,
+	}
+	img, err := ConvertPixelData(glRGB, glUNSIGNED_BYTE, StraightAlpha, PixelStore{}, pixels, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte{
+		11, 12, 13, 255, 14, 15, 16, 255, 17, 18, 19, 255,
+		1, 2, 3, 255, 4, 5, 6, 255, 7, 8, 9, 255,
+	}
+	if n := img.(*image.NRGBA); !bytes.Equal(n.Pix, expected) {
+		t.Errorf("ConvertPixelData() = %v, expected %v", n.Pix, expected)
+	}
+	if _, err := ConvertPixelData(glRGB, glUNSIGNED_BYTE, StraightAlpha, PixelStore{}, pixels[:20], 3, 2); err == nil {
+		t.Errorf("ConvertPixelData() with too little data succeeded")
+	}
+}
+
+var allTestsStoreRoundTrip = []PixelStore{
+	{},
+	TightlyPacked,
+	{Alignment: 8},
+	{Alignment: 2, RowLength: 9},
+	{RowLength: 8, SkipPixels: 3, SkipRows: 2},
+}
+
+// TestStoreRoundTrip compares round trips with pack parameters to a
+// tightly packed round trip, as some types are lossy.
+func TestStoreRoundTrip(t *testing.T) {
+	for _, width := range []int{1, 3, 5, 7} {
+		img := testImage(image.NewNRGBA(image.Rect(0, 0, width, 3))).(*image.NRGBA)
+		gray := testImage(image.NewGray16(image.Rect(0, 0, width, 3)))
+		for _, store := range allTestsStoreRoundTrip {
+			for _, te := range []struct {
+				Img           image.Image
+				Format, Type_ glt.Enum
+			}{
+				{img, glRGB, glUNSIGNED_BYTE},
+				{img, glRGBA, glUNSIGNED_BYTE},
+				{img, glBGR, glUNSIGNED_SHORT_5_6_5},
+				{gray, glRED, glUNSIGNED_SHORT},
+				{gray, glLUMINANCE, glUNSIGNED_BYTE},
+			} {
+				pixels, w, h, err := ConvertImage(te.Img, te.Format, te.Type_, StraightAlpha, store)
+				if err != nil {
+					t.Errorf("ConvertImage(%#x, %#x, %+v) failed: %s", te.Format, te.Type_, store, err)
+					continue
+				}
+				if size, _ := store.Size(te.Format, te.Type_, w, h); len(pixels) != size {
+					t.Errorf("ConvertImage(%#x, %#x, %+v) = %d bytes, expected %d", te.Format, te.Type_, store, len(pixels), size)
+				}
+				back, err := ConvertPixelData(te.Format, te.Type_, StraightAlpha, store, pixels, w, h)
+				if err != nil {
+					t.Errorf("ConvertPixelData(%#x, %#x, %+v) failed: %s", te.Format, te.Type_, store, err)
+					continue
+				}
+				tight, _, _, _ := ConvertImage(te.Img, te.Format, te.Type_, StraightAlpha, TightlyPacked)
+				expected, _ := ConvertPixelData(te.Format, te.Type_, StraightAlpha, TightlyPacked, tight, w, h)
+				if !sameColors(back, expected) {
+					t.Errorf("width %d, %#x, %#x, %+v: round trip changed the image", width, te.Format, te.Type_, store)
+				}
+			}
+		}
+	}
+	if _, _, _, err := ConvertImage(image.NewNRGBA(image.Rect(0, 0, 4, 1)), glRGBA, glUNSIGNED_BYTE, StraightAlpha, PixelStore{RowLength: 3}); err == nil {
+		t.Errorf("ConvertImage() with a row length less than the width succeeded")
+	}
+}
@@ -1,126 +1,319 @@
-package glutil
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+// Package gltex converts between Go images and the pixel data of GL
+// texture and read pixel commands. It works with any generated binding:
+// formats and types are returned as glt.Enum values that can be passed
+// to TexImage2D directly.
+//
+// GL expects the first row of the pixel data to be the bottom row of the
+// image, so all conversions flip the image vertically, unless GL.TopDown
+// lets Upload pass images as they are. Components wider
+// than a byte are stored in native byte order.
+//
+// Supported formats are RED, RG, RGB, BGR, RGBA, BGRA, ALPHA, LUMINANCE
+// and LUMINANCE_ALPHA with the types UNSIGNED_BYTE, UNSIGNED_SHORT,
+// HALF_FLOAT and FLOAT, as well as the packed types UNSIGNED_SHORT_5_6_5,
+// UNSIGNED_SHORT_4_4_4_4, UNSIGNED_SHORT_5_5_5_1 and
+// UNSIGNED_INT_2_10_10_10_REV. Floating point data is clamped to [0, 1].
+//
+// The row layout of pixel data is described by a PixelStore, which
+// mirrors the pack and unpack parameters of PixelStorei. GL.Upload
+// specifies a texture from an image through the functions of a generated
+// binding, setting and restoring the unpack parameters. GL.ReadImage
+// reads the framebuffer back into an image, AsyncReader does so through
+// pixel buffer objects without stalling.
+//
+// DecodeCompressed and EncodeCompressed convert the block compressed
+// S3TC, RGTC, ETC1, ETC2, EAC and ASTC formats in pure Go, to preview and
+// compare compressed textures without a GPU.
+package gltex
 
 import (
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"image"
 	"unsafe"
+
+	"github.com/chsc/gogl2/glt"
 )
 
+// ErrUnsupported is returned for pixel formats and types gltex can not
+// convert.
+var ErrUnsupported = errors.New("gltex: unsupported pixel format or type")
+
+// AlphaMode selects whether the color components of pixel data are
+// multiplied by alpha.
+type AlphaMode int
+
 const (
-	glRGB = 0
-	glUNSIGNED_BYTE = 0
-	glRGBA = 0
+	StraightAlpha AlphaMode = iota
+	PremultipliedAlpha
 )
 
-func ImageFromPixelData(format, type_ int32, pixels uintptr, w, h uint32) (img image.Image, err error) {
-	if format == glRGB && type_ == glUNSIGNED_BYTE {
-		dest := image.NewNRGBA(image.Rect(0, 0, int(w), int(h)))
-		src := (*[0xfffffff - 1]byte)((unsafe.Pointer)(pixels))[0:int(w*h*3)]
-		srcOffset := 0
-		destOffset := len(dest.Pix) - dest.Stride
-		// flip image
-		for srcOffset < len(src) {
-			for i := destOffset; i < destOffset+dest.Stride; i += 4 {
-				dest.Pix[i+0] = src[srcOffset+0]
-				dest.Pix[i+1] = src[srcOffset+1]
-				dest.Pix[i+2] = src[srcOffset+2]
-				dest.Pix[i+3] = 255
-				srcOffset += 3
-			}
-			destOffset -= dest.Stride
-		}
-		img = dest
-	} else if format == glRGBA && type_ == glUNSIGNED_BYTE {
-		dest := image.NewNRGBA(image.Rect(0, 0, int(w), int(h)))
-		src := (*[0xfffffff - 1]byte)(pixels)[0 : w*h*4]
-		destOffset := len(dest.Pix) - dest.Stride
-		// flip image
-		for srcOffset := 0; srcOffset < len(src); srcOffset += dest.Stride {
-			copy(dest.Pix[destOffset:destOffset+dest.Stride], src[srcOffset:srcOffset+dest.Stride])
-			destOffset -= dest.Stride
-		}
-		img = dest
-	} else { //TODO: add more
-		err = errors.New("image format not supported")
+var nativeEndian binary.ByteOrder = binary.LittleEndian
+
+func init() {
+	x := uint16(1)
+	if *(*byte)(unsafe.Pointer(&x)) == 0 {
+		nativeEndian = binary.BigEndian
 	}
-	return
 }
-func PixelDataFromImage(img image.Image) (internalFormat int32, format, type_ uint32, pixels uintptr, width, height uint32, err error) {
-	var pixelSize int = 0
-	var pix []uint8 = nil
-	var srcStride int = 0
+
+// swap16 converts the 16 bit components of pix between big endian, as
+// used by the image package, and native byte order in place.
+func swap16(pix []byte) {
+	if nativeEndian == binary.BigEndian {
+		return
+	}
+	for i := 0; i+1 < len(pix); i += 2 {
+		pix[i], pix[i+1] = pix[i+1], pix[i]
+	}
+}
+
+// imagePixels describes the pixel memory of the image types whose layout
+// GL understands, so that they can be copied without conversion.
+type imagePixels struct {
+	pix    []uint8
+	offset func(x, y int) int
+	format glt.Enum
+	type_  glt.Enum
+	alpha  AlphaMode
+	size   int // bytes per pixel
+}
+
+func imagePixelsOf(img image.Image) (p imagePixels, ok bool) {
 	switch i := img.(type) {
 	case *image.Alpha:
-		internalFormat = gl.ALPHA8
-		format = glALPHA
-		type_ = glUNSIGNED_BYTE
-		pixelSize = 1
-		pix = i.Pix
-		srcStride = i.Stride
+		p = imagePixels{i.Pix, i.PixOffset, glALPHA, glUNSIGNED_BYTE, StraightAlpha, 1}
 	case *image.Alpha16:
-		internalFormat = gl.ALPHA16
-		format = glALPHA
-		type_ = glUNSIGNED_SHORT
-		pixelSize = 2
-		pix = i.Pix
-		srcStride = i.Stride
+		p = imagePixels{i.Pix, i.PixOffset, glALPHA, glUNSIGNED_SHORT, StraightAlpha, 2}
 	case *image.Gray:
-		internalFormat = gl.R8 // luminance?
-		format = glRED
-		type_ = gl.UNSIGNED_BYTE
-		pixelSize = 1
-		pix = i.Pix
-		srcStride = i.Stride
+		p = imagePixels{i.Pix, i.PixOffset, glRED, glUNSIGNED_BYTE, StraightAlpha, 1}
 	case *image.Gray16:
-		internalFormat = gl.R16
-		format = glRED
-		type_ = glUNSIGNED_SHORT
-		pixelSize = 2
-		pix = i.Pix
-		srcStride = i.Stride
+		p = imagePixels{i.Pix, i.PixOffset, glRED, glUNSIGNED_SHORT, StraightAlpha, 2}
 	case *image.NRGBA:
-		internalFormat = gl.RGBA8
-		format = glRGBA
-		type_ = glUNSIGNED_BYTE
-		pixelSize = 4
-		pix = i.Pix
-		srcStride = i.Stride
+		p = imagePixels{i.Pix, i.PixOffset, glRGBA, glUNSIGNED_BYTE, StraightAlpha, 4}
 	case *image.NRGBA64:
-		internalFormat = glRGBA16
-		format = glRGBA
-		type_ = glUNSIGNED_SHORT
-		pixelSize = 8
-		pix = i.Pix
-		srcStride = i.Stride
+		p = imagePixels{i.Pix, i.PixOffset, glRGBA, glUNSIGNED_SHORT, StraightAlpha, 8}
 	case *image.RGBA:
-		internalFormat = glRGBA8
-		format = glRGBA
-		type_ = glUNSIGNED_BYTE
-		pixelSize = 4
-		pix = i.Pix
-		srcStride = i.Stride
-		fmt.Println("nanananananana Batman!")
+		p = imagePixels{i.Pix, i.PixOffset, glRGBA, glUNSIGNED_BYTE, PremultipliedAlpha, 4}
 	case *image.RGBA64:
+		p = imagePixels{i.Pix, i.PixOffset, glRGBA, glUNSIGNED_SHORT, PremultipliedAlpha, 8}
+	default:
+		return p, false
+	}
+	return p, true
+}
+
+// matches reports whether the pixels can be copied as pixel data of
+// format, type_ and alpha.
+func (p *imagePixels) matches(format, type_ glt.Enum, alpha AlphaMode) bool {
+	if format == glLUMINANCE {
+		format = glRED
+	}
+	return p.format == format && p.type_ == type_ && (p.alpha == alpha || p.format != glRGBA)
+}
+
+// set stores the channels of c in the pixel at offset o.
+func (p *imagePixels) set(o int, c *channels) {
+	chs := formatChannels[p.format]
+	deep := p.size > len(chs)
+	for i, ch := range chs {
+		v := c[ch]
+		if p.alpha == PremultipliedAlpha && ch != chA && v > c[chA] {
+			v = c[chA]
+		}
+		if deep {
+			p.pix[o+2*i], p.pix[o+2*i+1] = uint8(v>>8), uint8(v)
+		} else {
+			p.pix[o+i] = uint8(v >> 8)
+		}
+	}
+}
+
+// newImage returns an image that holds pixel data of layout l without
+// loss: an *image.Alpha for ALPHA data, an *image.Gray for RED and
+// LUMINANCE data, and an *image.NRGBA or, for premultiplied data, an
+// *image.RGBA for everything else, or the 16 bit variants of those for
+// components wider than 8 bits.
+func newImage(format glt.Enum, l *pixelLayout, alpha AlphaMode, r image.Rectangle) image.Image {
+	deep := l.deep()
+	switch {
+	case format == glALPHA && deep:
+		return image.NewAlpha16(r)
+	case format == glALPHA:
+		return image.NewAlpha(r)
+	case (format == glRED || format == glLUMINANCE) && deep:
+		return image.NewGray16(r)
+	case format == glRED || format == glLUMINANCE:
+		return image.NewGray(r)
+	case alpha == PremultipliedAlpha && deep:
+		return image.NewRGBA64(r)
+	case alpha == PremultipliedAlpha:
+		return image.NewRGBA(r)
+	case deep:
+		return image.NewNRGBA64(r)
+	}
+	return image.NewNRGBA(r)
+}
+
+// ImageFromPixelData converts tightly packed pixel data with straight
+// alpha into an image. See ConvertPixelData.
+func ImageFromPixelData(format, type_ glt.Enum, pixels []byte, width, height int) (image.Image, error) {
+	return ConvertPixelData(format, type_, StraightAlpha, TightlyPacked, pixels, width, height)
+}
+
+// ConvertPixelData converts pixel data as returned by ReadPixels or
+// GetTexImage with the pack parameters store into an image. RED and
+// LUMINANCE data gives an *image.Gray, ALPHA data an *image.Alpha and all
+// other formats an *image.NRGBA, or an *image.RGBA if alpha is
+// PremultipliedAlpha. The 16 bit variants of those are returned for
+// components wider than 8 bits. Components missing from format are 0,
+// alpha is 1.
+func ConvertPixelData(format, type_ glt.Enum, alpha AlphaMode, store PixelStore, pixels []byte, width, height int) (image.Image, error) {
+	if err := store.check(); err != nil {
+		return nil, err
+	}
+	l, err := newPixelLayout(format, type_)
+	if err != nil {
+		return nil, err
+	}
+	if width < 0 || height < 0 {
+		return nil, fmt.Errorf("gltex: invalid size %dx%d", width, height)
+	}
+	offset, srcStride, size := store.layout(l, width, height)
+	if len(pixels) < size {
+		return nil, fmt.Errorf("gltex: %d bytes of pixel data, %dx%d image needs %d", len(pixels), width, height, size)
+	}
+	img := newImage(format, l, alpha, image.Rect(0, 0, width, height))
+	dest, _ := imagePixelsOf(img)
+	lineLen := width * l.size
+	if dest.matches(format, type_, alpha) {
+		for y := 0; y < height; y++ {
+			src := offset + (height-1-y)*srcStride
+			line := dest.pix[dest.offset(0, y):][:lineLen]
+			copy(line, pixels[src:src+lineLen])
+			if type_ == glUNSIGNED_SHORT {
+				swap16(line)
+			}
+		}
+		return img, nil
+	}
+	luminance := l.hasChannel(chL)
+	for y := 0; y < height; y++ {
+		src := pixels[offset+(height-1-y)*srcStride:]
+		for x := 0; x < width; x++ {
+			c := channels{chA: 0xffff}
+			l.decode(src[x*l.size:], &c)
+			if luminance {
+				c[chR], c[chG], c[chB] = c[chL], c[chL], c[chL]
+			}
+			dest.set(dest.offset(x, y), &c)
+		}
+	}
+	return img, nil
+}
+
+// PixelDataFromImage converts an image into pixel data for TexImage2D.
+// It returns the internal format, format and type to pass along with the
+// tightly packed pixels, so UNPACK_ALIGNMENT must be 1 unless the rows
+// happen to be aligned. Alpha and gray images give ALPHA and LUMINANCE
+// data, all other images RGBA data with 8 or, for *image.NRGBA64 and
+// *image.RGBA64, 16 bit components. The data of *image.RGBA and
+// *image.RGBA64 images is premultiplied and passed unchanged, all other
+// images give straight alpha. Use ConvertImage to choose the format.
+//
+// The ALPHA and LUMINANCE formats, with the sized internal formats
+// ALPHA8, ALPHA16, LUMINANCE8 and LUMINANCE16, need GL 2.1 or a
+// compatibility profile. Core profiles reject them, use GL.Upload with
+// Core set there, which specifies RED textures with a swizzle instead.
+// OpenGL ES 2.0 only accepts the unsized internal formats, pass format
+// as the internal format there.
+func PixelDataFromImage(img image.Image) (internalFormat, format, type_ glt.Enum, pixels []byte, width, height int, err error) {
+	internalFormat, format, type_, alpha := imageFormat(img)
+	pixels, width, height, err = ConvertImage(img, format, type_, alpha, TightlyPacked)
+	return
+}
+
+// imageFormat returns the internal format, format, type and alpha mode
+// that hold the pixels of img without loss, see PixelDataFromImage.
+func imageFormat(img image.Image) (internalFormat, format, type_ glt.Enum, alpha AlphaMode) {
+	format, type_, alpha = glRGBA, glUNSIGNED_BYTE, StraightAlpha
+	if p, ok := imagePixelsOf(img); ok {
+		format, type_, alpha = p.format, p.type_, p.alpha
+	}
+	switch {
+	case format == glALPHA && type_ == glUNSIGNED_BYTE:
+		internalFormat = glALPHA8
+	case format == glALPHA:
+		internalFormat = glALPHA16
+	case format == glRED && type_ == glUNSIGNED_BYTE:
+		format, internalFormat = glLUMINANCE, glLUMINANCE8
+	case format == glRED:
+		format, internalFormat = glLUMINANCE, glLUMINANCE16
+	case type_ == glUNSIGNED_BYTE:
+		internalFormat = glRGBA8
+	default:
 		internalFormat = glRGBA16
-		format = glRGBA
-		type_ = glUNSIGNED_SHORT
-		pixelSize = 8
-		pix = i.Pix
-		srcStride = i.Stride
-	default: // TODO: add more
-		err = errors.New("image type not supported")
 	}
-	// flip image to GL format: first pixel is lower left corner
-	width, height = gl.Sizei(img.Bounds().Dx()), gl.Sizei(img.Bounds().Dy())
-	data := make([]byte, int(width*height)*pixelSize)
-	start := 0
-	end := len(pix)
-	fmt.Println(start, end, len(pix))
-	destOffset := len(data) - srcStride
-	for srcOffset := start; srcOffset < end; srcOffset += srcStride {
-		copy(data[destOffset:destOffset+srcStride], pix[srcOffset:srcOffset+srcStride])
-		destOffset -= srcStride
-	}
-	pixels = gl.Pointer(&data[0])
 	return
 }
 
+// ConvertImage converts any image into pixel data of format and type_ for
+// TexImage2D with the unpack parameters store. If alpha is
+// PremultipliedAlpha the color components are multiplied by alpha.
+// LUMINANCE is computed from the color components with the weights of
+// color.GrayModel. Skipped pixels and padding are zero.
+func ConvertImage(img image.Image, format, type_ glt.Enum, alpha AlphaMode, store PixelStore) (pixels []byte, width, height int, err error) {
+	return convertImage(img, format, type_, alpha, store, true)
+}
+
+// convertImage implements ConvertImage. The rows are flipped if flip is
+// set.
+func convertImage(img image.Image, format, type_ glt.Enum, alpha AlphaMode, store PixelStore, flip bool) (pixels []byte, width, height int, err error) {
+	if err := store.check(); err != nil {
+		return nil, 0, 0, err
+	}
+	l, err := newPixelLayout(format, type_)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	b := img.Bounds()
+	width, height = b.Dx(), b.Dy()
+	if store.RowLength != 0 && store.RowLength < width {
+		return nil, 0, 0, fmt.Errorf("gltex: row length %d is less than the width %d", store.RowLength, width)
+	}
+	offset, stride, size := store.layout(l, width, height)
+	lineLen := width * l.size
+	pixels = make([]byte, size)
+	// flip image to GL format: first pixel is lower left corner
+	row := func(y int) int {
+		if flip {
+			return b.Max.Y - 1 - y
+		}
+		return y - b.Min.Y
+	}
+	if p, ok := imagePixelsOf(img); ok && p.matches(format, type_, alpha) {
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			src := p.offset(b.Min.X, y)
+			line := pixels[offset+row(y)*stride:][:lineLen]
+			copy(line, p.pix[src:src+lineLen])
+			if type_ == glUNSIGNED_SHORT {
+				swap16(line)
+			}
+		}
+		return pixels, width, height, nil
+	}
+	var c channels
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		line := pixels[offset+row(y)*stride:]
+		for x := b.Min.X; x < b.Max.X; x++ {
+			colorChannels(img.At(x, y), alpha, &c)
+			l.encode(line[(x-b.Min.X)*l.size:], &c)
+		}
+	}
+	return pixels, width, height, nil
+}
@@ -0,0 +1,280 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package gltex
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/chsc/gogl2/glt"
+)
+
+// testImage fills img with a pattern of distinct opaque colors or, for
+// alpha images, distinct alpha values.
+func testImage(img interface {
+	image.Image
+	Set(x, y int, c color.Color)
+}) image.Image {
+	m := img.ColorModel()
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			v := uint16(x*7919 + y*104729)
+			if m == color.AlphaModel || m == color.Alpha16Model {
+				img.Set(x, y, color.Alpha16{v})
+			} else {
+				img.Set(x, y, color.NRGBA64{v, v ^ 0x5a5a, v + 0x1234, 0xffff})
+			}
+		}
+	}
+	return img
+}
+
+type roundTripTest struct {
+	Img                           image.Image
+	InternalFormat, Format, Type_ glt.Enum
+}
+
+var r = image.Rect(0, 0, 5, 3)
+
+var allTestsRoundTrip = []roundTripTest{
+	{testImage(image.NewAlpha(r)), glALPHA8, glALPHA, glUNSIGNED_BYTE},
+	{testImage(image.NewAlpha16(r)), glALPHA16, glALPHA, glUNSIGNED_SHORT},
+	{testImage(image.NewGray(r)), glLUMINANCE8, glLUMINANCE, glUNSIGNED_BYTE},
+	{testImage(image.NewGray16(r)), glLUMINANCE16, glLUMINANCE, glUNSIGNED_SHORT},
+	{testImage(image.NewNRGBA(r)), glRGBA8, glRGBA, glUNSIGNED_BYTE},
+	{testImage(image.NewNRGBA64(r)), glRGBA16, glRGBA, glUNSIGNED_SHORT},
+	{testImage(image.NewRGBA(r)), glRGBA8, glRGBA, glUNSIGNED_BYTE},
+	{testImage(image.NewRGBA64(r)), glRGBA16, glRGBA, glUNSIGNED_SHORT},
+	{testImage(image.NewNRGBA(image.Rect(0, 0, 8, 8))).(*image.NRGBA).SubImage(image.Rect(2, 3, 7, 6)), glRGBA8, glRGBA, glUNSIGNED_BYTE},
+}
+
+func sameColors(a, b image.Image) bool {
+	if a.Bounds().Dx() != b.Bounds().Dx() || a.Bounds().Dy() != b.Bounds().Dy() {
+		return false
+	}
+	for y := 0; y < a.Bounds().Dy(); y++ {
+		for x := 0; x < a.Bounds().Dx(); x++ {
+			r1, g1, b1, a1 := a.At(a.Bounds().Min.X+x, a.Bounds().Min.Y+y).RGBA()
+			r2, g2, b2, a2 := b.At(b.Bounds().Min.X+x, b.Bounds().Min.Y+y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 || a1 != a2 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, test := range allTestsRoundTrip {
+		internalFormat, format, type_, pixels, w, h, err := PixelDataFromImage(test.Img)
+		if err != nil {
+			t.Errorf("%T: PixelDataFromImage() failed: %s", test.Img, err)
+			continue
+		}
+		if internalFormat != test.InternalFormat || format != test.Format || type_ != test.Type_ {
+			t.Errorf("%T: PixelDataFromImage() = %#x, %#x, %#x, expected %#x, %#x, %#x", test.Img,
+				internalFormat, format, type_, test.InternalFormat, test.Format, test.Type_)
+		}
+		if w != 5 || h != 3 {
+			t.Errorf("%T: PixelDataFromImage() size = %dx%d", test.Img, w, h)
+		}
+		img, err := ImageFromPixelData(format, type_, pixels, w, h)
+		if err != nil {
+			t.Errorf("%T: ImageFromPixelData() failed: %s", test.Img, err)
+			continue
+		}
+		if !sameColors(img, test.Img) {
+			t.Errorf("%T: round trip changed the image", test.Img)
+		}
+	}
+}
+
+func TestPixelDataLayout(t *testing.T) {
+	img := image.NewGray16(image.Rect(0, 0, 2, 2))
+	img.SetGray16(0, 0, color.Gray16{0x0102}) // top left
+	img.SetGray16(1, 1, color.Gray16{0x0304}) // bottom right
+	_, _, _, pixels, _, _, err := PixelDataFromImage(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte{0, 0, 0x04, 0x03, 0x02, 0x01, 0, 0} // bottom row first, little endian
+	if nativeEndian.Uint16([]byte{1, 0}) != 1 {
+		expected = []byte{0, 0, 0x03, 0x04, 0x01, 0x02, 0, 0}
+	}
+	if !bytes.Equal(pixels, expected) {
+		t.Errorf("PixelDataFromImage() = %x, expected %x", pixels, expected)
+	}
+}
+
+func TestImageFromRGB(t *testing.T) {
+	pixels := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	img, err := ImageFromPixelData(glRGB, glUNSIGNED_BYTE, pixels, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte{7, 8, 9, 255, 10, 11, 12, 255, 1, 2, 3, 255, 4, 5, 6, 255}
+	if n := img.(*image.NRGBA); !bytes.Equal(n.Pix, expected) {
+		t.Errorf("ImageFromPixelData() = %v, expected %v", n.Pix, expected)
+	}
+	if _, err := ImageFromPixelData(glRGB, glUNSIGNED_BYTE, pixels, 3, 2); err == nil {
+		t.Errorf("ImageFromPixelData() with too little data succeeded")
+	}
+	if _, err := ImageFromPixelData(0x1234, glUNSIGNED_BYTE, pixels, 2, 2); err != ErrUnsupported {
+		t.Errorf("ImageFromPixelData() of unknown format = %v", err)
+	}
+	if _, err := ImageFromPixelData(glRGB, glUNSIGNED_SHORT_4_4_4_4, pixels, 2, 2); err != ErrUnsupported {
+		t.Errorf("ImageFromPixelData() of 4 packed components for RGB = %v", err)
+	}
+}
+
+// sameColorsIn reports whether b holds the colors of a converted to m.
+func sameColorsIn(m color.Model, a, b image.Image) bool {
+	if a.Bounds().Dx() != b.Bounds().Dx() || a.Bounds().Dy() != b.Bounds().Dy() {
+		return false
+	}
+	for y := 0; y < a.Bounds().Dy(); y++ {
+		for x := 0; x < a.Bounds().Dx(); x++ {
+			r1, g1, b1, a1 := m.Convert(a.At(a.Bounds().Min.X+x, a.Bounds().Min.Y+y)).RGBA()
+			r2, g2, b2, a2 := b.At(b.Bounds().Min.X+x, b.Bounds().Min.Y+y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 || a1 != a2 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func testPaletted() image.Image {
+	p := color.Palette{color.NRGBA{0, 0, 0, 0}, color.NRGBA{0xff, 0x80, 0, 0x80}, color.RGBA{0x10, 0x20, 0x30, 0xff}}
+	img := image.NewPaletted(r, p)
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i % len(p))
+	}
+	return img
+}
+
+func testYCbCr(img *image.YCbCr) *image.YCbCr {
+	for i := range img.Y {
+		img.Y[i] = uint8(i * 37)
+	}
+	for i := range img.Cb {
+		img.Cb[i], img.Cr[i] = uint8(i*53), uint8(255-i*29)
+	}
+	return img
+}
+
+func testNYCbCrA() image.Image {
+	img := image.NewNYCbCrA(r, image.YCbCrSubsampleRatio422)
+	testYCbCr(&img.YCbCr)
+	for i := range img.A {
+		img.A[i] = uint8(i * 71)
+	}
+	return img
+}
+
+var allTestsStandardImage = []image.Image{
+	testYCbCr(image.NewYCbCr(r, image.YCbCrSubsampleRatio420)),
+	testYCbCr(image.NewYCbCr(image.Rect(0, 0, 8, 8), image.YCbCrSubsampleRatio444)).SubImage(image.Rect(1, 2, 6, 5)),
+	testNYCbCrA(),
+	testImage(image.NewCMYK(r)),
+	testPaletted(),
+}
+
+func TestStandardImages(t *testing.T) {
+	for _, img := range allTestsStandardImage {
+		internalFormat, format, type_, pixels, w, h, err := PixelDataFromImage(img)
+		if err != nil {
+			t.Errorf("%T: PixelDataFromImage() failed: %s", img, err)
+			continue
+		}
+		if internalFormat != glRGBA8 || format != glRGBA || type_ != glUNSIGNED_BYTE || w != 5 || h != 3 {
+			t.Errorf("%T: PixelDataFromImage() = %#x, %#x, %#x, %dx%d", img, internalFormat, format, type_, w, h)
+		}
+		back, err := ImageFromPixelData(format, type_, pixels, w, h)
+		if err != nil {
+			t.Errorf("%T: ImageFromPixelData() failed: %s", img, err)
+			continue
+		}
+		if !sameColorsIn(back.ColorModel(), img, back) {
+			t.Errorf("%T: round trip changed the image", img)
+		}
+	}
+}
+
+type convertTest struct {
+	Format, Type_ glt.Enum
+	Alpha         AlphaMode
+	Model         color.Model // of the result
+}
+
+var allTestsConvert = []convertTest{
+	{glBGRA, glUNSIGNED_BYTE, StraightAlpha, color.NRGBAModel},
+	{glBGRA, glUNSIGNED_BYTE, PremultipliedAlpha, color.RGBAModel},
+	{glRGBA, glUNSIGNED_SHORT, PremultipliedAlpha, color.RGBA64Model},
+	{glBGR, glUNSIGNED_BYTE, StraightAlpha, color.NRGBAModel},
+	{glRGBA, glFLOAT, StraightAlpha, color.NRGBA64Model},
+	{glRGBA, glUNSIGNED_INT_2_10_10_10_REV, StraightAlpha, color.NRGBA64Model},
+	{glRGB, glUNSIGNED_SHORT_5_6_5, StraightAlpha, color.NRGBAModel},
+	{glLUMINANCE, glUNSIGNED_BYTE, StraightAlpha, color.GrayModel},
+	{glLUMINANCE, glHALF_FLOAT, StraightAlpha, color.Gray16Model},
+	{glLUMINANCE_ALPHA, glUNSIGNED_BYTE, StraightAlpha, color.NRGBAModel},
+	{glALPHA, glFLOAT, StraightAlpha, color.Alpha16Model},
+}
+
+func TestConvert(t *testing.T) {
+	// colors every format and type can represent exactly
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	for i, c := range []color.NRGBA{{0, 0, 0, 0xff}, {0xff, 0xff, 0xff, 0xff}, {0xff, 0, 0, 0xff}, {0, 0xff, 0, 0}, {0, 0, 0xff, 0}, {0xff, 0xff, 0xff, 0}} {
+		img.SetNRGBA(i%3, i/3, c)
+	}
+	for _, te := range allTestsConvert {
+		pixels, w, h, err := ConvertImage(img, te.Format, te.Type_, te.Alpha, TightlyPacked)
+		if err != nil {
+			t.Errorf("ConvertImage(%#x, %#x) failed: %s", te.Format, te.Type_, err)
+			continue
+		}
+		back, err := ConvertPixelData(te.Format, te.Type_, te.Alpha, TightlyPacked, pixels, w, h)
+		if err != nil {
+			t.Errorf("ConvertPixelData(%#x, %#x) failed: %s", te.Format, te.Type_, err)
+			continue
+		}
+		if back.ColorModel() != te.Model {
+			t.Errorf("ConvertPixelData(%#x, %#x) = %T", te.Format, te.Type_, back)
+		}
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				var e, a channels
+				colorChannels(img.At(x, y), te.Alpha, &e)
+				colorChannels(back.At(x, y), te.Alpha, &a)
+				switch te.Format {
+				case glALPHA:
+					// alpha images have no color
+					e[chR], e[chG], e[chB] = a[chR], a[chG], a[chB]
+				case glLUMINANCE, glLUMINANCE_ALPHA:
+					// luminance is quantized
+					if d := int(a[chL]) - int(e[chL]); d >= -0x101 && d <= 0x101 {
+						e[chL] = a[chL]
+					}
+					e[chR], e[chG], e[chB] = e[chL], e[chL], e[chL]
+					if te.Format == glLUMINANCE {
+						e[chA] = 0xffff
+					}
+				case glRGB, glBGR:
+					e[chA] = 0xffff
+				}
+				if te.Alpha == StraightAlpha && a[chA] == 0 {
+					e[chR], e[chG], e[chB], e[chL] = a[chR], a[chG], a[chB], a[chL] // undefined
+				}
+				e[chL] = a[chL]
+				if e != a {
+					t.Errorf("ConvertImage(%#x, %#x) at %d,%d: %v, expected %v", te.Format, te.Type_, x, y, a, e)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package gltex
+
+import (
+	"encoding/binary"
+	"image"
+	"runtime"
+
+	"github.com/chsc/gogl2/glt"
+)
+
+// GL holds the functions of a generated binding that the upload and
+// readback helpers call, so that gltex works with any of them. Only the
+// functions used by a method need to be set:
+//
+//	up := &gltex.GL{
+//		TexImage2D:  gl.TexImage2D,
+//		PixelStorei: gl.PixelStorei,
+//		GetIntegerv: gl.GetIntegerv,
+//	}
+//	err := up.Upload(gl.TEXTURE_2D, 0, img)
+type GL struct {
+	TexImage2D  func(target glt.Enum, level, internalFormat, width, height, border int32, format, type_ glt.Enum, pixels glt.Pointer)
+	PixelStorei func(pname glt.Enum, param int32)
+	GetIntegerv func(pname glt.Enum, data *int32)
+
+	// Core is set for core profiles and OpenGL ES 3.0, which lack the
+	// ALPHA and LUMINANCE formats. Alpha and gray images are then
+	// uploaded as RED textures with a texture swizzle, which needs GL 3.3
+	// or ARB_texture_swizzle and TexParameteri.
+	Core          bool
+	TexParameteri func(target, pname glt.Enum, param int32)
+
+	// TopDown makes Upload keep the top row of images at t = 0, as for a
+	// Texture with TopDown set, so the t texture coordinate has to be
+	// flipped. In exchange images GL can read directly are passed without
+	// a copy.
+	TopDown bool
+
+	// ReadImage, ReadImage64 and AsyncReader
+	ReadPixels func(x, y, width, height int32, format, type_ glt.Enum, pixels glt.Pointer)
+
+	// SelectReadBuffer
+	BindFramebuffer func(target glt.Enum, framebuffer uint32)
+	ReadBuffer      func(src glt.Enum)
+
+	// AsyncReader, needs GL 3.2 or ARB_sync
+	GenBuffers     func(n int32, buffers *uint32)
+	DeleteBuffers  func(n int32, buffers *uint32)
+	BindBuffer     func(target glt.Enum, buffer uint32)
+	BufferData     func(target glt.Enum, size int, data glt.Pointer, usage glt.Enum)
+	MapBuffer      func(target, access glt.Enum) glt.Pointer
+	UnmapBuffer    func(target glt.Enum) bool
+	FenceSync      func(condition glt.Enum, flags glt.Bitfield) glt.Pointer
+	ClientWaitSync func(sync glt.Pointer, flags glt.Bitfield, timeout uint64) glt.Enum
+	DeleteSync     func(sync glt.Pointer)
+}
+
+var unpackParams = [4]glt.Enum{glUNPACK_ALIGNMENT, glUNPACK_ROW_LENGTH, glUNPACK_SKIP_PIXELS, glUNPACK_SKIP_ROWS}
+
+// Upload specifies level of the texture bound to target from img with
+// TexImage2D. The internal format, format and type are chosen as by
+// PixelDataFromImage, unless Core is set: then alpha and gray images give
+// R8 or R16 textures whose swizzle reads them as ALPHA or LUMINANCE. The
+// unpack parameters are set for the data and restored afterwards.
+//
+// GL can not flip rows while unpacking, so Upload passes a flipped copy
+// of the pixels, with the top row of the image at t = 1, unless TopDown
+// is set. Then the pixels of *image.Alpha, *image.Gray, *image.NRGBA and
+// *image.RGBA images, and on big endian machines of their 16 bit
+// variants, are passed to GL as they are, with UNPACK_ROW_LENGTH set to
+// the stride of the image. UNPACK_ROW_LENGTH needs desktop GL or OpenGL
+// ES 3.0, it is only set for sub-images whose rows are not contiguous.
+func (gl *GL) Upload(target glt.Enum, level int, img image.Image) error {
+	internalFormat, format, type_, alpha := imageFormat(img)
+	b := img.Bounds()
+	if p, ok := imagePixelsOf(img); ok && gl.TopDown && (type_ == glUNSIGNED_BYTE || nativeEndian == binary.BigEndian) {
+		o := p.offset(b.Min.X, b.Min.Y)
+		stride := p.offset(b.Min.X, b.Min.Y+1) - o
+		store := PixelStore{Alignment: 8}
+		for stride%store.Alignment != 0 {
+			store.Alignment /= 2
+		}
+		if stride != b.Dx()*p.size {
+			store.RowLength = stride / p.size
+		}
+		var pixels []byte
+		if !b.Empty() {
+			pixels = p.pix[o:]
+		}
+		gl.texImage2D(target, level, internalFormat, format, type_, b.Dx(), b.Dy(), pixels, store)
+		return nil
+	}
+	pixels, width, height, err := convertImage(img, format, type_, alpha, TightlyPacked, !gl.TopDown)
+	if err != nil {
+		return err
+	}
+	gl.texImage2D(target, level, internalFormat, format, type_, width, height, pixels, TightlyPacked)
+	return nil
+}
+
+func (gl *GL) texImage2D(target glt.Enum, level int, internalFormat, format, type_ glt.Enum, width, height int, pixels []byte, store PixelStore) {
+	if gl.Core && (format == glALPHA || format == glLUMINANCE) {
+		internalFormat = glR8
+		if type_ == glUNSIGNED_SHORT {
+			internalFormat = glR16
+		}
+		gl.swizzle(target, format)
+		format = glRED
+	}
+	restore := gl.pixelStore(&unpackParams, store)
+	gl.TexImage2D(target, int32(level), int32(internalFormat), int32(width), int32(height), 0, format, type_, glt.SlicePtr(pixels))
+	runtime.KeepAlive(pixels)
+	restore()
+}
+
+// swizzle sets the texture swizzle of the texture bound to target, so
+// that its RED component is read as format, ALPHA or LUMINANCE.
+func (gl *GL) swizzle(target, format glt.Enum) {
+	if target >= glTEXTURE_CUBE_MAP_POSITIVE_X && target <= glTEXTURE_CUBE_MAP_NEGATIVE_Z {
+		target = glTEXTURE_CUBE_MAP
+	}
+	rgba := [4]glt.Enum{glRED, glRED, glRED, glONE}
+	if format == glALPHA {
+		rgba = [4]glt.Enum{glZERO, glZERO, glZERO, glRED}
+	}
+	for i, c := range rgba {
+		gl.TexParameteri(target, glTEXTURE_SWIZZLE_R+glt.Enum(i), int32(c))
+	}
+}
+
+// pixelStore sets the parameters params, the alignment, row length, skip
+// pixels and skip rows parameters of PixelStorei, to store where they
+// differ from the current state. It returns a function that restores the
+// changed parameters.
+func (gl *GL) pixelStore(params *[4]glt.Enum, store PixelStore) (restore func()) {
+	values := [4]int32{int32(store.alignment()), int32(store.RowLength), int32(store.SkipPixels), int32(store.SkipRows)}
+	var old [4]int32
+	var changed [4]bool
+	for i, pname := range params {
+		gl.GetIntegerv(pname, &old[i])
+		if old[i] != values[i] {
+			gl.PixelStorei(pname, values[i])
+			changed[i] = true
+		}
+	}
+	return func() {
+		for i, pname := range params {
+			if changed[i] {
+				gl.PixelStorei(pname, old[i])
+			}
+		}
+	}
+}
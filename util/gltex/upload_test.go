@@ -0,0 +1,155 @@
+// Copyright 2013 The GoGL2 Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.mkd file.
+
+package gltex
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/chsc/gogl2/glt"
+)
+
+// fakeGL records the pixel store state, the texture parameters and the
+// arguments of TexImage2D.
+type fakeGL struct {
+	state                         map[glt.Enum]int32
+	params                        map[glt.Enum]map[glt.Enum]int32 // parameters of each target
+	internalFormat, width, height int32
+	format, type_                 glt.Enum
+	pixels                        glt.Pointer
+	unpack                        [4]int32 // unpack state during TexImage2D
+}
+
+func newFakeGL(state map[glt.Enum]int32) (*fakeGL, *GL) {
+	f := &fakeGL{state: map[glt.Enum]int32{glUNPACK_ALIGNMENT: 4}, params: map[glt.Enum]map[glt.Enum]int32{}}
+	for pname, v := range state {
+		f.state[pname] = v
+	}
+	return f, &GL{
+		TexImage2D: func(target glt.Enum, level, internalFormat, width, height, border int32, format, type_ glt.Enum, pixels glt.Pointer) {
+			f.internalFormat, f.width, f.height = internalFormat, width, height
+			f.format, f.type_, f.pixels = format, type_, pixels
+			for i, pname := range unpackParams {
+				f.unpack[i] = f.state[pname]
+			}
+		},
+		PixelStorei: func(pname glt.Enum, param int32) { f.state[pname] = param },
+		GetIntegerv: func(pname glt.Enum, data *int32) { *data = f.state[pname] },
+		TexParameteri: func(target, pname glt.Enum, param int32) {
+			if f.params[target] == nil {
+				f.params[target] = map[glt.Enum]int32{}
+			}
+			f.params[target][pname] = param
+		},
+	}
+}
+
+type uploadTest struct {
+	Img     image.Image
+	TopDown bool
+	State   map[glt.Enum]int32 // state before the upload
+	Unpack  [4]int32           // alignment, row length, skip pixels, skip rows
+	NoCopy  bool
+}
+
+var (
+	nrgba    = testImage(image.NewNRGBA(image.Rect(0, 0, 8, 8))).(*image.NRGBA)
+	gray     = testImage(image.NewGray(image.Rect(0, 0, 6, 4))).(*image.Gray)
+	gray16   = testImage(image.NewGray16(image.Rect(0, 0, 6, 4)))
+	paletted = image.NewPaletted(image.Rect(0, 0, 3, 3), []color.Color{color.Black})
+)
+
+var allTestsUpload = []uploadTest{
+	{nrgba, false, nil, [4]int32{1, 0, 0, 0}, false},
+	{nrgba, true, nil, [4]int32{8, 0, 0, 0}, true},
+	{nrgba.SubImage(image.Rect(2, 3, 7, 6)), true, nil, [4]int32{8, 8, 0, 0}, true},
+	{gray, true, nil, [4]int32{2, 0, 0, 0}, true},
+	{gray.SubImage(image.Rect(1, 1, 4, 3)), true, nil, [4]int32{2, 6, 0, 0}, true},
+	{gray16, true, nil, [4]int32{1, 0, 0, 0}, nativeEndian == binary.BigEndian},
+	{paletted, true, nil, [4]int32{1, 0, 0, 0}, false},
+	{nrgba, true, map[glt.Enum]int32{glUNPACK_ALIGNMENT: 1, glUNPACK_ROW_LENGTH: 3, glUNPACK_SKIP_ROWS: 2}, [4]int32{8, 0, 0, 0}, true},
+	{image.NewNRGBA(image.Rect(0, 0, 0, 0)), false, nil, [4]int32{1, 0, 0, 0}, false},
+}
+
+func TestUpload(t *testing.T) {
+	for i, test := range allTestsUpload {
+		f, gl := newFakeGL(test.State)
+		before := map[glt.Enum]int32{}
+		for pname, v := range f.state {
+			before[pname] = v
+		}
+		gl.TopDown = test.TopDown
+		if err := gl.Upload(glTEXTURE_2D, 0, test.Img); err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		internalFormat, format, type_, _ := imageFormat(test.Img)
+		b := test.Img.Bounds()
+		if glt.Enum(f.internalFormat) != internalFormat || f.format != format || f.type_ != type_ {
+			t.Errorf("test %d: formats %#x %#x %#x, want %#x %#x %#x", i, f.internalFormat, f.format, f.type_, internalFormat, format, type_)
+		}
+		if int(f.width) != b.Dx() || int(f.height) != b.Dy() {
+			t.Errorf("test %d: size %dx%d", i, f.width, f.height)
+		}
+		if f.unpack != test.Unpack {
+			t.Errorf("test %d: unpack state %v, want %v", i, f.unpack, test.Unpack)
+		}
+		if p, ok := imagePixelsOf(test.Img); ok && !b.Empty() && test.NoCopy != (f.pixels == glt.Ptr(&p.pix[p.offset(b.Min.X, b.Min.Y)])) {
+			t.Errorf("test %d: pixels passed without copy = %v", i, !test.NoCopy)
+		}
+		if b.Empty() != (f.pixels == nil) {
+			t.Errorf("test %d: pixels %p", i, f.pixels)
+		}
+		for pname, v := range f.state {
+			if before[pname] != v {
+				t.Errorf("test %d: %#x = %d, not restored to %d", i, pname, v, before[pname])
+			}
+		}
+	}
+}
+
+type uploadCoreTest struct {
+	Img                    image.Image
+	Target                 glt.Enum
+	InternalFormat, Format glt.Enum
+	Swizzle                [4]glt.Enum // zero if not set
+}
+
+var allTestsUploadCore = []uploadCoreTest{
+	{gray, glTEXTURE_2D, glR8, glRED, [4]glt.Enum{glRED, glRED, glRED, glONE}},
+	{gray16, glTEXTURE_2D, glR16, glRED, [4]glt.Enum{glRED, glRED, glRED, glONE}},
+	{image.NewAlpha(image.Rect(0, 0, 2, 2)), glTEXTURE_CUBE_MAP_POSITIVE_X + 2, glR8, glRED, [4]glt.Enum{glZERO, glZERO, glZERO, glRED}},
+	{image.NewAlpha16(image.Rect(0, 0, 2, 2)), glTEXTURE_2D, glR16, glRED, [4]glt.Enum{glZERO, glZERO, glZERO, glRED}},
+	{nrgba, glTEXTURE_2D, glRGBA8, glRGBA, [4]glt.Enum{}},
+}
+
+func TestUploadCore(t *testing.T) {
+	for i, test := range allTestsUploadCore {
+		for _, topDown := range []bool{false, true} {
+			f, gl := newFakeGL(nil)
+			gl.Core, gl.TopDown = true, topDown
+			if err := gl.Upload(test.Target, 0, test.Img); err != nil {
+				t.Errorf("test %d: top down %v: %v", i, topDown, err)
+				continue
+			}
+			if glt.Enum(f.internalFormat) != test.InternalFormat || f.format != test.Format {
+				t.Errorf("test %d: top down %v: formats %#x %#x, want %#x %#x", i, topDown, f.internalFormat, f.format, test.InternalFormat, test.Format)
+			}
+			target := test.Target
+			if target != glTEXTURE_2D {
+				target = glTEXTURE_CUBE_MAP
+			}
+			var swizzle [4]glt.Enum
+			for j := range swizzle {
+				swizzle[j] = glt.Enum(f.params[target][glTEXTURE_SWIZZLE_R+glt.Enum(j)])
+			}
+			if swizzle != test.Swizzle || len(f.params) > 1 {
+				t.Errorf("test %d: top down %v: swizzle %#x, want %#x", i, topDown, swizzle, test.Swizzle)
+			}
+		}
+	}
+}
@@ -38,6 +38,24 @@ var allTestsCamelCase = []testCamelCase{
 	{"1_2_", "12"},
 }
 
+var allTestsFeatureFileName = []testCamelCase{
+	{"GL_VERSION_1_0", "gl_version_1_0.go"},
+	{"GL_ARB_debug_output", "gl_arb_debug_output.go"},
+	{"GL_EXT_foo_windows", "gl_ext_foo_windows_cmds.go"},
+	{"GL_ARM_shader_framebuffer_fetch", "gl_arm_shader_framebuffer_fetch.go"},
+	{"GL_NV_arm", "gl_nv_arm_cmds.go"},
+}
+
+func TestFeatureFileName(t *testing.T) {
+	for i := range allTestsFeatureFileName {
+		te := &allTestsFeatureFileName[i]
+		fn := featureFileName(te.In)
+		if fn != te.Out {
+			t.Errorf("featureFileName() failed: %s -> %s (%s != %s)", te.In, te.Out, fn, te.Out)
+		}
+	}
+}
+
 func TestCamelCase(t *testing.T) {
 	for i := range allTestsCamelCase {
 		te := &allTestsCamelCase[i]
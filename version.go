@@ -55,3 +55,63 @@ func (v Version) Valid() bool {
 func (v Version) String() string {
 	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
 }
+
+// VersionSelector selects a single version, a range of versions such as
+// "3.3-4.6" or the latest version of an API ("latest"). Ranges and latest
+// are resolved against the features of the registry.
+type VersionSelector struct {
+	Min    Version
+	Max    Version
+	Latest bool
+}
+
+func ParseVersionSelector(selector string) (VersionSelector, error) {
+	if selector == "latest" {
+		return VersionSelector{Latest: true}, nil
+	}
+	bounds := strings.SplitN(selector, "-", 2)
+	min, err := ParseVersion(bounds[0])
+	if err != nil {
+		return VersionSelector{}, err
+	}
+	max := min
+	if len(bounds) == 2 {
+		if max, err = ParseVersion(bounds[1]); err != nil {
+			return VersionSelector{}, err
+		}
+		if max.Compare(min) < 0 {
+			return VersionSelector{}, fmt.Errorf("Invalid version range: '%s'.", selector)
+		}
+	}
+	return VersionSelector{Min: min, Max: max}, nil
+}
+
+// Single reports whether the selector selects exactly one version.
+func (vs VersionSelector) Single() bool {
+	return !vs.Latest && vs.Min.Compare(vs.Max) == 0
+}
+
+// Select returns the versions of all that the selector selects.
+func (vs VersionSelector) Select(all []Version) []Version {
+	selected := make([]Version, 0, len(all))
+	for _, v := range all {
+		if vs.Latest {
+			if len(selected) == 0 || v.Compare(selected[0]) > 0 {
+				selected = append(selected[:0], v)
+			}
+		} else if v.Compare(vs.Min) >= 0 && v.Compare(vs.Max) <= 0 {
+			selected = append(selected, v)
+		}
+	}
+	return selected
+}
+
+func (vs VersionSelector) String() string {
+	switch {
+	case vs.Latest:
+		return "latest"
+	case vs.Single():
+		return vs.Min.String()
+	}
+	return vs.Min.String() + "-" + vs.Max.String()
+}
@@ -5,6 +5,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -40,3 +41,40 @@ func TestVersion(t *testing.T) {
 		}
 	}
 }
+
+type versionSelectorTest struct {
+	In  string
+	Out string // selected versions of 1.0, 2.0, 2.1, 3.3, 4.6 or error
+}
+
+var versionSelectorTests = []versionSelectorTest{
+	{"2.1", "2.1"},
+	{"2.2", ""},
+	{"latest", "4.6"},
+	{"2.0-3.3", "2.0 2.1 3.3"},
+	{"1.5-5.0", "2.0 2.1 3.3 4.6"},
+	{"3.3-2.0", "error"},
+	{"3.3-", "error"},
+	{"lates", "error"},
+}
+
+func TestVersionSelector(t *testing.T) {
+	all := []Version{{1, 0}, {2, 0}, {2, 1}, {3, 3}, {4, 6}}
+	for _, test := range versionSelectorTests {
+		vs, err := ParseVersionSelector(test.In)
+		out := "error"
+		if err == nil {
+			s := make([]string, 0, len(all))
+			for _, v := range vs.Select(all) {
+				s = append(s, v.String())
+			}
+			out = strings.Join(s, " ")
+			if vs.String() != test.In {
+				t.Errorf("%s: String() = %s", test.In, vs)
+			}
+		}
+		if out != test.Out {
+			t.Errorf("%s: selected %q, expected %q", test.In, out, test.Out)
+		}
+	}
+}